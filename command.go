@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/WindowsSov8forUs/glyccat/config"
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/WindowsSov8forUs/glyccat/processor"
+)
+
+// runCommand 执行子命令，返回退出码
+func runCommand(args []string, configPath string) int {
+	switch args[0] {
+	case "config":
+		return runConfigCommand(args[1:], configPath)
+	default:
+		fmt.Fprintf(os.Stderr, "未知的命令 %q\n", args[0])
+		return 2
+	}
+}
+
+// runConfigCommand 执行 config 子命令
+func runConfigCommand(args []string, configPath string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "用法: glyccat config check [--config <配置文件路径>]")
+		return 2
+	}
+
+	flagSet := flag.NewFlagSet("config check", flag.ContinueOnError)
+	flagSet.StringVar(&configPath, "config", configPath, "配置文件路径")
+	if err := flagSet.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	diagnostics, err := config.Check(configPath, config.CheckOptions{
+		IsKnownIntent: processor.IsKnownIntent,
+	})
+	if err != nil {
+		fmt.Printf("%s %v\n", log.FailMark, log.Red(fmt.Sprint(err)))
+		return 1
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == config.SeverityError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+		fmt.Println(diagnostic.Format(configPath))
+	}
+
+	if errorCount > 0 {
+		fmt.Printf("%s 配置检查未通过: %d 个错误, %d 个警告\n", log.FailMark, errorCount, warningCount)
+		return 1
+	}
+	fmt.Printf("%s 配置检查通过: %d 个警告\n", log.SuccessMark, warningCount)
+	return 0
+}
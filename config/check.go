@@ -0,0 +1,568 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+)
+
+// Severity 诊断级别
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // 错误，配置无法正常使用
+	SeverityWarning Severity = "warning" // 警告，配置可以使用但可能不符合预期
+)
+
+// Diagnostic 配置诊断信息
+type Diagnostic struct {
+	Severity   Severity // 诊断级别
+	Line       int      // 所在行号，无法确定时为 0
+	Key        string   // 配置键，无法确定时为空
+	Message    string   // 诊断信息
+	Suggestion string   // 修复建议，可以为空
+}
+
+// Format 格式化为 文件:行号: 级别: 配置键: 信息 的形式
+func (d Diagnostic) Format(path string) string {
+	var builder strings.Builder
+	builder.WriteString(path)
+	if d.Line > 0 {
+		builder.WriteString(":" + strconv.Itoa(d.Line))
+	}
+	builder.WriteString(": " + string(d.Severity) + ": ")
+	if d.Key != "" {
+		builder.WriteString(d.Key + ": ")
+	}
+	builder.WriteString(d.Message)
+	if d.Suggestion != "" {
+		builder.WriteString("\n    建议: " + d.Suggestion)
+	}
+	return builder.String()
+}
+
+// CheckOptions 配置检查选项
+type CheckOptions struct {
+	IsKnownIntent func(name string) bool // 判断事件名称是否可以订阅，为 nil 时不检查事件名称
+}
+
+// intentAliases 常见的错误事件名称与对应的正确名称，正确名称为空表示不支持该事件
+var intentAliases = map[string]string{
+	"USER_MESSAGES":                "GROUP_AND_C2C_EVENT",
+	"GROUP_MESSAGES":               "GROUP_AND_C2C_EVENT",
+	"C2C_MESSAGES":                 "GROUP_AND_C2C_EVENT",
+	"DIRECT_MESSAGES":              "DIRECT_MESSAGE",
+	"OPEN_FORUMS_EVENT":            "",
+	"AUDIO_OR_LIVE_CHANNEL_MEMBER": "",
+}
+
+var (
+	yamlLinePattern   = regexp.MustCompile(`line (\d+)`)
+	intentNamePattern = regexp.MustCompile(`"([A-Z_]+)"`)
+)
+
+// Check 检查配置文件，返回按行号排序的诊断信息
+//
+// 检查 YAML 语法、类型、缺失与无效的配置项、取值范围、URL 格式、事件名称与互斥的配置项，
+// 环境变量覆盖后的配置同样会被检查
+func Check(path string, opts CheckOptions) ([]Diagnostic, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	c := &checker{
+		opts:  opts,
+		lines: make(map[string]int),
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		c.checkSyntax(data, err)
+		return c.result(), nil
+	}
+	if len(root.Content) == 0 {
+		c.add(SeverityError, "", "配置文件为空", "使用 config.yml 模板重新生成配置文件")
+		return c.result(), nil
+	}
+	c.indexNode(root.Content[0], "")
+
+	// 在默认配置上解析，类型错误不会中断解析
+	conf := DefaultConfig()
+	if err := root.Decode(conf); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			c.add(SeverityError, "", err.Error(), "")
+			return c.result(), nil
+		}
+		for _, message := range typeErr.Errors {
+			line := yamlErrorLine(message)
+			message = yamlLinePattern.ReplaceAllString(message, "")
+			message = strings.TrimPrefix(message, ": ")
+			c.addAtLine(SeverityError, line, c.keyAtLine(line), message, "修改为与模板中相同类型的值")
+		}
+	}
+
+	c.checkKeys(&root)
+
+	overridden, err := applyEnvOverrides(conf)
+	if err != nil {
+		c.add(SeverityError, "", err.Error(), "")
+	}
+	c.overridden = overridden
+
+	c.checkValues(conf)
+	return c.result(), nil
+}
+
+// checker 配置检查器
+type checker struct {
+	opts        CheckOptions
+	lines       map[string]int // 配置键所在的行号
+	overridden  []string       // 被环境变量覆盖的配置键
+	diagnostics []Diagnostic
+}
+
+// add 添加配置键上的诊断信息
+func (c *checker) add(severity Severity, key, message, suggestion string) {
+	line := c.lines[key]
+	if key != "" && line > 0 {
+		for _, overriddenKey := range c.overridden {
+			if overriddenKey == key || strings.HasPrefix(key, overriddenKey+"[") {
+				// 实际的值来自环境变量，行号没有意义
+				line = 0
+				message += fmt.Sprintf("（值来自环境变量 %s）", EnvName(overriddenKey))
+				break
+			}
+		}
+	}
+	c.addAtLine(severity, line, key, message, suggestion)
+}
+
+// addAtLine 添加指定行号的诊断信息
+func (c *checker) addAtLine(severity Severity, line int, key, message, suggestion string) {
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		Severity:   severity,
+		Line:       line,
+		Key:        key,
+		Message:    message,
+		Suggestion: suggestion,
+	})
+}
+
+// result 按行号排序诊断信息，没有行号的排在最后
+func (c *checker) result() []Diagnostic {
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		a, b := c.diagnostics[i].Line, c.diagnostics[j].Line
+		if a == 0 || b == 0 {
+			return a != 0 && b == 0
+		}
+		return a < b
+	})
+	return c.diagnostics
+}
+
+// indexNode 记录每个配置键与列表项所在的行号
+func (c *checker) indexNode(node *yaml.Node, prefix string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			c.lines[key] = node.Content[i].Line
+			c.indexNode(node.Content[i+1], key)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			c.lines[fmt.Sprintf("%s[%d]", prefix, i)] = item.Line
+		}
+	}
+}
+
+// keyAtLine 获取指定行上的配置键
+func (c *checker) keyAtLine(line int) string {
+	if line == 0 {
+		return ""
+	}
+	for key, keyLine := range c.lines {
+		if keyLine == line {
+			return key
+		}
+	}
+	return ""
+}
+
+// checkSyntax 报告 YAML 语法错误，并尝试定位未闭合的引号
+func (c *checker) checkSyntax(data []byte, err error) {
+	found := false
+	for index, line := range strings.Split(string(data), "\n") {
+		content := strings.TrimSpace(line)
+		if content == "" || strings.HasPrefix(content, "#") {
+			continue
+		}
+		if unclosedQuote(content) {
+			found = true
+			c.addAtLine(SeverityError, index+1, "", "引号未闭合", "在值的末尾补上引号，或将整行注释掉")
+		}
+	}
+	if !found {
+		c.addAtLine(SeverityError, yamlErrorLine(err.Error()), "", err.Error(), "检查该行附近的缩进、冒号与引号")
+	}
+}
+
+// checkKeys 检查缺失与无效的配置项
+func (c *checker) checkKeys(root *yaml.Node) {
+	var currentConfigMap map[string]interface{}
+	if err := root.Decode(&currentConfigMap); err != nil {
+		return
+	}
+	var templateConfigMap map[string]interface{}
+	if err := yaml.Unmarshal([]byte(DefaultConfigTemplate()), &templateConfigMap); err != nil {
+		return
+	}
+
+	missingKeys := findMissingConfigKeysFromMaps(currentConfigMap, templateConfigMap)
+	invalidKeys := findInvalidConfigKeysFromMaps(currentConfigMap, templateConfigMap)
+	sort.Strings(missingKeys)
+	sort.Strings(invalidKeys)
+
+	for _, key := range invalidKeys {
+		c.add(SeverityWarning, key, "无效的配置项，将被忽略", "删除该项或检查拼写与缩进")
+	}
+	defaults := configFieldValues(DefaultConfig())
+	for _, key := range missingKeys {
+		suggestion := "从模板中补全该项"
+		if value, ok := defaults[key]; ok {
+			if text, isString := value.(string); isString {
+				value = strconv.Quote(text)
+			}
+			suggestion = fmt.Sprintf("添加该项，默认值为 %v", value)
+		}
+		c.add(SeverityWarning, key, "缺少配置项，将使用默认值", suggestion)
+	}
+}
+
+// checkValues 检查取值范围、格式与互斥的配置项
+func (c *checker) checkValues(conf *Config) {
+	if conf.LogLevel < log.OFF || conf.LogLevel > log.TRACE {
+		c.add(SeverityError, "log_level", fmt.Sprintf("日志等级 %d 超出范围", conf.LogLevel), fmt.Sprintf("设置为 %d 到 %d 之间的整数", log.OFF, log.TRACE))
+	}
+	if conf.Log.Format != log.FormatText && conf.Log.Format != log.FormatJSON {
+		c.add(SeverityError, "log.format", fmt.Sprintf("不支持的日志格式 %q", conf.Log.Format), fmt.Sprintf("设置为 %q 或 %q", log.FormatText, log.FormatJSON))
+	}
+	if conf.Log.MaxSize < 0 {
+		c.add(SeverityError, "log.max_size", "日志文件大小上限不能为负数", "设置为正整数，单位 MiB")
+	}
+	for key, value := range map[string]int{
+		"log.max_age":     conf.Log.MaxAge,
+		"log.max_backups": conf.Log.MaxBackups,
+	} {
+		if value < 0 {
+			c.add(SeverityError, key, "不能为负数", "设置为 0 表示不限制")
+		}
+	}
+
+	// 账号配置
+	account := conf.Account
+	if account.AppID == 0 {
+		c.add(SeverityError, "account.app_id", "未设置机器人 ID", "填写 QQ 开放平台-开发设置中的 AppID")
+	}
+	if account.Token == "" {
+		c.add(SeverityError, "account.token", "未设置机器人令牌", "填写 QQ 开放平台-开发设置中的 Token")
+	}
+	if account.AppSecret == "" {
+		c.add(SeverityError, "account.app_secret", "未设置机器人密钥", "填写 QQ 开放平台-开发设置中的 AppSecret")
+	}
+	switch {
+	case !account.WebSocket.Enable && !account.WebHook.Enable:
+		c.add(SeverityError, "account.websocket.enable", "WebSocket 与 WebHook 均未启用，无法接收事件", "启用 account.websocket 或 account.webhook 之一")
+	case account.WebSocket.Enable && account.WebHook.Enable:
+		c.add(SeverityWarning, "account.webhook.enable", "同时启用了 WebSocket 与 WebHook ，将只使用 WebHook", "关闭其中之一")
+	}
+	if account.WebSocket.Enable {
+		if account.WebSocket.Shards == 0 {
+			c.add(SeverityError, "account.websocket.shards", "分片数不能为 0", "设置为 1")
+		}
+		c.checkIntents(account)
+	}
+	if account.WebHook.Enable {
+		if account.WebHook.Port == 0 {
+			c.add(SeverityError, "account.webhook.port", "未设置 WebHook 端口", "设置为 1 到 65535 之间的端口")
+		}
+		c.checkPath("account.webhook.path", account.WebHook.Path)
+	}
+	if account.Passive.Fallback != PassiveFallbackProactive && account.Passive.Fallback != PassiveFallbackError {
+		c.add(SeverityError, "account.passive.fallback", fmt.Sprintf("不支持的处理方式 %q", account.Passive.Fallback), fmt.Sprintf("设置为 %q 或 %q", PassiveFallbackProactive, PassiveFallbackError))
+	}
+
+	// 本地文件服务器配置
+	fileServer := conf.FileServer
+	if fileServer.ExternalURL != "" {
+		c.checkHost("file_server.external_url", fileServer.ExternalURL, "example.com:8080")
+	}
+	if fileServer.SingleUse && !fileServer.SignURL {
+		c.add(SeverityWarning, "file_server.single_use", "未启用 sign_url ，single_use 不会生效", "同时启用 file_server.sign_url")
+	}
+	listener := fileServer.Listener
+	if listener.Enable {
+		if !fileServer.Enable {
+			c.add(SeverityWarning, "file_server.listener.enable", "本地文件服务器未启用，独立监听不会生效", "同时启用 file_server.enable")
+		}
+		if listener.Port == 0 {
+			c.add(SeverityError, "file_server.listener.port", "未设置独立监听端口", "设置为 1 到 65535 之间的端口")
+		} else if listener.Port == conf.Satori.Server.Port && (listener.Host == conf.Satori.Server.Host || listener.Host == "0.0.0.0") {
+			c.add(SeverityError, "file_server.listener.port", "独立监听端口与 Satori 服务器端口冲突", "使用不同的端口")
+		}
+	}
+	if (listener.TLSCert == "") != (listener.TLSKey == "") {
+		c.add(SeverityError, "file_server.listener.tls_cert", "TLS 证书与私钥需要同时设置", "同时设置 tls_cert 与 tls_key ，或都留空")
+	}
+	for key, path := range map[string]string{
+		"file_server.listener.tls_cert": listener.TLSCert,
+		"file_server.listener.tls_key":  listener.TLSKey,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			c.add(SeverityError, key, fmt.Sprintf("无法读取文件 %s", path), "检查文件路径与权限")
+		}
+	}
+
+	// 数据库配置
+	if conf.Database.MessageDatabase.Limit < 0 {
+		c.add(SeverityError, "database.message_database.limit", "消息获取数量限制不能为负数", "设置为 0 表示不限制")
+	}
+
+	// 媒体处理配置
+	media := conf.Media
+	for key, value := range map[string]int{
+		"media.image.max_width":       media.Image.MaxWidth,
+		"media.image.max_height":      media.Image.MaxHeight,
+		"media.image.max_size":        media.Image.MaxSize,
+		"media.limits.audio_max_size": media.Limits.AudioMaxSize,
+		"media.limits.video_max_size": media.Limits.VideoMaxSize,
+		"media.transcode.workers":     media.Transcode.Workers,
+	} {
+		if value < 0 {
+			c.add(SeverityError, key, "不能为负数", "设置为 0 表示不限制")
+		}
+	}
+	if media.Audio.Format != "wav" && media.Audio.Format != "ogg" {
+		c.add(SeverityError, "media.audio.format", fmt.Sprintf("不支持的解码格式 %q", media.Audio.Format), `设置为 "wav" 或 "ogg"`)
+	}
+	if media.Audio.Decode && !fileServer.Enable {
+		c.add(SeverityWarning, "media.audio.decode", "本地文件服务器未启用，语音解码不会生效", "同时启用 file_server.enable")
+	}
+	if media.Audio.Decode && runtime.GOOS == "windows" {
+		c.add(SeverityWarning, "media.audio.decode", "Windows 下不支持解码 silk 语音，只有 amr 语音会被解码", "在 Linux 或 macOS 上运行，或关闭 media.audio.decode")
+	}
+	if media.Transcode.Workers == 0 {
+		c.add(SeverityWarning, "media.transcode.workers", "最大并发转码数为 0 ，将按 1 处理", "设置为不小于 1 的整数")
+	}
+
+	// Satori 配置
+	satori := conf.Satori
+	if satori.Version != 1 {
+		c.add(SeverityError, "satori.version", fmt.Sprintf("不支持的 Satori 版本 %d", satori.Version), "设置为 1")
+	}
+	c.checkPath("satori.path", satori.Path)
+	if satori.Server.Port == 0 {
+		c.add(SeverityError, "satori.server.port", "未设置 Satori 服务器端口", "设置为 1 到 65535 之间的端口")
+	}
+	if satori.Token == "" {
+		c.add(SeverityWarning, "satori.token", "未设置鉴权令牌，任何人都可以调用 Satori API", "设置一个随机的令牌")
+	}
+	for i, proxyURL := range satori.Proxy.URLs {
+		c.checkURL(fmt.Sprintf("satori.proxy.urls[%d]", i), proxyURL, "https://example.com/")
+	}
+
+	// 限流配置
+	c.checkRateLimit(conf.RateLimit)
+}
+
+// checkRateLimit 检查限流配置的取值范围
+func (c *checker) checkRateLimit(rateLimit RateLimit) {
+	if !rateLimit.Enable {
+		return
+	}
+	if rateLimit.QueueSize < 0 {
+		c.add(SeverityError, "rate_limit.queue_size", "不能为负数", "设置为 0 表示不限制")
+	}
+	if rateLimit.Queue && rateLimit.QueueTimeout == 0 {
+		c.add(SeverityWarning, "rate_limit.queue_timeout", "最长排队时间为 0 ，超出限制的请求不会排队", "设置为大于 0 的秒数，或关闭 rate_limit.queue")
+	}
+	for name, group := range map[string]RateLimitGroup{
+		"message": rateLimit.Message,
+		"manage":  rateLimit.Manage,
+		"query":   rateLimit.Query,
+	} {
+		prefix := "rate_limit." + name
+		for key, value := range map[string]float64{
+			prefix + ".rate":        group.Rate,
+			prefix + ".target_rate": group.TargetRate,
+		} {
+			if value < 0 {
+				c.add(SeverityError, key, "不能为负数", "设置为 0 表示不限制")
+			}
+		}
+		for key, value := range map[string]int{
+			prefix + ".burst":        group.Burst,
+			prefix + ".target_burst": group.TargetBurst,
+		} {
+			if value < 0 {
+				c.add(SeverityError, key, "不能为负数", "设置为不小于 1 的整数")
+			}
+		}
+	}
+}
+
+// checkIntents 检查事件名称与互斥的事件订阅
+func (c *checker) checkIntents(account Account) {
+	seen := make(map[string]bool)
+	for i, intent := range account.WebSocket.Intents {
+		key := fmt.Sprintf("account.websocket.intents[%d]", i)
+		if seen[intent] {
+			c.add(SeverityWarning, key, fmt.Sprintf("重复的事件 %q", intent), "删除重复项")
+		}
+		seen[intent] = true
+
+		if c.opts.IsKnownIntent == nil || c.opts.IsKnownIntent(intent) {
+			continue
+		}
+		c.add(SeverityError, key, fmt.Sprintf("未知的事件 %q", intent), c.suggestIntent(intent))
+	}
+
+	if account.Sandbox && seen["GROUP_AND_C2C_EVENT"] {
+		c.add(SeverityError, "account.sandbox", "沙箱环境与单聊/群聊不适配", "关闭 account.sandbox ，或取消订阅 GROUP_AND_C2C_EVENT")
+	}
+}
+
+// suggestIntent 为未知的事件名称提供修复建议
+func (c *checker) suggestIntent(intent string) string {
+	if correct, ok := intentAliases[intent]; ok {
+		if correct == "" {
+			return "GlycCat 不支持该事件，删除该项"
+		}
+		return fmt.Sprintf("改为 %q", correct)
+	}
+
+	// 查找编辑距离最近的事件名称
+	best, bestDistance := "", -1
+	for _, match := range intentNamePattern.FindAllStringSubmatch(intentsDocs, -1) {
+		candidate := match[1]
+		if c.opts.IsKnownIntent != nil && !c.opts.IsKnownIntent(candidate) {
+			continue
+		}
+		distance := editDistance(strings.ToUpper(intent), candidate)
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if best != "" && bestDistance <= len(best)/3+1 {
+		return fmt.Sprintf("改为 %q", best)
+	}
+	return "参考配置模板中的事件列表"
+}
+
+// checkURL 检查 URL 格式
+func (c *checker) checkURL(key, value, example string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.add(SeverityError, key, fmt.Sprintf("无效的 URL %q", value), fmt.Sprintf("使用带有 http:// 或 https:// 的完整地址，如 %s", example))
+	}
+}
+
+// checkHost 检查不带协议的 host[:port] 地址格式
+func (c *checker) checkHost(key, value, example string) {
+	host := strings.TrimSuffix(value, "/")
+	if strings.Contains(host, "://") {
+		c.add(SeverityError, key, fmt.Sprintf("地址 %q 不应带有协议", value), fmt.Sprintf("只填写主机与端口，如 %s", example))
+		return
+	}
+	u, err := url.Parse("//" + host)
+	if err != nil || u.Host == "" || u.Path != "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		c.add(SeverityError, key, fmt.Sprintf("无效的地址 %q", value), fmt.Sprintf("使用 host[:port] 形式的地址，如 %s", example))
+		return
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			c.add(SeverityError, key, fmt.Sprintf("无效的端口 %q", port), "设置为 1 到 65535 之间的端口")
+		}
+	}
+}
+
+// checkPath 检查路由路径格式
+func (c *checker) checkPath(key, value string) {
+	if value == "" {
+		return
+	}
+	if !strings.HasPrefix(value, "/") {
+		c.add(SeverityError, key, fmt.Sprintf("路径 %q 需要以 / 开头", value), fmt.Sprintf("改为 %q", "/"+value))
+	} else if len(value) > 1 && strings.HasSuffix(value, "/") {
+		c.add(SeverityWarning, key, fmt.Sprintf("路径 %q 不应以 / 结尾", value), fmt.Sprintf("改为 %q", strings.TrimRight(value, "/")))
+	}
+}
+
+// yamlErrorLine 从 YAML 错误信息中获取行号
+func yamlErrorLine(message string) int {
+	match := yamlLinePattern.FindStringSubmatch(message)
+	if match == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(match[1])
+	return line
+}
+
+// unclosedQuote 判断一行中注释之前的双引号是否未闭合
+func unclosedQuote(line string) bool {
+	inQuote := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inQuote {
+				i++
+			}
+		case '"':
+			inQuote = !inQuote
+		case '#':
+			if !inQuote {
+				return false
+			}
+		}
+	}
+	return inQuote
+}
+
+// editDistance 计算两个字符串的编辑距离
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// checkForTest 将 DefaultConfigTemplate 中的 old 替换为 new 后检查
+func checkForTest(t *testing.T, old, new string) []Diagnostic {
+	t.Helper()
+	data := DefaultConfigTemplate()
+	if old != "" {
+		if !strings.Contains(data, old) {
+			t.Fatalf("模板中不存在 %q", old)
+		}
+		data = strings.Replace(data, old, new, 1)
+	}
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+	diagnostics, err := Check(path, CheckOptions{})
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	return diagnostics
+}
+
+// findDiagnostic 查找指定配置键上的诊断信息
+func findDiagnostic(diagnostics []Diagnostic, key string) (Diagnostic, bool) {
+	for _, d := range diagnostics {
+		if d.Key == key {
+			return d, true
+		}
+	}
+	return Diagnostic{}, false
+}
+
+func TestCheckExternalURL(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"example.com", false},
+		{"example.com:8080", false},
+		{"127.0.0.1:8080/", false},
+		{"[::1]:8080", false},
+		{"http://example.com:8080", true},
+		{"https://example.com", true},
+		{"example.com:0", true},
+		{"example.com:70000", true},
+		{"example.com:8080/files", true},
+		{"user@example.com", true},
+	}
+
+	for _, tt := range tests {
+		diagnostics := checkForTest(t, `external_url: ""`, `external_url: "`+tt.value+`"`)
+		d, found := findDiagnostic(diagnostics, "file_server.external_url")
+		if found != tt.wantErr {
+			t.Errorf("external_url %q: diagnostic found = %v (%+v), want %v", tt.value, found, d, tt.wantErr)
+			continue
+		}
+		if found && (d.Severity != SeverityError || d.Line == 0) {
+			t.Errorf("external_url %q: diagnostic = %+v, want error with line", tt.value, d)
+		}
+	}
+}
+
+func TestCheckReportsKeysWithLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		key      string
+		severity Severity
+	}{
+		{"invalid key", "file_server:\n", "file_server:\n  unknown_key: 1\n", "file_server.unknown_key", SeverityWarning},
+		{"missing key", "  sign_url: true\n", "\n", "file_server.sign_url", SeverityWarning},
+		{"type error", "  sign_url: true", "  sign_url: \"abc\"", "file_server.sign_url", SeverityError},
+		{"invalid fallback", `fallback: "proactive"`, `fallback: "drop"`, "account.passive.fallback", SeverityError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := checkForTest(t, tt.old, tt.new)
+			d, found := findDiagnostic(diagnostics, tt.key)
+			if !found {
+				t.Fatalf("未找到 %s 上的诊断信息: %+v", tt.key, diagnostics)
+			}
+			if d.Severity != tt.severity {
+				t.Errorf("severity = %s, want %s", d.Severity, tt.severity)
+			}
+			if tt.name != "missing key" && d.Line == 0 {
+				t.Errorf("line = 0, want line of %s", tt.key)
+			}
+		})
+	}
+}
+
+func TestCheckSyntaxError(t *testing.T) {
+	diagnostics := checkForTest(t, `external_url: ""`, `external_url: "example.com`)
+	if len(diagnostics) == 0 {
+		t.Fatal("Check() 未报告语法错误")
+	}
+	d := diagnostics[0]
+	if d.Severity != SeverityError || d.Line == 0 {
+		t.Errorf("diagnostic = %+v, want error with line", d)
+	}
+	if got := d.Format("config.yml"); !strings.HasPrefix(got, "config.yml:") {
+		t.Errorf("Format() = %q, want prefix %q", got, "config.yml:")
+	}
+}
+
+func TestCheckEnvOverride(t *testing.T) {
+	t.Setenv(EnvName("file_server.external_url"), "http://example.com")
+	diagnostics := checkForTest(t, "", "")
+	d, found := findDiagnostic(diagnostics, "file_server.external_url")
+	if !found {
+		t.Fatal("未报告环境变量中的无效地址")
+	}
+	if d.Line != 0 || !strings.Contains(d.Message, EnvName("file_server.external_url")) {
+		t.Errorf("diagnostic = %+v, want no line and env name in message", d)
+	}
+}
@@ -3,28 +3,58 @@ package config
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"gopkg.in/yaml.v3"
 
 	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/WindowsSov8forUs/glyccat/ratelimit"
 )
 
 var (
-	instance *Config
-	mutex    sync.Mutex
+	instance       *Config
+	mutex          sync.Mutex
+	nonInteractive bool   // 是否以非交互模式加载配置
+	configPath     string // 配置文件路径，用于重新加载
 )
 
 // Config 配置
 type Config struct {
 	LogLevel   log.LogLevel `yaml:"log_level"`   // 日志等级
+	Log        LogOutput    `yaml:"log"`         // 日志输出配置
 	Account    Account      `yaml:"account"`     // QQ 机器人账号配置
 	FileServer FileServer   `yaml:"file_server"` // 本地文件服务器配置
 	Database   Database     `yaml:"database"`    // 数据库配置
+	Media      Media        `yaml:"media"`       // 媒体处理配置
 	Satori     Satori       `yaml:"satori"`      // Satori 配置
+	RateLimit  RateLimit    `yaml:"rate_limit"`  // OpenAPI 限流配置
+}
+
+// LogOutput 日志输出配置
+type LogOutput struct {
+	Format     string `yaml:"format"`      // 日志格式，可选 text 或 json
+	File       string `yaml:"file"`        // 日志文件路径，为空时不写入文件
+	MaxSize    int    `yaml:"max_size"`    // 单个日志文件大小上限，单位 MiB
+	MaxAge     int    `yaml:"max_age"`     // 日志文件保留天数
+	MaxBackups int    `yaml:"max_backups"` // 日志文件保留数量
+	Compress   bool   `yaml:"compress"`    // 是否压缩轮转后的日志文件
+}
+
+// Options 转换为日志输出选项
+func (l LogOutput) Options() log.Options {
+	return log.Options{
+		Format:     l.Format,
+		File:       l.File,
+		MaxSize:    l.MaxSize,
+		MaxAge:     l.MaxAge,
+		MaxBackups: l.MaxBackups,
+		Compress:   l.Compress,
+	}
 }
 
 // Account QQ 机器人账号配置
@@ -36,6 +66,7 @@ type Account struct {
 	Sandbox   bool      `yaml:"sandbox"`    // 是否使用沙箱环境
 	WebSocket WebSocket `yaml:"websocket"`  // WebSocket 配置
 	WebHook   QQWebHook `yaml:"webhook"`    // WebHook 配置
+	Passive   Passive   `yaml:"passive"`    // 被动回复配置
 }
 
 // WebSocket QQ 机器人 WebSocket 配置
@@ -53,16 +84,84 @@ type QQWebHook struct {
 	Path   string `yaml:"path"`   // WebHook 路径
 }
 
+// 被动回复不可用时的处理方式
+const (
+	PassiveFallbackProactive = "proactive" // 发送主动消息
+	PassiveFallbackError     = "error"     // 返回错误
+)
+
+// Passive 群聊与单聊的被动回复配置
+type Passive struct {
+	Auto     bool   `yaml:"auto"`     // 是否自动附加被动回复的消息或事件 ID
+	Fallback string `yaml:"fallback"` // 没有可以被动回复的消息或事件时的处理方式
+}
+
 // FileServer 本地文件服务器配置
 type FileServer struct {
-	Enable      bool   `yaml:"enable"`       // 是否启用对外本地文件服务器
-	ExternalURL string `yaml:"external_url"` // 本地文件服务器公网地址 {{ .Host }}:{{ .Port }}
-	TTL         uint64 `yaml:"ttl"`          // 文件存储时间，单位秒
+	Enable       bool   `yaml:"enable"`         // 是否启用对外本地文件服务器
+	ExternalURL  string `yaml:"external_url"`   // 本地文件服务器公网地址 {{ .Host }}:{{ .Port }}
+	TTL          uint64 `yaml:"ttl"`            // 文件存储时间，单位秒
+	MaxFileSize  uint64 `yaml:"max_file_size"`  // 单个文件大小上限，单位字节
+	MaxTotalSize uint64 `yaml:"max_total_size"` // 文件总大小上限，单位字节
+	SignURL      bool   `yaml:"sign_url"`       // 是否为文件链接签名
+	SignKey      string `yaml:"sign_key"`       // 文件链接签名密钥，为空时自动生成
+	URLTTL       uint64 `yaml:"url_ttl"`        // 文件链接有效期，单位秒
+	SingleUse    bool   `yaml:"single_use"`     // 文件链接是否只能使用一次
+
+	Listener FileServerListener `yaml:"listener"` // 独立的文件服务监听配置
+}
+
+// FileServerListener 独立的文件服务监听配置
+type FileServerListener struct {
+	Enable  bool   `yaml:"enable"`   // 是否启用独立监听
+	Host    string `yaml:"host"`     // 监听地址
+	Port    uint16 `yaml:"port"`     // 监听端口
+	TLSCert string `yaml:"tls_cert"` // TLS 证书文件路径
+	TLSKey  string `yaml:"tls_key"`  // TLS 私钥文件路径
 }
 
 // Database 数据库配置
 type Database struct {
 	MessageDatabase MessageDatabase `yaml:"message_database"` // 消息数据库配置
+	MemberDatabase  MemberDatabase  `yaml:"member_database"`  // 成员数据库配置
+}
+
+// Media 媒体处理配置
+type Media struct {
+	Image     ImageOptimize `yaml:"image"`     // 图片优化配置
+	Audio     AudioDecode   `yaml:"audio"`     // 语音解码配置
+	Transcode Transcode     `yaml:"transcode"` // 转码服务配置
+	Limits    MediaLimits   `yaml:"limits"`    // 音视频上传限制
+}
+
+// ImageOptimize 图片优化配置
+type ImageOptimize struct {
+	MaxWidth    int  `yaml:"max_width"`    // 最大宽度，单位像素
+	MaxHeight   int  `yaml:"max_height"`   // 最大高度，单位像素
+	MaxSize     int  `yaml:"max_size"`     // 图片大小上限，单位字节
+	ConvertWebP bool `yaml:"convert_webp"` // 是否将 WebP 图片转换为 PNG/JPEG
+}
+
+// AudioDecode 收到的语音解码配置
+type AudioDecode struct {
+	Decode bool   `yaml:"decode"` // 是否将收到的语音解码为通用格式
+	Format string `yaml:"format"` // 解码格式，可选 wav 或 ogg
+}
+
+// Transcode 转码服务配置
+type Transcode struct {
+	Workers  int    `yaml:"workers"`   // 最大并发转码数
+	Timeout  uint64 `yaml:"timeout"`   // 单个转码任务的超时时间，单位秒
+	Cache    bool   `yaml:"cache"`     // 是否缓存转码结果
+	CacheTTL uint64 `yaml:"cache_ttl"` // 转码结果缓存有效期，单位秒
+}
+
+// MediaLimits 发送的音视频限制，为 0 时不限制
+type MediaLimits struct {
+	AudioMaxDuration uint64 `yaml:"audio_max_duration"` // 音频最大时长，单位秒
+	AudioMaxSize     int    `yaml:"audio_max_size"`     // 音频大小上限，单位字节
+	VideoMaxDuration uint64 `yaml:"video_max_duration"` // 视频最大时长，单位秒
+	VideoMaxSize     int    `yaml:"video_max_size"`     // 视频大小上限，单位字节
 }
 
 // MessageDatabase 消息数据库配置
@@ -71,6 +170,11 @@ type MessageDatabase struct {
 	Limit  int  `yaml:"limit"`  // 消息获取数量限制
 }
 
+// MemberDatabase 成员数据库配置
+type MemberDatabase struct {
+	Enable bool `yaml:"enable"` // 是否启用成员数据库
+}
+
 // Satori Satori 配置
 type Satori struct {
 	Version uint8   `yaml:"version"` // Satori 版本，目前只有 1
@@ -78,6 +182,7 @@ type Satori struct {
 	Token   string  `yaml:"token"`   // 鉴权令牌
 	Server  Server  `yaml:"server"`  // 服务器配置
 	WebHook WebHook `yaml:"webhook"` // WebHook 客户端配置
+	Proxy   Proxy   `yaml:"proxy"`   // 代理路由配置
 }
 
 // Server 服务器配置
@@ -91,8 +196,65 @@ type WebHook struct {
 	Timeout uint32 `yaml:"timeout"` // 超时时间
 }
 
+// Proxy 代理路由配置
+type Proxy struct {
+	URLs     []string `yaml:"urls"`      // 允许代理的 URL 前缀
+	Timeout  uint32   `yaml:"timeout"`   // 代理请求超时时间，单位秒
+	MaxSize  uint64   `yaml:"max_size"`  // 代理资源大小上限，单位字节
+	Cache    bool     `yaml:"cache"`     // 是否缓存代理资源
+	CacheTTL uint32   `yaml:"cache_ttl"` // 代理资源缓存有效期，单位秒
+}
+
+// RateLimit QQ 开放平台 OpenAPI 调用的限流配置
+type RateLimit struct {
+	Enable       bool           `yaml:"enable"`        // 是否启用限流
+	Queue        bool           `yaml:"queue"`         // 超出限制时是否排队等待
+	QueueSize    int            `yaml:"queue_size"`    // 每个令牌桶最多排队的请求数
+	QueueTimeout uint64         `yaml:"queue_timeout"` // 最长排队时间，单位秒
+	Message      RateLimitGroup `yaml:"message"`       // 发送消息
+	Manage       RateLimitGroup `yaml:"manage"`        // 除发送消息以外的写操作
+	Query        RateLimitGroup `yaml:"query"`         // 查询
+}
+
+// RateLimitGroup 接口分组的令牌桶配置，速率为 0 时不限制
+type RateLimitGroup struct {
+	Rate        float64 `yaml:"rate"`         // 分组每秒补充的令牌数
+	Burst       int     `yaml:"burst"`        // 分组的令牌桶容量
+	TargetRate  float64 `yaml:"target_rate"`  // 每个目标每秒补充的令牌数
+	TargetBurst int     `yaml:"target_burst"` // 每个目标的令牌桶容量
+}
+
+// Options 转换为限流选项
+func (r RateLimit) Options() ratelimit.Options {
+	return ratelimit.Options{
+		Enable:       r.Enable,
+		Queue:        r.Queue,
+		QueueSize:    r.QueueSize,
+		QueueTimeout: time.Duration(r.QueueTimeout) * time.Second,
+		Rules: map[ratelimit.Group]ratelimit.GroupRule{
+			ratelimit.GroupMessage: r.Message.rule(),
+			ratelimit.GroupManage:  r.Manage.rule(),
+			ratelimit.GroupQuery:   r.Query.rule(),
+		},
+	}
+}
+
+// rule 转换为令牌桶规则
+func (g RateLimitGroup) rule() ratelimit.GroupRule {
+	return ratelimit.GroupRule{
+		Group:  ratelimit.Rule{Rate: g.Rate, Burst: g.Burst},
+		Target: ratelimit.Rule{Rate: g.TargetRate, Burst: g.TargetBurst},
+	}
+}
+
 // GetSatoriToken 获取 Satori 鉴权令牌
 func GetSatoriToken() string {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if instance == nil {
+		return ""
+	}
 	return instance.Satori.Token
 }
 
@@ -100,16 +262,96 @@ func GetSatoriToken() string {
 func DefaultConfig() *Config {
 	return &Config{
 		LogLevel: log.INFO,
+		Log: LogOutput{
+			Format:     log.FormatText,
+			File:       "log/glyc-cat.log",
+			MaxSize:    256, // 默认单个日志文件大小上限为 256 MiB
+			MaxAge:     7,
+			MaxBackups: 10,
+		},
+		Account: Account{
+			Passive: Passive{
+				Auto:     true,
+				Fallback: PassiveFallbackProactive,
+			},
+		},
+		FileServer: FileServer{
+			MaxFileSize:  100 * 1024 * 1024,  // 默认单个文件大小上限为 100 MiB
+			MaxTotalSize: 1024 * 1024 * 1024, // 默认文件总大小上限为 1 GiB
+			SignURL:      true,
+			URLTTL:       3600, // 默认文件链接有效期为 1 小时
+			Listener: FileServerListener{
+				Host: "0.0.0.0",
+				Port: 8081,
+			},
+		},
 		Database: Database{
 			MessageDatabase: MessageDatabase{
 				Enable: true,
 				Limit:  50, // 默认消息获取数量限制
 			},
+			MemberDatabase: MemberDatabase{
+				Enable: true,
+			},
+		},
+		Media: Media{
+			Image: ImageOptimize{
+				MaxWidth:    4096,
+				MaxHeight:   4096,
+				MaxSize:     10 * 1024 * 1024, // 默认图片大小上限为 10 MiB
+				ConvertWebP: true,
+			},
+			Audio: AudioDecode{
+				Decode: false,
+				Format: "wav",
+			},
+			Transcode: Transcode{
+				Workers:  2,
+				Timeout:  120, // 默认转码超时时间为 2 分钟
+				Cache:    true,
+				CacheTTL: 86400, // 默认转码结果缓存 1 天
+			},
+			Limits: MediaLimits{
+				AudioMaxDuration: 0,
+				AudioMaxSize:     0,
+				VideoMaxDuration: 0,
+				VideoMaxSize:     0,
+			},
 		},
 		Satori: Satori{
 			WebHook: WebHook{
 				Timeout: 10, // 默认 WebHook 超时时间为 10 秒
 			},
+			Proxy: Proxy{
+				URLs: []string{
+					"https://multimedia.nt.qq.com.cn/",
+					"https://gchat.qpic.cn/",
+				},
+				Timeout:  30,               // 默认代理请求超时时间为 30 秒
+				MaxSize:  50 * 1024 * 1024, // 默认代理资源大小上限为 50 MiB
+				Cache:    false,
+				CacheTTL: 3600, // 默认代理资源缓存 1 小时
+			},
+		},
+		RateLimit: RateLimit{
+			Enable:       false,
+			Queue:        true,
+			QueueSize:    100,
+			QueueTimeout: 10, // 默认最长排队 10 秒
+			Message: RateLimitGroup{
+				Rate:        20,
+				Burst:       20,
+				TargetRate:  5,
+				TargetBurst: 5,
+			},
+			Manage: RateLimitGroup{
+				Rate:  10,
+				Burst: 10,
+			},
+			Query: RateLimitGroup{
+				Rate:  20,
+				Burst: 40,
+			},
 		},
 	}
 }
@@ -126,6 +368,12 @@ func DumpConfig(conf *Config) string {
 	return fmt.Sprintf(
 		ConfigTemplate,
 		conf.LogLevel,
+		conf.Log.Format,
+		conf.Log.File,
+		conf.Log.MaxSize,
+		conf.Log.MaxAge,
+		conf.Log.MaxBackups,
+		conf.Log.Compress,
 		conf.Account.BotID,
 		conf.Account.AppID,
 		conf.Account.Token,
@@ -138,17 +386,66 @@ func DumpConfig(conf *Config) string {
 		conf.Account.WebHook.Host,
 		conf.Account.WebHook.Port,
 		conf.Account.WebHook.Path,
+		conf.Account.Passive.Auto,
+		conf.Account.Passive.Fallback,
 		conf.FileServer.Enable,
 		conf.FileServer.ExternalURL,
 		conf.FileServer.TTL,
+		conf.FileServer.MaxFileSize,
+		conf.FileServer.MaxTotalSize,
+		conf.FileServer.SignURL,
+		conf.FileServer.SignKey,
+		conf.FileServer.URLTTL,
+		conf.FileServer.SingleUse,
+		conf.FileServer.Listener.Enable,
+		conf.FileServer.Listener.Host,
+		conf.FileServer.Listener.Port,
+		conf.FileServer.Listener.TLSCert,
+		conf.FileServer.Listener.TLSKey,
 		conf.Database.MessageDatabase.Enable,
 		conf.Database.MessageDatabase.Limit,
+		conf.Database.MemberDatabase.Enable,
+		conf.Media.Image.MaxWidth,
+		conf.Media.Image.MaxHeight,
+		conf.Media.Image.MaxSize,
+		conf.Media.Image.ConvertWebP,
+		conf.Media.Audio.Decode,
+		conf.Media.Audio.Format,
+		conf.Media.Transcode.Workers,
+		conf.Media.Transcode.Timeout,
+		conf.Media.Transcode.Cache,
+		conf.Media.Transcode.CacheTTL,
+		conf.Media.Limits.AudioMaxDuration,
+		conf.Media.Limits.AudioMaxSize,
+		conf.Media.Limits.VideoMaxDuration,
+		conf.Media.Limits.VideoMaxSize,
 		conf.Satori.Version,
 		conf.Satori.Path,
 		conf.Satori.Token,
 		conf.Satori.Server.Host,
 		conf.Satori.Server.Port,
 		conf.Satori.WebHook.Timeout,
+		dumpStringList(conf.Satori.Proxy.URLs),
+		conf.Satori.Proxy.Timeout,
+		conf.Satori.Proxy.MaxSize,
+		conf.Satori.Proxy.Cache,
+		conf.Satori.Proxy.CacheTTL,
+		conf.RateLimit.Enable,
+		conf.RateLimit.Queue,
+		conf.RateLimit.QueueSize,
+		conf.RateLimit.QueueTimeout,
+		conf.RateLimit.Message.Rate,
+		conf.RateLimit.Message.Burst,
+		conf.RateLimit.Message.TargetRate,
+		conf.RateLimit.Message.TargetBurst,
+		conf.RateLimit.Manage.Rate,
+		conf.RateLimit.Manage.Burst,
+		conf.RateLimit.Manage.TargetRate,
+		conf.RateLimit.Manage.TargetBurst,
+		conf.RateLimit.Query.Rate,
+		conf.RateLimit.Query.Burst,
+		conf.RateLimit.Query.TargetRate,
+		conf.RateLimit.Query.TargetBurst,
 	)
 }
 
@@ -533,17 +830,67 @@ func promptSatoriConfig(conf *Config) error {
 	return nil
 }
 
+// SetNonInteractive 设置是否以非交互模式加载配置
+//
+// 非交互模式下不会询问用户：配置文件不存在时使用默认配置，缺失的配置项使用模板默认值，且不会改写配置文件
+func SetNonInteractive(enable bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	nonInteractive = enable
+}
+
 // LoadConfig 加载配置
+//
+// 读取配置文件后使用 GLYCCAT_ 前缀的环境变量覆盖对应的配置项
 func LoadConfig(path string) (*Config, error) {
 	mutex.Lock()
 	defer mutex.Unlock()
 
+	config, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	configPath = path
+
+	// 使用环境变量覆盖配置
+	overridden, err := applyEnvOverrides(config)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range overridden {
+		fmt.Printf("%s 配置项 %s 已由环境变量覆盖\n", log.InfoMark, key)
+	}
+
+	registerSecrets(config)
+	instance = config
+	return instance, nil
+}
+
+// registerSecrets 登记配置中的令牌与密钥，使其不会出现在日志中
+func registerSecrets(conf *Config) {
+	log.AddSecret(
+		conf.Account.Token,
+		conf.Account.AppSecret,
+		conf.Satori.Token,
+		conf.FileServer.SignKey,
+	)
+}
+
+// readConfigFile 读取配置文件，配置文件不存在时进入首次配置流程
+func readConfigFile(path string) (*Config, error) {
 	var config *Config
 
-	// 检查 config.yml 是否存在
-	if _, err := os.Stat("config.yml"); os.IsNotExist(err) {
+	// 检查配置文件是否存在
+	if _, err := os.Stat(path); os.IsNotExist(err) {
 		config = DefaultConfig()
 
+		if nonInteractive {
+			fmt.Printf("%s 未检测到配置文件 %s ，将使用默认配置与环境变量\n", log.WarningMark, path)
+			return config, nil
+		}
+
 		fmt.Printf("%s 未检测到配置文件，即将进入首次配置流程\n", log.InfoMark)
 		if err := SetConfigByInput(config); err != nil {
 			fmt.Printf("%s 获取用户配置项时出错: %v\n", log.FailMark, err)
@@ -552,32 +899,77 @@ func LoadConfig(path string) (*Config, error) {
 
 		configData := DumpConfig(config)
 
-		// 写入 config.yml
-		err = os.WriteFile("config.yml", []byte(configData), 0644)
+		// 写入配置文件
+		err = os.WriteFile(path, []byte(configData), 0644)
 		if err != nil {
 			return nil, fmt.Errorf("%s 写入配置文件时出错: %v", log.FailMark, err)
 		}
-	} else {
-		// 确保配置完整性
-		if err := ensureConfigComplete(path); err != nil {
-			return nil, err
-		}
+		return config, nil
+	}
 
-		// 读取配置文件
-		configData, err := os.ReadFile(path)
-		if err != nil {
-			return nil, err
-		}
+	if nonInteractive {
+		return readConfigFileWithDefaults(path)
+	}
 
-		// 初始化配置结构体
-		config = &Config{}
-		if err = yaml.Unmarshal(configData, config); err != nil {
-			return nil, err
+	// 确保配置完整性
+	if err := ensureConfigComplete(path); err != nil {
+		return nil, err
+	}
+
+	// 读取配置文件
+	configData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// 初始化配置结构体
+	config = &Config{}
+	if err = yaml.Unmarshal(configData, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// readConfigFileWithDefaults 读取配置文件，缺失的配置项使用模板默认值并输出变更
+func readConfigFileWithDefaults(path string) (*Config, error) {
+	configData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	var currentConfigMap map[string]interface{}
+	if err = yaml.Unmarshal(configData, &currentConfigMap); err != nil {
+		return nil, fmt.Errorf("解析当前配置文件失败: %w", err)
+	}
+	var templateConfigMap map[string]interface{}
+	if err = yaml.Unmarshal([]byte(DefaultConfigTemplate()), &templateConfigMap); err != nil {
+		return nil, fmt.Errorf("解析默认配置模板失败: %w", err)
+	}
+
+	// 在默认配置上解析，未出现的配置项保留默认值
+	config := DefaultConfig()
+	if err = yaml.Unmarshal(configData, config); err != nil {
+		return nil, fmt.Errorf("解析当前配置文件失败: %w", err)
+	}
+
+	missingKeys := findMissingConfigKeysFromMaps(currentConfigMap, templateConfigMap)
+	invalidKeys := findInvalidConfigKeysFromMaps(currentConfigMap, templateConfigMap)
+	sort.Strings(missingKeys)
+	sort.Strings(invalidKeys)
+
+	defaults := configFieldValues(DefaultConfig())
+	for _, key := range missingKeys {
+		if value, ok := defaults[key]; ok {
+			fmt.Printf("%s 缺失配置项 %s ，使用默认值: %v\n", log.WarningMark, key, value)
+		} else {
+			fmt.Printf("%s 缺失配置项 %s ，使用默认值\n", log.WarningMark, key)
 		}
 	}
+	for _, key := range invalidKeys {
+		fmt.Printf("%s 忽略无效配置项 %s\n", log.WarningMark, key)
+	}
 
-	instance = config
-	return instance, nil
+	return config, nil
 }
 
 // ensureConfigComplete 检查配置是否完整
@@ -725,8 +1117,8 @@ func promptUserForConfigFix() (bool, error) {
 
 // fixConfigFile 修复配置文件
 func fixConfigFile(configPath string, originalData []byte) error {
-	// 创建备份文件名（带时间戳）
-	backupPath := "config.yml.backup"
+	// 备份文件与配置文件位于同一目录
+	backupPath := configPath + ".backup"
 
 	// 备份原配置文件
 	if err := os.WriteFile(backupPath, originalData, 0644); err != nil {
@@ -783,11 +1175,19 @@ func mergeConfigWithTemplate(originalData []byte) ([]byte, error) {
 		return nil, fmt.Errorf("解析原配置失败: %w", err)
 	}
 
+	// 记录原配置中出现的配置键，零值有意义的配置项据此判断是否覆盖
+	var originalConfigMap map[string]interface{}
+	if err := yaml.Unmarshal(originalData, &originalConfigMap); err != nil {
+		return nil, fmt.Errorf("解析原配置失败: %w", err)
+	}
+	present := make(configKeySet)
+	collectConfigKeys("", originalConfigMap, present)
+
 	// 获取默认配置
 	defaultConfig := DefaultConfig()
 
-	// 合并配置：用原配置的非零值覆盖默认配置
-	mergedConfig := mergeConfigStructs(defaultConfig, &originalConfig)
+	// 合并配置：用原配置中出现的值或非零值覆盖默认配置
+	mergedConfig := mergeConfigStructs(defaultConfig, &originalConfig, present)
 
 	// 使用 DumpConfig 方法导出配置
 	mergedData := DumpConfig(mergedConfig)
@@ -795,8 +1195,28 @@ func mergeConfigWithTemplate(originalData []byte) ([]byte, error) {
 	return []byte(mergedData), nil
 }
 
+// configKeySet 配置文件中出现的配置键集合
+type configKeySet map[string]bool
+
+// collectConfigKeys 递归收集配置文件中出现的配置键
+func collectConfigKeys(prefix string, current map[string]interface{}, keys configKeySet) {
+	for key, value := range current {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+		keys[fullKey] = true
+
+		if currentMap, ok := value.(map[string]interface{}); ok {
+			collectConfigKeys(fullKey, currentMap, keys)
+		}
+	}
+}
+
 // mergeConfigStructs 合并配置结构体
-func mergeConfigStructs(template, original *Config) *Config {
+//
+// 大部分配置项只有非零值才覆盖模板，零值有意义的配置项（如 0 表示无上限）只要出现在原配置中就会覆盖模板
+func mergeConfigStructs(template, original *Config, present configKeySet) *Config {
 	result := *template // 复制模板配置
 
 	// 合并基本字段（只有非零值才覆盖）
@@ -804,6 +1224,24 @@ func mergeConfigStructs(template, original *Config) *Config {
 		result.LogLevel = original.LogLevel
 	}
 
+	// 合并日志输出配置
+	if original.Log.Format != "" {
+		result.Log.Format = original.Log.Format
+	}
+	if present["log.file"] {
+		result.Log.File = original.Log.File
+	}
+	if original.Log.MaxSize != 0 {
+		result.Log.MaxSize = original.Log.MaxSize
+	}
+	if present["log.max_age"] {
+		result.Log.MaxAge = original.Log.MaxAge
+	}
+	if present["log.max_backups"] {
+		result.Log.MaxBackups = original.Log.MaxBackups
+	}
+	result.Log.Compress = original.Log.Compress
+
 	// 合并 Account 配置
 	if original.Account.BotID != 0 {
 		result.Account.BotID = original.Account.BotID
@@ -840,6 +1278,14 @@ func mergeConfigStructs(template, original *Config) *Config {
 		result.Account.WebHook.Path = original.Account.WebHook.Path
 	}
 
+	// 合并被动回复配置
+	if present["account.passive.auto"] {
+		result.Account.Passive.Auto = original.Account.Passive.Auto
+	}
+	if original.Account.Passive.Fallback != "" {
+		result.Account.Passive.Fallback = original.Account.Passive.Fallback
+	}
+
 	// 合并 FileServer 配置
 	result.FileServer.Enable = original.FileServer.Enable
 	if original.FileServer.ExternalURL != "" {
@@ -848,12 +1294,86 @@ func mergeConfigStructs(template, original *Config) *Config {
 	if original.FileServer.TTL != 0 {
 		result.FileServer.TTL = original.FileServer.TTL
 	}
+	if present["file_server.max_file_size"] {
+		result.FileServer.MaxFileSize = original.FileServer.MaxFileSize
+	}
+	if present["file_server.max_total_size"] {
+		result.FileServer.MaxTotalSize = original.FileServer.MaxTotalSize
+	}
+	if present["file_server.sign_url"] {
+		result.FileServer.SignURL = original.FileServer.SignURL
+	}
+	if original.FileServer.SignKey != "" {
+		result.FileServer.SignKey = original.FileServer.SignKey
+	}
+	if original.FileServer.URLTTL != 0 {
+		result.FileServer.URLTTL = original.FileServer.URLTTL
+	}
+	result.FileServer.SingleUse = original.FileServer.SingleUse
+	result.FileServer.Listener.Enable = original.FileServer.Listener.Enable
+	if original.FileServer.Listener.Host != "" {
+		result.FileServer.Listener.Host = original.FileServer.Listener.Host
+	}
+	if original.FileServer.Listener.Port != 0 {
+		result.FileServer.Listener.Port = original.FileServer.Listener.Port
+	}
+	if original.FileServer.Listener.TLSCert != "" {
+		result.FileServer.Listener.TLSCert = original.FileServer.Listener.TLSCert
+	}
+	if original.FileServer.Listener.TLSKey != "" {
+		result.FileServer.Listener.TLSKey = original.FileServer.Listener.TLSKey
+	}
 
 	// 合并 Database 配置
 	result.Database.MessageDatabase.Enable = original.Database.MessageDatabase.Enable
 	if original.Database.MessageDatabase.Limit != 0 {
 		result.Database.MessageDatabase.Limit = original.Database.MessageDatabase.Limit
 	}
+	if present["database.member_database.enable"] {
+		result.Database.MemberDatabase.Enable = original.Database.MemberDatabase.Enable
+	}
+
+	// 合并 Media 配置
+	if present["media.image.max_width"] {
+		result.Media.Image.MaxWidth = original.Media.Image.MaxWidth
+	}
+	if present["media.image.max_height"] {
+		result.Media.Image.MaxHeight = original.Media.Image.MaxHeight
+	}
+	if present["media.image.max_size"] {
+		result.Media.Image.MaxSize = original.Media.Image.MaxSize
+	}
+	if present["media.image.convert_webp"] {
+		result.Media.Image.ConvertWebP = original.Media.Image.ConvertWebP
+	}
+	result.Media.Audio.Decode = original.Media.Audio.Decode
+	if original.Media.Audio.Format != "" {
+		result.Media.Audio.Format = original.Media.Audio.Format
+	}
+	if original.Media.Transcode.Workers != 0 {
+		result.Media.Transcode.Workers = original.Media.Transcode.Workers
+	}
+	if present["media.transcode.timeout"] {
+		result.Media.Transcode.Timeout = original.Media.Transcode.Timeout
+	}
+	if present["media.transcode.cache"] {
+		result.Media.Transcode.Cache = original.Media.Transcode.Cache
+	}
+	if present["media.transcode.cache_ttl"] {
+		result.Media.Transcode.CacheTTL = original.Media.Transcode.CacheTTL
+	}
+	if original.Media.Limits.AudioMaxDuration != 0 {
+		result.Media.Limits.AudioMaxDuration = original.Media.Limits.AudioMaxDuration
+	}
+	if original.Media.Limits.AudioMaxSize != 0 {
+		result.Media.Limits.AudioMaxSize = original.Media.Limits.AudioMaxSize
+	}
+	if original.Media.Limits.VideoMaxDuration != 0 {
+		result.Media.Limits.VideoMaxDuration = original.Media.Limits.VideoMaxDuration
+	}
+	if original.Media.Limits.VideoMaxSize != 0 {
+		result.Media.Limits.VideoMaxSize = original.Media.Limits.VideoMaxSize
+	}
 
 	// 合并 Satori 配置
 	if original.Satori.Version != 0 {
@@ -874,10 +1394,55 @@ func mergeConfigStructs(template, original *Config) *Config {
 	if original.Satori.WebHook.Timeout != 0 {
 		result.Satori.WebHook.Timeout = original.Satori.WebHook.Timeout
 	}
+	if len(original.Satori.Proxy.URLs) > 0 {
+		result.Satori.Proxy.URLs = original.Satori.Proxy.URLs
+	}
+	if present["satori.proxy.timeout"] {
+		result.Satori.Proxy.Timeout = original.Satori.Proxy.Timeout
+	}
+	if present["satori.proxy.max_size"] {
+		result.Satori.Proxy.MaxSize = original.Satori.Proxy.MaxSize
+	}
+	result.Satori.Proxy.Cache = original.Satori.Proxy.Cache
+	if original.Satori.Proxy.CacheTTL != 0 {
+		result.Satori.Proxy.CacheTTL = original.Satori.Proxy.CacheTTL
+	}
+
+	// 合并限流配置
+	result.RateLimit.Enable = original.RateLimit.Enable
+	if present["rate_limit.queue"] {
+		result.RateLimit.Queue = original.RateLimit.Queue
+	}
+	if present["rate_limit.queue_size"] {
+		result.RateLimit.QueueSize = original.RateLimit.QueueSize
+	}
+	if present["rate_limit.queue_timeout"] {
+		result.RateLimit.QueueTimeout = original.RateLimit.QueueTimeout
+	}
+	result.RateLimit.Message = mergeRateLimitGroup(result.RateLimit.Message, original.RateLimit.Message, present, "rate_limit.message")
+	result.RateLimit.Manage = mergeRateLimitGroup(result.RateLimit.Manage, original.RateLimit.Manage, present, "rate_limit.manage")
+	result.RateLimit.Query = mergeRateLimitGroup(result.RateLimit.Query, original.RateLimit.Query, present, "rate_limit.query")
 
 	return &result
 }
 
+// mergeRateLimitGroup 合并接口分组的令牌桶配置，prefix 为分组的配置键
+func mergeRateLimitGroup(template, original RateLimitGroup, present configKeySet, prefix string) RateLimitGroup {
+	if present[prefix+".rate"] {
+		template.Rate = original.Rate
+	}
+	if present[prefix+".burst"] {
+		template.Burst = original.Burst
+	}
+	if present[prefix+".target_rate"] {
+		template.TargetRate = original.TargetRate
+	}
+	if present[prefix+".target_burst"] {
+		template.TargetBurst = original.TargetBurst
+	}
+	return template
+}
+
 // regenerateConfigFromTemplate 从模板重新生成配置文件
 func regenerateConfigFromTemplate(configPath string) error {
 	// 使用 DefaultConfigTemplate() 而不是 ConfigTemplate
@@ -1080,6 +1645,83 @@ func GetFileServerURL() string {
 	return instance.FileServer.ExternalURL
 }
 
+// GetPassiveConfig 获取被动回复配置
+func GetPassiveConfig() Passive {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if instance == nil {
+		return Passive{}
+	}
+	return instance.Account.Passive
+}
+
+// GetImageConfig 获取图片优化配置
+func GetImageConfig() ImageOptimize {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if instance == nil {
+		return ImageOptimize{}
+	}
+	return instance.Media.Image
+}
+
+// GetAudioConfig 获取语音解码配置
+func GetAudioConfig() AudioDecode {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if instance == nil {
+		return AudioDecode{}
+	}
+	return instance.Media.Audio
+}
+
+// GetTranscodeConfig 获取转码服务配置
+func GetTranscodeConfig() Transcode {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if instance == nil {
+		return Transcode{}
+	}
+	return instance.Media.Transcode
+}
+
+// GetMediaLimitsConfig 获取音视频上传限制配置
+func GetMediaLimitsConfig() MediaLimits {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if instance == nil {
+		return MediaLimits{}
+	}
+	return instance.Media.Limits
+}
+
+// GetProxyConfig 获取代理路由配置
+func GetProxyConfig() Proxy {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if instance == nil {
+		return Proxy{}
+	}
+	return instance.Satori.Proxy
+}
+
+// GetWebHookConfig 获取 WebHook 客户端配置
+func GetWebHookConfig() WebHook {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if instance == nil {
+		return WebHook{}
+	}
+	return instance.Satori.WebHook
+}
+
 const intentsDocs = `
       %s- "GUILDS"                  # 频道事件，该事件是默认订阅的
       %s- "GUILD_MEMBERS"           # 频道成员事件，该事件是默认订阅的
@@ -1121,3 +1763,16 @@ func dumpIntents(intents []string) string {
 		sharp(set["PUBLIC_GUILD_MESSAGES"]),
 	)
 }
+
+// dumpStringList 将字符串列表转换为 YAML 列表
+func dumpStringList(list []string) string {
+	if len(list) == 0 {
+		return " []"
+	}
+
+	var builder strings.Builder
+	for _, item := range list {
+		builder.WriteString(fmt.Sprintf("\n      - %q", item))
+	}
+	return builder.String()
+}
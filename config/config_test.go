@@ -0,0 +1,181 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mergeForTest 将配置文件内容与模板合并并解析为配置
+func mergeForTest(t *testing.T, data string) *Config {
+	t.Helper()
+
+	merged, err := mergeConfigWithTemplate([]byte(data))
+	if err != nil {
+		t.Fatalf("mergeConfigWithTemplate() error = %v", err)
+	}
+	conf := &Config{}
+	if err := yaml.Unmarshal(merged, conf); err != nil {
+		t.Fatalf("解析合并后的配置失败: %v", err)
+	}
+	return conf
+}
+
+func TestMergeConfigKeepsExplicitZero(t *testing.T) {
+	conf := mergeForTest(t, `
+log:
+  file: ""
+  max_age: 0
+  max_backups: 0
+file_server:
+  max_file_size: 0
+  max_total_size: 0
+media:
+  image:
+    max_width: 0
+    max_height: 0
+    max_size: 0
+  transcode:
+    timeout: 0
+    cache_ttl: 0
+rate_limit:
+  queue_size: 0
+  queue_timeout: 0
+  message:
+    rate: 0
+    target_rate: 0
+satori:
+  proxy:
+    timeout: 0
+    max_size: 0
+`)
+
+	if conf.Log.File != "" {
+		t.Errorf("log.file = %q, want empty", conf.Log.File)
+	}
+	if conf.Log.MaxAge != 0 {
+		t.Errorf("log.max_age = %d, want 0", conf.Log.MaxAge)
+	}
+	if conf.Log.MaxBackups != 0 {
+		t.Errorf("log.max_backups = %d, want 0", conf.Log.MaxBackups)
+	}
+	if conf.FileServer.MaxFileSize != 0 {
+		t.Errorf("file_server.max_file_size = %d, want 0", conf.FileServer.MaxFileSize)
+	}
+	if conf.FileServer.MaxTotalSize != 0 {
+		t.Errorf("file_server.max_total_size = %d, want 0", conf.FileServer.MaxTotalSize)
+	}
+	if conf.Media.Image.MaxWidth != 0 || conf.Media.Image.MaxHeight != 0 {
+		t.Errorf("media.image max size = %dx%d, want 0x0", conf.Media.Image.MaxWidth, conf.Media.Image.MaxHeight)
+	}
+	if conf.Media.Image.MaxSize != 0 {
+		t.Errorf("media.image.max_size = %d, want 0", conf.Media.Image.MaxSize)
+	}
+	if conf.Media.Transcode.Timeout != 0 {
+		t.Errorf("media.transcode.timeout = %d, want 0", conf.Media.Transcode.Timeout)
+	}
+	if conf.Media.Transcode.CacheTTL != 0 {
+		t.Errorf("media.transcode.cache_ttl = %d, want 0", conf.Media.Transcode.CacheTTL)
+	}
+	if conf.RateLimit.QueueSize != 0 {
+		t.Errorf("rate_limit.queue_size = %d, want 0", conf.RateLimit.QueueSize)
+	}
+	if conf.RateLimit.QueueTimeout != 0 {
+		t.Errorf("rate_limit.queue_timeout = %d, want 0", conf.RateLimit.QueueTimeout)
+	}
+	if conf.RateLimit.Message.Rate != 0 || conf.RateLimit.Message.TargetRate != 0 {
+		t.Errorf("rate_limit.message rates = %g/%g, want 0/0", conf.RateLimit.Message.Rate, conf.RateLimit.Message.TargetRate)
+	}
+	if conf.Satori.Proxy.Timeout != 0 {
+		t.Errorf("satori.proxy.timeout = %d, want 0", conf.Satori.Proxy.Timeout)
+	}
+	if conf.Satori.Proxy.MaxSize != 0 {
+		t.Errorf("satori.proxy.max_size = %d, want 0", conf.Satori.Proxy.MaxSize)
+	}
+	if conf.RateLimit.Message.Burst != DefaultConfig().RateLimit.Message.Burst {
+		t.Errorf("rate_limit.message.burst = %d, want %d", conf.RateLimit.Message.Burst, DefaultConfig().RateLimit.Message.Burst)
+	}
+}
+
+func TestMergeConfigFillsMissingKeys(t *testing.T) {
+	conf := mergeForTest(t, `
+file_server:
+  enable: true
+`)
+	defaults := DefaultConfig()
+
+	if conf.Log.File != defaults.Log.File {
+		t.Errorf("log.file = %q, want %q", conf.Log.File, defaults.Log.File)
+	}
+	if conf.FileServer.MaxFileSize != defaults.FileServer.MaxFileSize {
+		t.Errorf("file_server.max_file_size = %d, want %d", conf.FileServer.MaxFileSize, defaults.FileServer.MaxFileSize)
+	}
+	if conf.FileServer.MaxTotalSize != defaults.FileServer.MaxTotalSize {
+		t.Errorf("file_server.max_total_size = %d, want %d", conf.FileServer.MaxTotalSize, defaults.FileServer.MaxTotalSize)
+	}
+	if conf.RateLimit.Enable {
+		t.Error("rate_limit.enable = true, want false")
+	}
+	if conf.RateLimit.Query != defaults.RateLimit.Query {
+		t.Errorf("rate_limit.query = %+v, want %+v", conf.RateLimit.Query, defaults.RateLimit.Query)
+	}
+}
+
+func TestMergeConfigKeepsDefaultTrueOnUpgrade(t *testing.T) {
+	// 新增配置项之前的配置文件
+	conf := mergeForTest(t, `
+log_level: 4
+debug_mode: false
+account:
+  bot_id: 123456789
+  app_id: 123456789
+  token: "token"
+  app_secret: "secret"
+  sandbox: false
+  websocket:
+    enable: true
+    shards: 1
+    intents:
+      - "GUILDS"
+  webhook:
+    enable: false
+    host: ""
+    port: 8080
+    path: ""
+file_server:
+  enable: false
+  external_url: ""
+  ttl: 86400
+database:
+  message_database:
+    enable: true
+    limit: 20
+satori:
+  version: 1
+  path: ""
+  token: ""
+  server:
+    host: "127.0.0.1"
+    port: 8080
+  webhook:
+    timeout: 10
+`)
+
+	tests := []struct {
+		key string
+		got bool
+	}{
+		{"account.passive.auto", conf.Account.Passive.Auto},
+		{"file_server.sign_url", conf.FileServer.SignURL},
+		{"database.member_database.enable", conf.Database.MemberDatabase.Enable},
+		{"media.image.convert_webp", conf.Media.Image.ConvertWebP},
+		{"media.transcode.cache", conf.Media.Transcode.Cache},
+		{"rate_limit.queue", conf.RateLimit.Queue},
+	}
+
+	for _, tt := range tests {
+		if !tt.got {
+			t.Errorf("%s = false, want true", tt.key)
+		}
+	}
+}
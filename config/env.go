@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix 配置项环境变量前缀
+//
+// 配置键中的 . 替换为 _ 并转为大写，如 account.token 对应 GLYCCAT_ACCOUNT_TOKEN
+const EnvPrefix = "GLYCCAT_"
+
+// EnvFileSuffix 从文件读取配置值的环境变量后缀
+//
+// 如 GLYCCAT_ACCOUNT_TOKEN_FILE=/run/secrets/token 会读取该文件的内容作为 account.token
+const EnvFileSuffix = "_FILE"
+
+// EnvName 获取配置键对应的环境变量名
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// applyEnvOverrides 使用环境变量覆盖配置项，返回被覆盖的配置键
+func applyEnvOverrides(conf *Config) ([]string, error) {
+	var overridden []string
+	err := walkConfigFields(reflect.ValueOf(conf).Elem(), "", func(key string, field reflect.Value) error {
+		name := EnvName(key)
+		value, ok, err := lookupEnv(name)
+		if err != nil || !ok {
+			return err
+		}
+		if err := setFieldFromString(field, value); err != nil {
+			return fmt.Errorf("环境变量 %s 的值无效: %w", name, err)
+		}
+		overridden = append(overridden, key)
+		return nil
+	})
+	return overridden, err
+}
+
+// lookupEnv 读取环境变量，未设置时尝试读取 _FILE 变量指向的文件
+func lookupEnv(name string) (string, bool, error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true, nil
+	}
+	path, ok := os.LookupEnv(name + EnvFileSuffix)
+	if !ok {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("读取环境变量 %s 指向的文件失败: %w", name+EnvFileSuffix, err)
+	}
+	return strings.TrimSpace(string(data)), true, nil
+}
+
+// walkConfigFields 按 yaml 标签遍历配置结构体的所有叶子字段
+func walkConfigFields(value reflect.Value, prefix string, fn func(key string, field reflect.Value) error) error {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		name := strings.Split(valueType.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		field := value.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := walkConfigFields(field, key, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(key, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setFieldFromString 将字符串解析为字段对应的类型并赋值
+func setFieldFromString(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		// 列表以逗号分隔
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// configFieldValues 获取配置中所有叶子字段的值，键为配置键
+func configFieldValues(conf *Config) map[string]interface{} {
+	values := make(map[string]interface{})
+	for key, field := range configFields(conf) {
+		values[key] = field.Interface()
+	}
+	return values
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"log_level", "GLYCCAT_LOG_LEVEL"},
+		{"account.token", "GLYCCAT_ACCOUNT_TOKEN"},
+		{"rate_limit.message.target_rate", "GLYCCAT_RATE_LIMIT_MESSAGE_TARGET_RATE"},
+	}
+
+	for _, tt := range tests {
+		if got := EnvName(tt.key); got != tt.want {
+			t.Errorf("EnvName(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secret, []byte("file-secret\n"), 0600); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+
+	t.Setenv("GLYCCAT_LOG_LEVEL", "5")
+	t.Setenv("GLYCCAT_ACCOUNT_APP_ID", "10086")
+	t.Setenv("GLYCCAT_ACCOUNT_TOKEN", "env-token")
+	t.Setenv("GLYCCAT_ACCOUNT_APP_SECRET_FILE", secret)
+	t.Setenv("GLYCCAT_ACCOUNT_WEBSOCKET_INTENTS", "GUILDS, ,PUBLIC_GUILD_MESSAGES")
+	t.Setenv("GLYCCAT_FILE_SERVER_SIGN_URL", "false")
+	t.Setenv("GLYCCAT_RATE_LIMIT_MESSAGE_RATE", "1.5")
+
+	conf := DefaultConfig()
+	overridden, err := applyEnvOverrides(conf)
+	if err != nil {
+		t.Fatalf("applyEnvOverrides() error = %v", err)
+	}
+
+	tests := []struct {
+		key  string
+		got  interface{}
+		want interface{}
+	}{
+		{"log_level", int(conf.LogLevel), 5},
+		{"account.app_id", conf.Account.AppID, uint64(10086)},
+		{"account.token", conf.Account.Token, "env-token"},
+		{"account.app_secret", conf.Account.AppSecret, "file-secret"},
+		{"account.websocket.intents", conf.Account.WebSocket.Intents, []string{"GUILDS", "PUBLIC_GUILD_MESSAGES"}},
+		{"file_server.sign_url", conf.FileServer.SignURL, false},
+		{"rate_limit.message.rate", conf.RateLimit.Message.Rate, 1.5},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %#v, want %#v", tt.key, tt.got, tt.want)
+		}
+	}
+	if len(overridden) != len(tests) {
+		t.Errorf("overridden = %v, want %d keys", overridden, len(tests))
+	}
+}
+
+func TestApplyEnvOverridesInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"GLYCCAT_FILE_SERVER_SIGN_URL", "maybe"},
+		{"GLYCCAT_ACCOUNT_APP_ID", "-1"},
+		{"GLYCCAT_SATORI_SERVER_PORT", "port"},
+		{"GLYCCAT_ACCOUNT_TOKEN_FILE", filepath.Join(os.TempDir(), "glyccat-missing-secret")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			if _, err := applyEnvOverrides(DefaultConfig()); err == nil {
+				t.Errorf("applyEnvOverrides() error = nil, want error for %s=%q", tt.name, tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+)
+
+// liveReloadKeys 无需重启即可生效的配置键，以 . 结尾的表示该前缀下的所有配置键
+var liveReloadKeys = []string{
+	"log_level",
+	"log.",
+	"account.passive.",
+	"file_server.ttl",
+	"database.message_database.limit",
+	"media.image.",
+	"media.audio.",
+	"media.limits.",
+	"satori.token",
+	"satori.webhook.timeout",
+	"satori.proxy.",
+	"rate_limit.",
+}
+
+// ReloadResult 重新加载配置的结果
+type ReloadResult struct {
+	Applied         []string `json:"applied"`          // 已生效的配置键
+	RestartRequired []string `json:"restart_required"` // 需要重启才能生效的配置键
+	Config          *Config  `json:"-"`                // 重新加载后的配置，不应被修改
+}
+
+// Changed 配置是否发生变更
+func (r *ReloadResult) Changed() bool {
+	return len(r.Applied) > 0 || len(r.RestartRequired) > 0
+}
+
+// IsLiveReloadable 判断配置键是否无需重启即可生效
+func IsLiveReloadable(key string) bool {
+	for _, liveKey := range liveReloadKeys {
+		if key == liveKey || (strings.HasSuffix(liveKey, ".") && strings.HasPrefix(key, liveKey)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reload 重新读取配置文件与环境变量，并与当前配置比较
+//
+// 可以直接生效的配置项会写入当前配置的副本并替换当前配置，需要重启的配置项保持原值，两者分别记录在结果中。
+// 替换前的配置不会被修改，因此持有旧配置的组件不会与重新加载产生竞争，需要读取新值时应使用结果中的配置或各获取函数。
+// 重新加载不会进行任何交互，也不会改写配置文件
+func Reload() (*ReloadResult, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if instance == nil {
+		return nil, errors.New("配置尚未加载")
+	}
+
+	var next *Config
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		next = DefaultConfig()
+	} else {
+		next, err = readConfigFileWithDefaults(configPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := applyEnvOverrides(next); err != nil {
+		return nil, err
+	}
+	registerSecrets(next)
+
+	updated := *instance
+	currentFields := configFields(&updated)
+	nextFields := configFields(next)
+
+	keys := make([]string, 0, len(currentFields))
+	for key := range currentFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := &ReloadResult{
+		Applied:         []string{},
+		RestartRequired: []string{},
+	}
+	for _, key := range keys {
+		currentField, nextField := currentFields[key], nextFields[key]
+		if reflect.DeepEqual(currentField.Interface(), nextField.Interface()) {
+			continue
+		}
+		if !IsLiveReloadable(key) {
+			result.RestartRequired = append(result.RestartRequired, key)
+			continue
+		}
+		currentField.Set(nextField)
+		result.Applied = append(result.Applied, key)
+	}
+	instance = &updated
+	result.Config = instance
+
+	for _, key := range result.Applied {
+		log.Infof("配置项 %s 已重新加载", key)
+	}
+	for _, key := range result.RestartRequired {
+		log.Warnf("配置项 %s 已变更，需要重启后生效", key)
+	}
+
+	return result, nil
+}
+
+// configFields 获取配置中所有叶子字段，键为配置键
+func configFields(conf *Config) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	walkConfigFields(reflect.ValueOf(conf).Elem(), "", func(key string, field reflect.Value) error {
+		fields[key] = field
+		return nil
+	})
+	return fields
+}
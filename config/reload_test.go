@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+)
+
+func TestReloadKeepsPreviousConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("log_level: 5\nsatori:\n  server:\n    port: 9000\n"), 0644); err != nil {
+		t.Fatalf("写入配置文件失败: %v", err)
+	}
+
+	previous := DefaultConfig()
+	mutex.Lock()
+	instance, configPath = previous, path
+	mutex.Unlock()
+	t.Cleanup(func() {
+		mutex.Lock()
+		instance, configPath = nil, ""
+		mutex.Unlock()
+	})
+
+	result, err := Reload()
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if previous.LogLevel != log.INFO {
+		t.Errorf("previous log_level = %d, want %d", previous.LogLevel, log.INFO)
+	}
+	if result.Config == previous {
+		t.Fatal("Reload() result config is the previous config")
+	}
+	if result.Config.LogLevel != log.DEBUG {
+		t.Errorf("reloaded log_level = %d, want %d", result.Config.LogLevel, log.DEBUG)
+	}
+	if result.Config.Satori.Server.Port != previous.Satori.Server.Port {
+		t.Errorf("reloaded satori.server.port = %d, want %d", result.Config.Satori.Server.Port, previous.Satori.Server.Port)
+	}
+	if len(result.RestartRequired) != 1 || result.RestartRequired[0] != "satori.server.port" {
+		t.Errorf("RestartRequired = %v, want satori.server.port", result.RestartRequired)
+	}
+}
@@ -14,6 +14,15 @@ const ConfigTemplate = `# 配置文件
 #   - 6/7：输出所有日志
 log_level: %d
 
+# 日志输出配置
+log:
+  format: "%s" # 日志格式，可选 text 或 json ，json 格式会输出平台、频道、事件类型与请求 ID 等字段
+  file: "%s" # 日志文件路径，为空时只输出到控制台
+  max_size: %d # 单个日志文件大小上限，单位 MiB ，超出时轮转
+  max_age: %d # 日志文件保留天数，设置为 0 则不按时间删除
+  max_backups: %d # 日志文件保留数量，设置为 0 则不按数量删除
+  compress: %t # 是否使用 gzip 压缩轮转后的日志文件
+
 account: # QQ 机器人配置
   
   # QQ 机器人配置，需要通过 QQ 机器人管理端/开发/开发设置 获取
@@ -44,6 +53,12 @@ account: # QQ 机器人配置
     port: %d # WebHook 端口
     path: "%s" # WebHook 路径
 
+  # 被动回复配置
+  # 群聊与单聊中只能在收到消息或事件后的一段时间内进行有限次数的被动回复，超出后只能发送次数受限的主动消息
+  passive:
+    auto: %t # 消息中没有 qq:passive 元素时，是否自动回复该频道中最近收到的消息或事件并递增 msg_seq
+    fallback: "%s" # 没有可以被动回复的消息或事件时的处理方式，可选 proactive（发送主动消息）或 error（返回错误）
+
 # 本地文件服务器配置
 # 请确保配置正确，否则无法正常启动
 # enable 默认设置为 false ，如果需要使用本地文件服务器，请将其设置为 true
@@ -51,6 +66,25 @@ file_server:
   enable: %t # 是否使用本地文件服务器
   external_url: "%s" # 本地文件服务器公网地址 {{ .Host }}:{{ .Port }}
   ttl: %d # 文件存储时间，单位秒
+  max_file_size: %d # 单个文件大小上限，单位字节，设置为 0 则无上限
+  max_total_size: %d # 文件总大小上限，单位字节，超出时优先删除最久未使用的文件，设置为 0 则无上限
+
+  # 文件链接签名
+  # 启用后文件链接会带有签名，只有持有有效链接或 Satori 鉴权令牌的请求才能获取文件
+  # 交给 QQ 开放平台的链接带有有效期，返回给 Satori 应用的链接不会过期
+  sign_url: %t
+  sign_key: "%s" # 签名密钥，为空时自动生成并保存在文件目录中
+  url_ttl: %d # 交给 QQ 开放平台的文件链接有效期，单位秒
+  single_use: %t # 交给 QQ 开放平台的文件链接是否只能使用一次，开放平台可能会重复获取同一链接，请谨慎开启
+
+  # 独立的文件服务监听
+  # 启用后文件会在单独的地址上提供，该地址上不会挂载 Satori API 路由，此时 external_url 需要指向该地址
+  listener:
+    enable: %t # 是否启用独立监听
+    host: "%s" # 监听地址
+    port: %d # 监听端口
+    tls_cert: "%s" # TLS 证书文件路径，与 tls_key 同时设置时使用 HTTPS ，文件变更后会自动重新加载
+    tls_key: "%s" # TLS 私钥文件路径
 
 # 数据库配置
 # 关联到部分单聊/群聊 API 的使用以及程序的空间占用
@@ -65,6 +99,52 @@ database:
     enable: %t
     limit: %d # 消息获取数量限制，决定每次使用 API 可以获取多少消息，设置为 0 则无上限
 
+  # 成员数据库配置
+  member_database:
+
+    # 是否启用成员数据库
+    # QQ 群聊无法获取成员列表，启用后会记录发送过消息的群成员与用户，用于获取群组成员与用户信息
+    enable: %t
+
+# 媒体处理配置
+# 发送前会对媒体文件进行处理，使其符合 QQ 开放平台的上传限制
+media:
+
+  # 图片优化配置
+  # 超出尺寸或大小的图片会被缩放并重新编码，EXIF 方向会被应用到图片上
+  # GIF 重新编码会丢失动画，因此不会被缩放，超出大小上限时无法发送
+  image:
+    max_width: %d # 最大宽度，单位像素，设置为 0 则无上限
+    max_height: %d # 最大高度，单位像素，设置为 0 则无上限
+    max_size: %d # 图片大小上限，单位字节，超出时降低 JPEG 质量或缩小图片，设置为 0 则无上限
+    convert_webp: %t # 是否将 WebP 图片转换为 PNG/JPEG ，超出尺寸或大小限制的 WebP 图片总会被转换
+
+  # 语音解码配置
+  # QQ 的语音为 silk/amr 格式，大多数 Satori 应用无法直接播放或识别
+  # 启用后会下载收到的语音并解码保存至本地文件服务器，audio 元素的 src 会被替换为本地链接，原始链接保留在 origin-src 属性中
+  # 一条消息中的多段语音会并发解码，为避免阻塞后续事件，全部下载与解码共用 3 秒的期限，超时的语音会保留原始链接
+  # 需要启用本地文件服务器，解码 amr 与输出 ogg 需要安装 ffmpeg
+  # Windows 下不支持解码 silk 语音，只有 amr 语音会被解码
+  audio:
+    decode: %t # 是否解码收到的语音
+    format: "%s" # 解码格式，可选 wav 或 ogg
+
+  # 转码服务配置
+  # 音频、视频与图片的转码会在有限的并发数内进行，相同内容的转码结果会被缓存
+  transcode:
+    workers: %d # 最大并发转码数
+    timeout: %d # 单个转码任务的超时时间，单位秒，设置为 0 则无限制
+    cache: %t # 是否缓存转码结果
+    cache_ttl: %d # 转码结果缓存有效期，单位秒，设置为 0 则不过期
+
+  # 音视频上传限制
+  # 发送前会解析音视频的时长，超出限制时不会进行转码与上传；无法解析原始时长时会在转码后检查
+  limits:
+    audio_max_duration: %d # 音频最大时长，单位秒，设置为 0 则无限制
+    audio_max_size: %d # 转码后的音频大小上限，单位字节，设置为 0 则无限制
+    video_max_duration: %d # 视频最大时长，单位秒，设置为 0 则无限制
+    video_max_size: %d # 转码后的视频大小上限，单位字节，设置为 0 则无限制
+
 satori: # Satori 配置
   version: %d # Satori 版本，目前只有 1
   path: "%s" # Satori 部署路径，可以为空，如果不为空需要以 / 开头
@@ -77,4 +157,44 @@ satori: # Satori 配置
 
   # WebHook 配置
   webhook:
-    timeout: %d # WebHook 事件推送超时时间，单位为秒，设置为 0 则时间为无限`
+    timeout: %d # WebHook 事件推送超时时间，单位为秒，设置为 0 则时间为无限
+
+  # 代理路由配置
+  # 符合以下前缀的 URL 可以通过 /v1/proxy 路由由 GlycCat 代为获取
+  proxy:
+    urls:%s
+    timeout: %d # 代理请求超时时间，单位为秒，设置为 0 则时间为无限
+    max_size: %d # 代理资源大小上限，单位为字节，设置为 0 则无上限
+    cache: %t # 是否将代理资源缓存到本地
+    cache_ttl: %d # 代理资源缓存有效期，单位为秒
+
+# OpenAPI 限流配置
+# 向 QQ 开放平台发起的请求按照接口分组与目标（频道、私信、群聊、用户或频道服务器）进行限流
+# 每个分组有一个共享的令牌桶，分组内的每个目标另有独立的令牌桶，rate 设置为 0 则不限制
+# 无法排队时 Satori API 返回 429 ，并在 Retry-After 响应头中给出建议的重试等待时间
+rate_limit:
+  enable: %t # 是否启用限流
+  queue: %t # 超出限制时是否排队等待，设置为 false 则立即返回 429
+  queue_size: %d # 每个令牌桶最多排队的请求数，超出时返回 429 ，设置为 0 则无上限
+  queue_timeout: %d # 最长排队时间，单位为秒，预计等待时间超出时返回 429
+
+  # 发送消息
+  message:
+    rate: %g # 每秒补充的令牌数
+    burst: %d # 令牌桶容量，即允许连续发起的请求数
+    target_rate: %g # 每个目标每秒补充的令牌数
+    target_burst: %d # 每个目标的令牌桶容量
+
+  # 除发送消息以外的写操作，如撤回消息、上传文件、禁言与修改频道
+  manage:
+    rate: %g # 每秒补充的令牌数
+    burst: %d # 令牌桶容量，即允许连续发起的请求数
+    target_rate: %g # 每个目标每秒补充的令牌数
+    target_burst: %d # 每个目标的令牌桶容量
+
+  # 查询操作
+  query:
+    rate: %g # 每秒补充的令牌数
+    burst: %d # 令牌桶容量，即允许连续发起的请求数
+    target_rate: %g # 每个目标每秒补充的令牌数
+    target_burst: %d # 每个目标的令牌桶容量`
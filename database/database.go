@@ -1 +1,31 @@
 package database
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Close 关闭消息数据库与成员数据库，未启动的数据库会被跳过
+//
+// 会等待正在进行的读写完成，关闭后的读写会返回 leveldb.ErrClosed
+func Close() error {
+	var errs []error
+
+	if instance := messageDBInstance; instance != nil {
+		instance.mu.Lock()
+		if err := instance.DB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("关闭消息数据库失败: %w", err))
+		}
+		instance.mu.Unlock()
+	}
+
+	if instance := memberDBInstance; instance != nil {
+		instance.mu.Lock()
+		if err := instance.DB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("关闭成员数据库失败: %w", err))
+		}
+		instance.mu.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
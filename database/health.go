@@ -0,0 +1,32 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// ErrNotStarted 数据库未启动
+var ErrNotStarted = errors.New("database not started")
+
+// PingMessageDB 检查消息数据库是否可用
+func PingMessageDB() error {
+	if messageDBInstance == nil {
+		return ErrNotStarted
+	}
+	return ping(messageDBInstance.DB)
+}
+
+// PingMemberDB 检查成员数据库是否可用
+func PingMemberDB() error {
+	if memberDBInstance == nil {
+		return ErrNotStarted
+	}
+	return ping(memberDBInstance.DB)
+}
+
+// ping 读取数据库属性以确认数据库未关闭
+func ping(db *leveldb.DB) error {
+	_, err := db.GetProperty("leveldb.num-files-at-level0")
+	return err
+}
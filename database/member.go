@@ -0,0 +1,235 @@
+package database
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sync"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const memberDBPath string = "data/db/members"
+
+// ObservedMember 观察到的成员信息
+//
+// QQ 单聊/群聊没有提供成员查询接口，只能记录发送过消息的用户
+type ObservedMember struct {
+	UserId       string // 用户 ID
+	Avatar       string // 用户头像
+	FirstSeen    int64  // 第一次观察到的时间，毫秒时间戳
+	LastSeen     int64  // 最后一次观察到的时间，毫秒时间戳
+	MessageCount int64  // 观察到的消息数
+}
+
+// MemberDB 成员数据库
+type MemberDB struct {
+	DB *leveldb.DB
+	mu sync.Mutex
+}
+
+var memberDBInstance *MemberDB
+
+// StartMemberDB 启动成员数据库
+func StartMemberDB() error {
+	// 创建或打开成员缓存数据库
+	db, err := leveldb.OpenFile(memberDBPath, nil)
+	if err != nil {
+		return err
+	}
+
+	memberDBInstance = &MemberDB{
+		DB: db,
+	}
+
+	return nil
+}
+
+// groupMemberKey 生成群成员键
+func groupMemberKey(groupId, userId string) []byte {
+	return []byte(fmt.Sprintf("group:%s:%s", groupId, userId))
+}
+
+// userKey 生成单聊用户键
+func userKey(userId string) []byte {
+	return []byte(fmt.Sprintf("user:%s", userId))
+}
+
+// groupUserKey 生成群成员用户键
+//
+// 群成员的 member_openid 与单聊的 user_openid 不属于同一 ID 空间，需要分开记录
+func groupUserKey(userId string) []byte {
+	return []byte(fmt.Sprintf("group_user:%s", userId))
+}
+
+// ObserveMember 记录一次群成员发言
+func ObserveMember(groupId, userId, avatar string, seenAt int64) error {
+	if memberDBInstance == nil {
+		return nil
+	}
+
+	memberDBInstance.mu.Lock()
+	defer memberDBInstance.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+	if err := observe(batch, groupMemberKey(groupId, userId), userId, avatar, seenAt); err != nil {
+		return err
+	}
+	if err := observe(batch, groupUserKey(userId), userId, avatar, seenAt); err != nil {
+		return err
+	}
+	return memberDBInstance.DB.Write(batch, nil)
+}
+
+// ObserveUser 记录一次单聊用户发言
+func ObserveUser(userId, avatar string, seenAt int64) error {
+	if memberDBInstance == nil {
+		return nil
+	}
+
+	memberDBInstance.mu.Lock()
+	defer memberDBInstance.mu.Unlock()
+
+	batch := new(leveldb.Batch)
+	if err := observe(batch, userKey(userId), userId, avatar, seenAt); err != nil {
+		return err
+	}
+	return memberDBInstance.DB.Write(batch, nil)
+}
+
+// observe 更新观察记录，调用前需要持有锁
+func observe(batch *leveldb.Batch, key []byte, userId, avatar string, seenAt int64) error {
+	member, err := getObservedMember(key)
+	if err == leveldb.ErrNotFound {
+		member = &ObservedMember{
+			UserId:    userId,
+			FirstSeen: seenAt,
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if avatar != "" {
+		member.Avatar = avatar
+	}
+	if seenAt < member.FirstSeen {
+		member.FirstSeen = seenAt
+	}
+	if seenAt > member.LastSeen {
+		member.LastSeen = seenAt
+	}
+	member.MessageCount++
+
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	if err := enc.Encode(member); err != nil {
+		return err
+	}
+	batch.Put(key, buf.Bytes())
+	return nil
+}
+
+// getObservedMember 读取观察记录，调用前需要持有锁
+func getObservedMember(key []byte) (*ObservedMember, error) {
+	data, err := memberDBInstance.DB.Get(key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var member ObservedMember
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	if err := dec.Decode(&member); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// MemberDBEnabled 成员数据库是否已启用
+func MemberDBEnabled() bool {
+	return memberDBInstance != nil
+}
+
+// GetObservedMember 获取观察到的群成员，不存在时返回 nil
+func GetObservedMember(groupId, userId string) (*ObservedMember, error) {
+	if memberDBInstance == nil {
+		return nil, nil
+	}
+
+	memberDBInstance.mu.Lock()
+	defer memberDBInstance.mu.Unlock()
+
+	member, err := getObservedMember(groupMemberKey(groupId, userId))
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	return member, err
+}
+
+// GetObservedUser 获取观察到的用户，不存在时返回 nil
+//
+// 优先查找单聊用户，找不到时再查找群成员
+func GetObservedUser(userId string) (*ObservedMember, error) {
+	if memberDBInstance == nil {
+		return nil, nil
+	}
+
+	memberDBInstance.mu.Lock()
+	defer memberDBInstance.mu.Unlock()
+
+	member, err := getObservedMember(userKey(userId))
+	if err == leveldb.ErrNotFound {
+		member, err = getObservedMember(groupUserKey(userId))
+	}
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	return member, err
+}
+
+// GetObservedMemberList 获取观察到的群成员列表
+//
+// next 为上一页最后一个成员的用户 ID ，返回值中的 next 为空时表示没有更多成员
+func GetObservedMemberList(groupId, next string, limit int) ([]*ObservedMember, string, error) {
+	if memberDBInstance == nil {
+		return []*ObservedMember{}, "", nil
+	}
+
+	memberDBInstance.mu.Lock()
+	defer memberDBInstance.mu.Unlock()
+
+	prefix := []byte(fmt.Sprintf("group:%s:", groupId))
+	iter := memberDBInstance.DB.NewIterator(util.BytesPrefix(prefix), nil)
+	defer iter.Release()
+
+	// 初始化迭代器位置
+	var ok bool
+	if next != "" {
+		nextKey := groupMemberKey(groupId, next)
+		ok = iter.Seek(nextKey)
+		if ok && bytes.Equal(iter.Key(), nextKey) {
+			ok = iter.Next()
+		}
+	} else {
+		ok = iter.First()
+	}
+
+	members := []*ObservedMember{}
+	for ; ok; ok = iter.Next() {
+		if limit > 0 && len(members) >= limit {
+			// 仍有剩余成员
+			return members, members[len(members)-1].UserId, iter.Error()
+		}
+
+		var member ObservedMember
+		dec := gob.NewDecoder(bytes.NewReader(iter.Value()))
+		if err := dec.Decode(&member); err != nil {
+			log.Warnf("解析成员记录 %s 失败，已跳过: %v", iter.Key(), err)
+			continue
+		}
+		members = append(members, &member)
+	}
+
+	return members, "", iter.Error()
+}
@@ -0,0 +1,191 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// useMemoryMemberDB 使用内存中的成员数据库，测试结束后恢复
+func useMemoryMemberDB(t *testing.T) {
+	t.Helper()
+
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatalf("leveldb.Open() error = %v", err)
+	}
+
+	previous := memberDBInstance
+	memberDBInstance = &MemberDB{DB: db}
+	t.Cleanup(func() {
+		memberDBInstance = previous
+		db.Close()
+	})
+}
+
+func TestObserveMemberUpdatesRecord(t *testing.T) {
+	useMemoryMemberDB(t)
+
+	observations := []struct {
+		avatar string
+		seenAt int64
+	}{
+		{"avatar-1", 2000},
+		{"", 1000},
+		{"avatar-2", 3000},
+	}
+	for _, o := range observations {
+		if err := ObserveMember("group", "user", o.avatar, o.seenAt); err != nil {
+			t.Fatalf("ObserveMember() error = %v", err)
+		}
+	}
+
+	member, err := GetObservedMember("group", "user")
+	if err != nil {
+		t.Fatalf("GetObservedMember() error = %v", err)
+	}
+	if member == nil {
+		t.Fatal("GetObservedMember() = nil, want member")
+	}
+	if member.UserId != "user" || member.Avatar != "avatar-2" {
+		t.Errorf("member = %s/%s, want user/avatar-2", member.UserId, member.Avatar)
+	}
+	if member.FirstSeen != 1000 || member.LastSeen != 3000 {
+		t.Errorf("seen = %d-%d, want 1000-3000", member.FirstSeen, member.LastSeen)
+	}
+	if member.MessageCount != 3 {
+		t.Errorf("MessageCount = %d, want 3", member.MessageCount)
+	}
+}
+
+func TestObserveSeparatesGroupAndUser(t *testing.T) {
+	useMemoryMemberDB(t)
+
+	if err := ObserveMember("group-1", "user", "group-avatar", 1000); err != nil {
+		t.Fatalf("ObserveMember() error = %v", err)
+	}
+	if err := ObserveMember("group-2", "user", "group-avatar", 2000); err != nil {
+		t.Fatalf("ObserveMember() error = %v", err)
+	}
+
+	// 只在群聊中出现过的用户从群成员记录中获取
+	user, err := GetObservedUser("user")
+	if err != nil {
+		t.Fatalf("GetObservedUser() error = %v", err)
+	}
+	if user == nil || user.MessageCount != 2 || user.Avatar != "group-avatar" {
+		t.Fatalf("GetObservedUser() = %+v, want group record with 2 messages", user)
+	}
+
+	// 单聊记录优先，且不计入群成员的消息数
+	if err := ObserveUser("user", "user-avatar", 3000); err != nil {
+		t.Fatalf("ObserveUser() error = %v", err)
+	}
+	user, err = GetObservedUser("user")
+	if err != nil {
+		t.Fatalf("GetObservedUser() error = %v", err)
+	}
+	if user == nil || user.MessageCount != 1 || user.Avatar != "user-avatar" {
+		t.Errorf("GetObservedUser() = %+v, want c2c record with 1 message", user)
+	}
+
+	tests := []struct {
+		groupId string
+		count   int64
+		seen    int64
+	}{
+		{"group-1", 1, 1000},
+		{"group-2", 1, 2000},
+	}
+	for _, tt := range tests {
+		member, err := GetObservedMember(tt.groupId, "user")
+		if err != nil {
+			t.Fatalf("GetObservedMember(%s) error = %v", tt.groupId, err)
+		}
+		if member == nil || member.MessageCount != tt.count || member.LastSeen != tt.seen {
+			t.Errorf("GetObservedMember(%s) = %+v, want count %d seen %d", tt.groupId, member, tt.count, tt.seen)
+		}
+	}
+
+	if member, err := GetObservedMember("group-3", "user"); err != nil || member != nil {
+		t.Errorf("GetObservedMember(group-3) = %+v, %v, want nil, nil", member, err)
+	}
+}
+
+func TestGetObservedMemberListPagination(t *testing.T) {
+	useMemoryMemberDB(t)
+
+	for _, userId := range []string{"a", "b", "c", "d", "e"} {
+		if err := ObserveMember("group", userId, "", 1000); err != nil {
+			t.Fatalf("ObserveMember() error = %v", err)
+		}
+	}
+	// 其他群与单聊的记录不应出现在列表中
+	if err := ObserveMember("group-other", "x", "", 1000); err != nil {
+		t.Fatalf("ObserveMember() error = %v", err)
+	}
+	if err := ObserveUser("y", "", 1000); err != nil {
+		t.Fatalf("ObserveUser() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		next     string
+		limit    int
+		wantIds  []string
+		wantNext string
+	}{
+		{"first page", "", 2, []string{"a", "b"}, "b"},
+		{"middle page", "b", 2, []string{"c", "d"}, "d"},
+		{"last page", "d", 2, []string{"e"}, ""},
+		{"exact last page", "c", 2, []string{"d", "e"}, ""},
+		{"no limit", "", 0, []string{"a", "b", "c", "d", "e"}, ""},
+		{"missing cursor", "bb", 2, []string{"c", "d"}, "d"},
+		{"past end", "e", 2, []string{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members, next, err := GetObservedMemberList("group", tt.next, tt.limit)
+			if err != nil {
+				t.Fatalf("GetObservedMemberList() error = %v", err)
+			}
+			var ids []string
+			for _, member := range members {
+				ids = append(ids, member.UserId)
+			}
+			if len(ids) != len(tt.wantIds) {
+				t.Fatalf("ids = %v, want %v", ids, tt.wantIds)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIds[i] {
+					t.Fatalf("ids = %v, want %v", ids, tt.wantIds)
+				}
+			}
+			if next != tt.wantNext {
+				t.Errorf("next = %q, want %q", next, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestGetObservedMemberListSkipsCorruptRecord(t *testing.T) {
+	useMemoryMemberDB(t)
+
+	for _, userId := range []string{"a", "c"} {
+		if err := ObserveMember("group", userId, "", 1000); err != nil {
+			t.Fatalf("ObserveMember() error = %v", err)
+		}
+	}
+	if err := memberDBInstance.DB.Put(groupMemberKey("group", "b"), []byte("corrupt"), nil); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	members, next, err := GetObservedMemberList("group", "", 0)
+	if err != nil {
+		t.Fatalf("GetObservedMemberList() error = %v", err)
+	}
+	if len(members) != 2 || members[0].UserId != "a" || members[1].UserId != "c" || next != "" {
+		t.Errorf("GetObservedMemberList() = %d members, next %q, want a and c", len(members), next)
+	}
+}
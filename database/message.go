@@ -51,6 +51,30 @@ func StartMessageDB(messageLimit int) error {
 	return nil
 }
 
+// SetMessageLimit 设置消息获取数量限制，为 0 时不限制
+func SetMessageLimit(limit int) {
+	if messageDBInstance == nil {
+		return
+	}
+
+	messageDBInstance.mu.Lock()
+	defer messageDBInstance.mu.Unlock()
+
+	messageDBInstance.limit = limit
+}
+
+// MessageLimit 获取消息获取数量限制，为 0 时不限制
+func MessageLimit() int {
+	if messageDBInstance == nil {
+		return 0
+	}
+
+	messageDBInstance.mu.Lock()
+	defer messageDBInstance.mu.Unlock()
+
+	return messageDBInstance.limit
+}
+
 // SaveMessage 保存消息
 func SaveMessage(data *message.Message, channelId, channelType string) error {
 	if messageDBInstance == nil {
@@ -4,12 +4,15 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/WindowsSov8forUs/glyccat/config"
@@ -30,18 +33,43 @@ type FileServer struct {
 	path string
 	// URL 文件服务器 URL
 	URL string
+	// Scheme 文件链接协议
+	Scheme string
 	// EnableLocalFileServer 是否使用本地文件服务器
 	Enable bool
+	// Public 是否配置了公网地址，只有配置了公网地址时才能将文件链接交给 QQ 开放平台
+	Public bool
 	// TTL 默认文件有效期
 	TTL time.Duration
 	// MetaDB 文件元数据数据库
 	MetaDB *MetaDatabase
 	// FileInfoDB 文件信息数据库
 	FileInfoDB *FileInfoDatabase
+	// MaxFileSize 单个文件大小上限，为 0 时无上限
+	MaxFileSize int64
+	// MaxTotalSize 文件总大小上限，为 0 时无上限
+	MaxTotalSize int64
+
+	usedBytes int64            // 已使用的存储空间
+	storageMu sync.Mutex       // 存储空间锁，保证用量统计与文件写入一致
+	scheduler *ExpiryScheduler // 文件与文件信息的过期调度器
+	signer    *URLSigner       // 文件链接签名器，未启用签名时为 nil
 }
 
 var instance *FileServer
 
+// SetTTL 设置之后保存的文件的默认有效期，已保存的文件不受影响
+func SetTTL(ttl time.Duration) {
+	if instance == nil {
+		return
+	}
+
+	instance.storageMu.Lock()
+	defer instance.storageMu.Unlock()
+
+	instance.TTL = ttl
+}
+
 // StartFileServer 启动文件服务器
 func StartFileServer(conf *config.Config) {
 	log.Info("正在启动文件服务器...")
@@ -57,6 +85,14 @@ func StartFileServer(conf *config.Config) {
 		return
 	}
 
+	// 创建文件链接签名器
+	signer, err := newURLSigner(conf.FileServer)
+	if err != nil {
+		log.Errorf("创建文件链接签名器失败: %s", err)
+		instance = nil
+		return
+	}
+
 	// 启动文件元数据数据库
 	metaDB, err := StartMetaDB()
 	if err != nil {
@@ -73,30 +109,77 @@ func StartFileServer(conf *config.Config) {
 		return
 	}
 
+	// 独立监听配置了 TLS 时使用 HTTPS
+	scheme := "http"
+	listener := conf.FileServer.Listener
+	if listener.Enable && listener.TLSCert != "" && listener.TLSKey != "" {
+		scheme = "https"
+	}
+
 	instance = &FileServer{
 		version:    fmt.Sprintf("v%d", conf.Satori.Version),
 		path:       conf.Satori.Path,
 		URL:        publicURL,
+		Scheme:     scheme,
 		TTL:        time.Duration(conf.FileServer.TTL) * time.Second,
 		Enable:     conf.FileServer.Enable,
 		MetaDB:     metaDB,
 		FileInfoDB: fileInfoDB,
+
+		MaxFileSize:  int64(conf.FileServer.MaxFileSize),
+		MaxTotalSize: int64(conf.FileServer.MaxTotalSize),
+
+		scheduler: newExpiryScheduler(),
+		signer:    signer,
 	}
 
+	// 统计存储用量
+	loadStorageUsage()
+
 	// 清理过期文件
 	metaDBCleanup()
 	fileInfoDBCleanup()
 
-	// 确保文件服务器对公网开放
-	if instance.URL == "" {
-		log.Warn("文件服务器未配置公网地址，可能导致无法向 QQ 开放平台上传文件。")
-		instance.Enable = false
-		instance.URL = fmt.Sprintf("127.0.0.1:%d", conf.Satori.Server.Port)
+	// 未配置公网地址时文件只保存在本地，发送时直接上传至 QQ 开放平台
+	instance.Public = instance.URL != ""
+	if !instance.Public {
+		if listener.Enable {
+			instance.URL = fmt.Sprintf("127.0.0.1:%d", listener.Port)
+		} else {
+			instance.URL = fmt.Sprintf("127.0.0.1:%d", conf.Satori.Server.Port)
+		}
 	}
 
-	if instance.Enable {
+	if !instance.Enable {
+		return
+	}
+	if instance.Public {
 		log.Infof("文件服务器已启动，公网 IP : %s", instance.URL)
+	} else {
+		log.Warn("文件服务器未配置公网地址，文件只会保存在本地，发送时将直接上传至 QQ 开放平台。")
+	}
+}
+
+// Close 停止过期清理并关闭文件服务器的数据库，未启动时直接返回
+func Close() error {
+	if instance == nil {
+		return nil
+	}
+
+	// 先停止过期清理，避免清理时数据库已关闭
+	instance.scheduler.Stop()
+
+	instance.storageMu.Lock()
+	defer instance.storageMu.Unlock()
+
+	var errs []error
+	if err := instance.MetaDB.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("关闭文件元数据数据库失败: %w", err))
+	}
+	if err := instance.FileInfoDB.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("关闭文件信息数据库失败: %w", err))
 	}
+	return errors.Join(errs...)
 }
 
 // metaDBCleanup 文件元数据数据库清理
@@ -115,7 +198,7 @@ func metaDBCleanup() {
 	}
 
 	for _, meta := range files {
-		// 启动文件清理定时器
+		// 加入过期调度，已过期的文件会在调度器中立即清理
 		fileCleaner(meta)
 	}
 
@@ -124,7 +207,7 @@ func metaDBCleanup() {
 
 // fileInfoDBCleanup 文件信息数据库清理
 func fileInfoDBCleanup() {
-	if instance == nil || !instance.Enable {
+	if instance == nil {
 		return
 	}
 
@@ -138,21 +221,26 @@ func fileInfoDBCleanup() {
 	}
 
 	for _, info := range infos {
-		// 启动文件信息清理定时器
+		// 加入过期调度，已过期的文件信息会在调度器中立即清理
 		fileInfoCleaner(info)
 	}
 
 	log.Trace("文件信息数据库清理完成。")
 }
 
-// CalculateFileIdent 计算文件标识符
-func CalculateFileIdent(platform, userId string, file io.Reader) (string, error) {
+// newFileIdentHash 创建用于计算文件标识符的哈希
+func newFileIdentHash(platform, userId string) hash.Hash {
 	// 创建来源哈希
 	fromIdent := platform + ":" + userId
 	fromHash := sha256.Sum256([]byte(fromIdent))
 
 	// 计算文件内容哈希，使用来源哈希作为 HMAC 密钥
-	h := hmac.New(sha256.New, fromHash[:])
+	return hmac.New(sha256.New, fromHash[:])
+}
+
+// CalculateFileIdent 计算文件标识符
+func CalculateFileIdent(platform, userId string, file io.Reader) (string, error) {
+	h := newFileIdentHash(platform, userId)
 	if _, err := io.Copy(h, file); err != nil {
 		return "", err
 	}
@@ -175,6 +263,8 @@ func CalculateFileInfoIdent(targetId, src string) (string, error) {
 }
 
 // SaveFile 保存文件并返回内部链接
+//
+// 文件会先流式写入临时文件，计算标识后再移动至存储目录，相同的文件只会保存一份
 func SaveFile(file io.Reader, platform, userId, name, fileType string) (*FileMetadata, error) {
 	if instance == nil || !instance.Enable {
 		return nil, fmt.Errorf("文件服务器未启用！")
@@ -186,26 +276,49 @@ func SaveFile(file io.Reader, platform, userId, name, fileType string) (*FileMet
 		return nil, err
 	}
 
-	// 读取文件内容到内存
-	fileContent, err := io.ReadAll(file)
+	// 写入临时文件并生成文件名
+	tempPath, size, fileName, err := writeTempFile(file, platform, userId)
 	if err != nil {
-		log.Errorf("读取文件内容失败: %s", err)
+		log.Errorf("写入文件内容失败: %s", err)
 		return nil, err
 	}
+	defer os.Remove(tempPath)
 
-	// 生成文件名
-	fileName, err := CalculateFileIdent(platform, userId, strings.NewReader(string(fileContent)))
-	if err != nil {
-		log.Errorf("计算文件标识符失败: %s", err)
+	instance.storageMu.Lock()
+	defer instance.storageMu.Unlock()
+
+	path := filepath.Join(filePath, fileName)
+	now := uint64(time.Now().Unix())
+
+	// 文件已存在时直接复用，并刷新有效期
+	if meta, err := instance.MetaDB.GetFileMeta(fileName); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			meta.CreateAt = now
+			meta.LastAccess = now
+			meta.TTL = uint64(instance.TTL.Seconds())
+			if err := instance.MetaDB.SaveFileMeta(fileName, meta); err != nil {
+				log.Errorf("保存文件元数据失败: %s", err)
+			}
+			meta.deduplicated = true
+			fileCleaner(meta)
+
+			log.Debugf("文件 %s 已存在，复用已保存的文件", fileName)
+			return meta, nil
+		}
+	}
+
+	// 预留存储空间
+	if err := reserveStorage(size); err != nil {
+		log.Errorf("保存文件失败: %s", err)
 		return nil, err
 	}
 
-	// 保存数据
-	path := filepath.Join(filePath, fileName)
-	if err := os.WriteFile(path, fileContent, 0644); err != nil {
+	// 移动至存储目录
+	if err := os.Rename(tempPath, path); err != nil {
 		log.Errorf("保存文件失败: %s", err)
 		return nil, err
 	}
+	instance.usedBytes += size
 
 	// 存储文件元数据
 	meta := &FileMetadata{
@@ -214,15 +327,17 @@ func SaveFile(file io.Reader, platform, userId, name, fileType string) (*FileMet
 		URL:         fmt.Sprintf(internalFormat, platform, userId, fileName),
 		Path:        path,
 		ContentType: fileType,
-		CreateAt:    uint64(time.Now().Unix()),
+		CreateAt:    now,
 		TTL:         uint64(instance.TTL.Seconds()),
+		Size:        size,
+		LastAccess:  now,
 	}
 	if err := instance.MetaDB.SaveFileMeta(fileName, meta); err != nil {
 		log.Errorf("保存文件元数据失败: %s", err)
 	}
 
-	// 启动文件清理定时器
-	meta.cleanerTimer = fileCleaner(meta)
+	// 加入过期调度
+	fileCleaner(meta)
 
 	return meta, nil
 }
@@ -252,8 +367,8 @@ func SaveFileInfo(targetId, src, fileInfo string, ttl uint64) (*FileInfo, error)
 		return nil, err
 	}
 
-	// 启动文件信息清理定时器
-	info.cleanerTimer = fileInfoCleaner(info)
+	// 加入过期调度
+	fileInfoCleaner(info)
 
 	return info, nil
 }
@@ -278,6 +393,9 @@ func GetFile(ident string) (*FileMetadata, error) {
 		return nil, fmt.Errorf("文件不存在: %s", filePath)
 	}
 
+	// 更新最后访问时间
+	touchFile(meta)
+
 	return meta, nil
 }
 
@@ -303,13 +421,11 @@ func DeleteFile(ident string) error {
 		return nil
 	}
 
-	// 删除文件元数据
-	if err := instance.MetaDB.DeleteFileMeta(ident); err != nil {
-		log.Errorf("删除文件元数据失败: %s", err)
-	}
+	instance.storageMu.Lock()
+	defer instance.storageMu.Unlock()
 
-	// 删除文件
-	return os.Remove(filepath.Join(filePath, ident))
+	_, err := deleteFileLocked(ident)
+	return err
 }
 
 // DeleteFileInfo 删除文件信息
@@ -318,6 +434,9 @@ func DeleteFileInfo(ident string) error {
 		return nil
 	}
 
+	// 取消过期调度
+	instance.scheduler.Unschedule(expiryFileInfo, ident)
+
 	// 删除文件信息
 	if err := instance.FileInfoDB.DeleteFileInfo(ident); err != nil {
 		log.Errorf("删除文件信息失败: %s", err)
@@ -327,62 +446,67 @@ func DeleteFileInfo(ident string) error {
 	return nil
 }
 
-// fileCleaner 定期清理过期文件
-func fileCleaner(meta *FileMetadata) *time.Timer {
+// fileCleaner 将文件加入过期调度
+func fileCleaner(meta *FileMetadata) {
 	if meta.TTL == 0 {
-		return nil // 没有设置过期时间
-	}
-
-	// 计算过期时间戳
-	expireAt := meta.CreateAt + meta.TTL
-	now := uint64(time.Now().Unix())
-	if expireAt <= now {
-		// 文件已过期，删除文件和元数据
-		fileCleanerFunc(meta)
+		return // 没有设置过期时间
 	}
-
-	// 计算过期时长
-	duration := time.Duration(expireAt-now) * time.Second
-	// 启动定时器
-	return time.AfterFunc(duration, func() {
-		fileCleanerFunc(meta)
-	})
+	instance.scheduler.Schedule(expiryFile, meta.ID, meta.CreateAt+meta.TTL)
 }
 
 // fileCleanerFunc 文件清理函数
-func fileCleanerFunc(meta *FileMetadata) {
-	if err := DeleteFile(meta.ID); err != nil {
-		log.Errorf("清理文件失败: %s", err)
+func fileCleanerFunc(ident string) {
+	instance.storageMu.Lock()
+	defer instance.storageMu.Unlock()
+
+	// 文件可能已被重新保存并刷新了有效期
+	if current, err := instance.MetaDB.GetFileMeta(ident); err == nil {
+		expireAt := current.CreateAt + current.TTL
+		if current.TTL == 0 {
+			return
+		} else if expireAt > uint64(time.Now().Unix()) {
+			instance.scheduler.Schedule(expiryFile, ident, expireAt)
+			return
+		}
 	}
-}
 
-// fileInfoCleaner 定期清理过期文件信息
-func fileInfoCleaner(meta *FileInfo) *time.Timer {
-	if meta.TTL == 0 {
-		return nil // 没有设置过期时间
+	size, err := deleteFileLocked(ident)
+	if err != nil {
+		log.Errorf("清理文件失败: %s", err)
+		return
 	}
+	gcCounters.expiredFiles.Add(1)
+	gcCounters.expiredBytes.Add(uint64(size))
+}
 
-	// 计算过期时间戳
-	expireAt := meta.CreateAt + meta.TTL
-	now := uint64(time.Now().Unix())
-	if expireAt <= now {
-		// 文件信息已过期，删除文件信息
-		fileInfoCleanerFunc(meta)
+// fileInfoCleaner 将文件信息加入过期调度
+func fileInfoCleaner(info *FileInfo) {
+	if info.TTL == 0 {
+		return // 没有设置过期时间
 	}
-
-	// 计算过期时长
-	duration := time.Duration(expireAt-now) * time.Second
-	// 启动定时器
-	return time.AfterFunc(duration, func() {
-		fileInfoCleanerFunc(meta)
-	})
+	instance.scheduler.Schedule(expiryFileInfo, info.ID, info.CreateAt+info.TTL)
 }
 
 // fileInfoCleanerFunc 文件信息清理函数
-func fileInfoCleanerFunc(info *FileInfo) {
-	if err := DeleteFileInfo(info.ID); err != nil {
+func fileInfoCleanerFunc(ident string) {
+	// 文件信息可能已被重新保存并刷新了有效期
+	if current, err := instance.FileInfoDB.GetFileInfo(ident); err == nil {
+		expireAt := current.CreateAt + current.TTL
+		if current.TTL == 0 {
+			return
+		} else if expireAt > uint64(time.Now().Unix()) {
+			instance.scheduler.Schedule(expiryFileInfo, ident, expireAt)
+			return
+		}
+	} else {
+		return
+	}
+
+	if err := instance.FileInfoDB.DeleteFileInfo(ident); err != nil {
 		log.Errorf("清理文件信息失败: %s", err)
+		return
 	}
+	gcCounters.expiredFileInfos.Add(1)
 }
 
 // InternalURLPrefix 获取内部链接前缀
@@ -390,15 +514,28 @@ func InternalURLPrefix() string {
 	if instance == nil || !instance.Enable {
 		return ""
 	}
-	return fmt.Sprintf("http://%s%s/%s/proxy/", instance.URL, instance.path, instance.version)
+	return fmt.Sprintf("%s://%s%s/%s/proxy/", instance.Scheme, instance.URL, instance.path, instance.version)
 }
 
-// InternalURL 获取内部链接格式
+// InternalURL 获取返回给 Satori 应用的内部链接
+//
+// 应用可能会保存或转发该链接，因此启用签名时链接带有不会过期的签名
 func InternalURL(meta *FileMetadata) string {
 	if instance == nil || !instance.Enable {
 		return ""
 	}
-	return InternalURLPrefix() + meta.URL
+	return SignedInternalURL(meta, -1, false)
+}
+
+// OpenPlatformURL 获取交给 QQ 开放平台获取文件的内部链接
+//
+// 启用签名时链接带有默认有效期的签名，并按配置只能使用一次
+func OpenPlatformURL(meta *FileMetadata) string {
+	if !Public() {
+		return ""
+	}
+	singleUse := instance.signer != nil && instance.signer.singleUse
+	return SignedInternalURL(meta, 0, singleUse)
 }
 
 // ParseInternalURL 解析内部链接
@@ -414,6 +551,41 @@ func ParseInternalURL(url string) (string, string, string, bool) {
 	return matches[1], matches[2], matches[3], true
 }
 
+// Available 文件服务器是否可以在本地保存文件
+func Available() bool {
+	return instance != nil && instance.Enable
+}
+
+// Public 文件服务器是否可以对外提供文件，即是否可以将文件链接交给 QQ 开放平台
+func Public() bool {
+	return Available() && instance.Public
+}
+
+// ResolveInternalURL 将本文件服务器生成的链接解析为本地文件
+//
+// 支持完整的文件链接与 internal: 格式的内部链接，不是本文件服务器的链接时返回 false
+func ResolveInternalURL(src string) (*FileMetadata, bool) {
+	if instance == nil || !instance.Enable {
+		return nil, false
+	}
+
+	internalURL := strings.TrimPrefix(src, InternalURLPrefix())
+	// 去除签名等查询参数
+	if index := strings.IndexByte(internalURL, '?'); index >= 0 {
+		internalURL = internalURL[:index]
+	}
+
+	_, _, path, ok := ParseInternalURL(internalURL)
+	if !ok || !strings.HasPrefix(path, "_tmp/") {
+		return nil, false
+	}
+	meta, err := GetFile(strings.TrimPrefix(path, "_tmp/"))
+	if err != nil {
+		return nil, false
+	}
+	return meta, true
+}
+
 // GetPath 获取文件本地路径
 func GetPath(path string) (string, error) {
 	if instance == nil || !instance.Enable {
@@ -0,0 +1,90 @@
+package fileserver
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/WindowsSov8forUs/glyccat/config"
+)
+
+// startTestFileServer 在临时目录中启动文件服务器
+func startTestFileServer(t *testing.T, externalURL string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("获取工作目录失败: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("切换工作目录失败: %v", err)
+	}
+
+	conf := config.DefaultConfig()
+	conf.Satori.Version = 1
+	conf.Satori.Server.Port = 8080
+	conf.FileServer.Enable = true
+	conf.FileServer.ExternalURL = externalURL
+	conf.FileServer.TTL = 3600
+	StartFileServer(conf)
+	if instance == nil {
+		t.Fatal("StartFileServer() 未能启动文件服务器")
+	}
+
+	t.Cleanup(func() {
+		if err := Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+		instance = nil
+		os.Chdir(wd)
+	})
+}
+
+func TestLocalFilesWithoutExternalURL(t *testing.T) {
+	tests := []struct {
+		externalURL string
+		wantPublic  bool
+		wantPrefix  string
+	}{
+		{"", false, "http://127.0.0.1:8080/v1/proxy/internal:"},
+		{"files.example.com", true, "http://files.example.com/v1/proxy/internal:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.externalURL, func(t *testing.T) {
+			startTestFileServer(t, tt.externalURL)
+
+			if !Available() {
+				t.Fatal("Available() = false, want true")
+			}
+			if got := Public(); got != tt.wantPublic {
+				t.Errorf("Public() = %v, want %v", got, tt.wantPublic)
+			}
+
+			meta, err := SaveFile(strings.NewReader("hello"), "qq", "user", "hello.txt", "file")
+			if err != nil {
+				t.Fatalf("SaveFile() error = %v", err)
+			}
+
+			internalURL := InternalURL(meta)
+			if !strings.HasPrefix(internalURL, tt.wantPrefix) {
+				t.Errorf("InternalURL() = %q, want prefix %q", internalURL, tt.wantPrefix)
+			}
+			if got := OpenPlatformURL(meta); (got != "") != tt.wantPublic {
+				t.Errorf("OpenPlatformURL() = %q, want link only when public", got)
+			}
+
+			// 应用传回的链接直接解析为本地文件
+			for _, src := range []string{internalURL, meta.URL} {
+				resolved, ok := ResolveInternalURL(src)
+				if !ok || resolved.ID != meta.ID {
+					t.Errorf("ResolveInternalURL(%q) = %+v, %v, want %s", src, resolved, ok, meta.ID)
+				}
+			}
+			for _, src := range []string{"https://example.com/a.png", "internal:qq/user/other", "internal:qq/user/_tmp/missing"} {
+				if _, ok := ResolveInternalURL(src); ok {
+					t.Errorf("ResolveInternalURL(%q) = true, want false", src)
+				}
+			}
+		})
+	}
+}
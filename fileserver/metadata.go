@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"sync"
-	"time"
 
 	"github.com/syndtr/goleveldb/leveldb"
 )
@@ -13,14 +12,21 @@ const metaDatabasePath = "data/files/.metadata"
 
 // FileMetadata 文件元数据
 type FileMetadata struct {
-	ID           string      `json:"id"`           // 文件唯一标识 ID
-	Name         string      `json:"name"`         // 文件名
-	URL          string      `json:"url"`          // 文件内部链接
-	Path         string      `json:"path"`         // 文件存储相对路径
-	ContentType  string      `json:"content_type"` // 文件内容类型
-	CreateAt     uint64      `json:"create_at"`    // 文件创建时间戳
-	TTL          uint64      `json:"ttl"`          // 文件有效时间
-	cleanerTimer *time.Timer `json:"-"`            // 清理定时器，用于定时删除过期文件
+	ID           string `json:"id"`           // 文件唯一标识 ID
+	Name         string `json:"name"`         // 文件名
+	URL          string `json:"url"`          // 文件内部链接
+	Path         string `json:"path"`         // 文件存储相对路径
+	ContentType  string `json:"content_type"` // 文件内容类型
+	CreateAt     uint64 `json:"create_at"`    // 文件创建时间戳
+	TTL          uint64 `json:"ttl"`          // 文件有效时间
+	Size         int64  `json:"size"`         // 文件大小
+	LastAccess   uint64 `json:"last_access"`  // 文件最后访问时间戳
+	deduplicated bool   `json:"-"`            // 本次保存是否复用了已存在的文件
+}
+
+// Deduplicated 本次保存是否复用了已存在的文件
+func (m *FileMetadata) Deduplicated() bool {
+	return m.deduplicated
 }
 
 // MarshalBinary 序列化文件元数据为二进制
@@ -125,3 +131,10 @@ func (db *MetaDatabase) DeleteFileMeta(ident string) error {
 
 	return db.DB.Delete([]byte(ident), nil)
 }
+
+// Close 关闭文件元数据数据库，会等待正在进行的读写完成
+func (db *MetaDatabase) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.DB.Close()
+}
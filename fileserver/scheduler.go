@@ -0,0 +1,274 @@
+package fileserver
+
+import (
+	"container/heap"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+)
+
+// expiryKind 过期条目类型
+type expiryKind uint8
+
+const (
+	expiryFile     expiryKind = iota // 文件
+	expiryFileInfo                   // 文件信息
+)
+
+// expiryEntry 过期条目
+type expiryEntry struct {
+	kind     expiryKind // 条目类型
+	ident    string     // 条目标识
+	expireAt uint64     // 过期时间戳
+	index    int        // 在堆中的位置
+}
+
+// expiryKey 过期条目键
+type expiryKey struct {
+	kind  expiryKind
+	ident string
+}
+
+// expiryHeap 以过期时间排序的最小堆
+type expiryHeap []*expiryEntry
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].expireAt < h[j].expireAt }
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x any) {
+	entry := x.(*expiryEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *expiryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*h = old[:n-1]
+	return entry
+}
+
+// ExpiryScheduler 文件与文件信息共用的过期调度器
+//
+// 所有条目按过期时间存放在同一个最小堆中，由单个协程在最近的过期时间唤醒并清理
+type ExpiryScheduler struct {
+	heap    expiryHeap
+	entries map[expiryKey]*expiryEntry
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	mu      sync.Mutex
+}
+
+// GCStats 过期清理统计
+type GCStats struct {
+	ExpiredFiles     uint64 `json:"expired_files"`      // 因过期删除的文件数
+	ExpiredBytes     uint64 `json:"expired_bytes"`      // 因过期删除的文件大小
+	EvictedFiles     uint64 `json:"evicted_files"`      // 因存储空间不足删除的文件数
+	EvictedBytes     uint64 `json:"evicted_bytes"`      // 因存储空间不足删除的文件大小
+	ExpiredFileInfos uint64 `json:"expired_file_infos"` // 因过期删除的文件信息数
+	Scheduled        int    `json:"scheduled"`          // 等待过期的条目数
+}
+
+// gcCounters 过期清理计数
+var gcCounters struct {
+	expiredFiles     atomic.Uint64
+	expiredBytes     atomic.Uint64
+	evictedFiles     atomic.Uint64
+	evictedBytes     atomic.Uint64
+	expiredFileInfos atomic.Uint64
+}
+
+// newExpiryScheduler 创建并启动过期调度器
+func newExpiryScheduler() *ExpiryScheduler {
+	scheduler := &ExpiryScheduler{
+		entries: make(map[expiryKey]*expiryEntry),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go scheduler.run()
+	return scheduler
+}
+
+// Schedule 添加或更新条目的过期时间
+func (s *ExpiryScheduler) Schedule(kind expiryKind, ident string, expireAt uint64) {
+	s.mu.Lock()
+	key := expiryKey{kind, ident}
+	if entry, ok := s.entries[key]; ok {
+		entry.expireAt = expireAt
+		heap.Fix(&s.heap, entry.index)
+	} else {
+		entry := &expiryEntry{kind: kind, ident: ident, expireAt: expireAt}
+		heap.Push(&s.heap, entry)
+		s.entries[key] = entry
+	}
+	isFirst := s.heap[0].ident == ident && s.heap[0].kind == kind
+	s.mu.Unlock()
+
+	// 最近的过期时间发生变化时唤醒调度协程
+	if isFirst {
+		s.notify()
+	}
+}
+
+// Unschedule 移除条目
+func (s *ExpiryScheduler) Unschedule(kind expiryKind, ident string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := expiryKey{kind, ident}
+	if entry, ok := s.entries[key]; ok {
+		heap.Remove(&s.heap, entry.index)
+		delete(s.entries, key)
+	}
+}
+
+// Len 等待过期的条目数
+func (s *ExpiryScheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.heap)
+}
+
+// Stop 停止调度器，并等待正在进行的清理完成
+func (s *ExpiryScheduler) Stop() {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	<-s.done
+}
+
+// notify 唤醒调度协程
+func (s *ExpiryScheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run 调度协程
+func (s *ExpiryScheduler) run() {
+	defer close(s.done)
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		// 取出所有已过期的条目
+		now := uint64(time.Now().Unix())
+		var expired []*expiryEntry
+		s.mu.Lock()
+		for len(s.heap) > 0 && s.heap[0].expireAt <= now {
+			entry := heap.Pop(&s.heap).(*expiryEntry)
+			delete(s.entries, expiryKey{entry.kind, entry.ident})
+			expired = append(expired, entry)
+		}
+		wait := time.Hour
+		if len(s.heap) > 0 {
+			wait = time.Duration(s.heap[0].expireAt-now) * time.Second
+		}
+		s.mu.Unlock()
+
+		for _, entry := range expired {
+			s.expire(entry)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// expire 清理过期条目
+func (s *ExpiryScheduler) expire(entry *expiryEntry) {
+	switch entry.kind {
+	case expiryFile:
+		fileCleanerFunc(entry.ident)
+	case expiryFileInfo:
+		fileInfoCleanerFunc(entry.ident)
+	}
+}
+
+// RunGC 立即扫描并清理所有已过期的文件与文件信息
+func RunGC() (*GCStats, error) {
+	if instance == nil {
+		return &GCStats{}, nil
+	}
+	before := Stats()
+
+	now := uint64(time.Now().Unix())
+	if instance.Enable {
+		metas, err := instance.MetaDB.GetFileMetas()
+		if err != nil {
+			return nil, err
+		}
+		for ident, meta := range metas {
+			if meta.TTL != 0 && meta.CreateAt+meta.TTL <= now {
+				fileCleanerFunc(ident)
+			}
+		}
+	}
+
+	infos, err := instance.FileInfoDB.GetFileInfos()
+	if err != nil {
+		return nil, err
+	}
+	for ident, info := range infos {
+		if info.TTL != 0 && info.CreateAt+info.TTL <= now {
+			fileInfoCleanerFunc(ident)
+		}
+	}
+
+	after := Stats()
+	log.Infof(
+		"文件清理完成，删除了 %d 个文件（%d 字节）与 %d 条文件信息",
+		after.ExpiredFiles-before.ExpiredFiles,
+		after.ExpiredBytes-before.ExpiredBytes,
+		after.ExpiredFileInfos-before.ExpiredFileInfos,
+	)
+
+	return &GCStats{
+		ExpiredFiles:     after.ExpiredFiles - before.ExpiredFiles,
+		ExpiredBytes:     after.ExpiredBytes - before.ExpiredBytes,
+		ExpiredFileInfos: after.ExpiredFileInfos - before.ExpiredFileInfos,
+		Scheduled:        after.Scheduled,
+	}, nil
+}
+
+// Stats 获取过期清理的累计统计
+func Stats() *GCStats {
+	stats := &GCStats{
+		ExpiredFiles:     gcCounters.expiredFiles.Load(),
+		ExpiredBytes:     gcCounters.expiredBytes.Load(),
+		EvictedFiles:     gcCounters.evictedFiles.Load(),
+		EvictedBytes:     gcCounters.evictedBytes.Load(),
+		ExpiredFileInfos: gcCounters.expiredFileInfos.Load(),
+	}
+	if instance != nil && instance.scheduler != nil {
+		stats.Scheduled = instance.scheduler.Len()
+	}
+	return stats
+}
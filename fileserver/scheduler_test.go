@@ -0,0 +1,86 @@
+package fileserver
+
+import (
+	"testing"
+	"time"
+)
+
+// newIdleScheduler 创建不启动调度协程的过期调度器
+func newIdleScheduler() *ExpiryScheduler {
+	return &ExpiryScheduler{
+		entries: make(map[expiryKey]*expiryEntry),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// drainWake 读取并清空唤醒信号
+func drainWake(s *ExpiryScheduler) bool {
+	select {
+	case <-s.wake:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestExpirySchedulerOrder(t *testing.T) {
+	s := newIdleScheduler()
+
+	tests := []struct {
+		name      string
+		op        func()
+		wantFirst expiryKey
+		wantLen   int
+		wantWake  bool
+	}{
+		{"first entry", func() { s.Schedule(expiryFile, "a", 300) }, expiryKey{expiryFile, "a"}, 1, true},
+		{"later entry", func() { s.Schedule(expiryFile, "b", 400) }, expiryKey{expiryFile, "a"}, 2, false},
+		{"same ident other kind", func() { s.Schedule(expiryFileInfo, "a", 200) }, expiryKey{expiryFileInfo, "a"}, 3, true},
+		{"reschedule later", func() { s.Schedule(expiryFileInfo, "a", 500) }, expiryKey{expiryFile, "a"}, 3, false},
+		{"reschedule earlier", func() { s.Schedule(expiryFile, "b", 100) }, expiryKey{expiryFile, "b"}, 3, true},
+		{"unschedule first", func() { s.Unschedule(expiryFile, "b") }, expiryKey{expiryFile, "a"}, 2, false},
+		{"unschedule missing", func() { s.Unschedule(expiryFile, "missing") }, expiryKey{expiryFile, "a"}, 2, false},
+	}
+
+	for _, tt := range tests {
+		tt.op()
+		if got := s.Len(); got != tt.wantLen {
+			t.Errorf("%s: Len() = %d, want %d", tt.name, got, tt.wantLen)
+		}
+		first := s.heap[0]
+		if got := (expiryKey{first.kind, first.ident}); got != tt.wantFirst {
+			t.Errorf("%s: first = %+v, want %+v", tt.name, got, tt.wantFirst)
+		}
+		if got := drainWake(s); got != tt.wantWake {
+			t.Errorf("%s: wake = %v, want %v", tt.name, got, tt.wantWake)
+		}
+		for i, entry := range s.heap {
+			if entry.index != i {
+				t.Errorf("%s: entry %s index = %d, want %d", tt.name, entry.ident, entry.index, i)
+			}
+		}
+	}
+}
+
+func TestExpirySchedulerStop(t *testing.T) {
+	s := newExpiryScheduler()
+	s.Schedule(expiryFile, "later", uint64(time.Now().Add(time.Hour).Unix()))
+
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		s.Stop() // 重复调用不会阻塞
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop() did not return")
+	}
+	if got := s.Len(); got != 1 {
+		t.Errorf("Len() = %d after stop, want 1", got)
+	}
+}
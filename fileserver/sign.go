@@ -0,0 +1,217 @@
+package fileserver
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/config"
+	"github.com/WindowsSov8forUs/glyccat/log"
+)
+
+const (
+	signKeyFile        = ".signkey"      // 自动生成的签名密钥文件
+	nonceSweepEvery    = 1 * time.Minute // 已使用的一次性链接的清理间隔
+	signExpiresParam   = "expires"       // 链接过期时间参数
+	signNonceParam     = "nonce"         // 一次性链接标识参数
+	signSignatureParam = "sig"           // 链接签名参数
+)
+
+var (
+	// ErrURLExpired 链接已过期
+	ErrURLExpired = errors.New("url has expired")
+	// ErrURLSignature 链接签名无效
+	ErrURLSignature = errors.New("invalid url signature")
+	// ErrURLUsed 一次性链接已被使用
+	ErrURLUsed = errors.New("url has already been used")
+)
+
+// URLSigner 文件链接签名器
+type URLSigner struct {
+	key       []byte        // 签名密钥
+	ttl       time.Duration // 链接有效期
+	singleUse bool          // 是否只能使用一次
+
+	used      map[string]int64 // 已使用的一次性链接标识与其过期时间
+	lastSweep time.Time        // 上次清理已使用标识的时间
+	mu        sync.Mutex
+}
+
+// newURLSigner 创建文件链接签名器，未启用签名时返回 nil
+func newURLSigner(conf config.FileServer) (*URLSigner, error) {
+	if !conf.SignURL {
+		return nil, nil
+	}
+
+	key, err := loadSignKey(conf.SignKey)
+	if err != nil {
+		return nil, err
+	}
+	ttl := time.Duration(conf.URLTTL) * time.Second
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	return &URLSigner{
+		key:       key,
+		ttl:       ttl,
+		singleUse: conf.SingleUse,
+		used:      make(map[string]int64),
+	}, nil
+}
+
+// loadSignKey 加载签名密钥，未配置时读取或生成保存在文件目录中的密钥
+func loadSignKey(configured string) ([]byte, error) {
+	if configured != "" {
+		return []byte(configured), nil
+	}
+
+	path := filepath.Join(filePath, signKeyFile)
+	if data, err := os.ReadFile(path); err == nil {
+		if key, err := hex.DecodeString(strings.TrimSpace(string(data))); err == nil && len(key) > 0 {
+			return key, nil
+		}
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)), 0600); err != nil {
+		return nil, err
+	}
+	log.Info("已生成文件链接签名密钥。")
+	return key, nil
+}
+
+// sign 计算签名
+func (s *URLSigner) sign(path, expires, nonce string) string {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(path))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(expires))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(nonce))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Sign 为内部链接生成签名查询参数
+//
+// ttl 为 0 时使用默认有效期，小于 0 时生成不会过期的签名
+func (s *URLSigner) Sign(path string, ttl time.Duration, singleUse bool) (string, error) {
+	if ttl == 0 {
+		ttl = s.ttl
+	}
+	expires := "0"
+	if ttl > 0 {
+		expires = strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	}
+
+	query := url.Values{}
+	query.Set(signExpiresParam, expires)
+
+	var nonce string
+	if singleUse {
+		buf := make([]byte, 12)
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		nonce = base64.RawURLEncoding.EncodeToString(buf)
+		query.Set(signNonceParam, nonce)
+	}
+	query.Set(signSignatureParam, s.sign(path, expires, nonce))
+
+	return query.Encode(), nil
+}
+
+// Verify 校验内部链接的签名
+func (s *URLSigner) Verify(path string, query url.Values) error {
+	expires := query.Get(signExpiresParam)
+	nonce := query.Get(signNonceParam)
+	signature := query.Get(signSignatureParam)
+	if expires == "" || signature == "" {
+		return ErrURLSignature
+	}
+
+	expected := s.sign(path, expires, nonce)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrURLSignature
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrURLSignature
+	}
+	now := time.Now().Unix()
+	if expiresAt != 0 && expiresAt < now {
+		return ErrURLExpired
+	}
+
+	if nonce == "" {
+		return nil
+	}
+
+	// 一次性链接只能使用一次
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.lastSweep) > nonceSweepEvery {
+		for used, usedExpiresAt := range s.used {
+			if usedExpiresAt < now {
+				delete(s.used, used)
+			}
+		}
+		s.lastSweep = time.Now()
+	}
+	if _, ok := s.used[nonce]; ok {
+		return ErrURLUsed
+	}
+	s.used[nonce] = expiresAt
+	return nil
+}
+
+// SignedInternalURL 获取指定有效期的内部链接，ttl 为 0 时使用默认有效期，小于 0 时不会过期
+func SignedInternalURL(meta *FileMetadata, ttl time.Duration, singleUse bool) string {
+	if instance == nil || !instance.Enable {
+		return ""
+	}
+
+	internalURL := InternalURLPrefix() + meta.URL
+	if instance.signer == nil {
+		return internalURL
+	}
+
+	query, err := instance.signer.Sign(meta.URL, ttl, singleUse)
+	if err != nil {
+		log.Errorf("生成文件链接签名失败: %s", err)
+		return internalURL
+	}
+	return internalURL + "?" + query
+}
+
+// VerifyInternalURL 校验内部链接，未启用签名时总是通过
+func VerifyInternalURL(internalURL string, query url.Values) error {
+	if instance == nil || instance.signer == nil {
+		return nil
+	}
+	if err := instance.signer.Verify(internalURL, query); err != nil {
+		return fmt.Errorf("%s: %w", internalURL, err)
+	}
+	return nil
+}
+
+// SigningEnabled 是否启用了文件链接签名
+func SigningEnabled() bool {
+	return instance != nil && instance.signer != nil
+}
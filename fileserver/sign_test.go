@@ -0,0 +1,75 @@
+package fileserver
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newTestSigner 创建测试用的签名器
+func newTestSigner() *URLSigner {
+	return &URLSigner{
+		key:  []byte("test-key"),
+		ttl:  time.Hour,
+		used: make(map[string]int64),
+	}
+}
+
+// signForTest 生成签名并解析为查询参数
+func signForTest(t *testing.T, signer *URLSigner, path string, ttl time.Duration, singleUse bool) url.Values {
+	t.Helper()
+
+	query, err := signer.Sign(path, ttl, singleUse)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatalf("url.ParseQuery(%q) error = %v", query, err)
+	}
+	return values
+}
+
+func TestURLSignerPermanent(t *testing.T) {
+	signer := newTestSigner()
+	path := "internal:qq/1/_tmp/abc"
+
+	query := signForTest(t, signer, path, -1, false)
+	if got := query.Get(signExpiresParam); got != "0" {
+		t.Fatalf("expires = %q, want 0", got)
+	}
+	for i := 0; i < 2; i++ {
+		if err := signer.Verify(path, query); err != nil {
+			t.Fatalf("Verify() #%d error = %v", i, err)
+		}
+	}
+	if err := signer.Verify("internal:qq/1/_tmp/other", query); !errors.Is(err, ErrURLSignature) {
+		t.Errorf("Verify() with other path error = %v, want %v", err, ErrURLSignature)
+	}
+}
+
+func TestURLSignerExpired(t *testing.T) {
+	signer := newTestSigner()
+	path := "internal:qq/1/_tmp/abc"
+
+	query := signForTest(t, signer, path, -time.Hour, false)
+	query.Set(signExpiresParam, "1")
+	query.Set(signSignatureParam, signer.sign(path, "1", ""))
+	if err := signer.Verify(path, query); !errors.Is(err, ErrURLExpired) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrURLExpired)
+	}
+}
+
+func TestURLSignerSingleUse(t *testing.T) {
+	signer := newTestSigner()
+	path := "internal:qq/1/_tmp/abc"
+
+	query := signForTest(t, signer, path, 0, true)
+	if err := signer.Verify(path, query); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if err := signer.Verify(path, query); !errors.Is(err, ErrURLUsed) {
+		t.Errorf("Verify() second use error = %v, want %v", err, ErrURLUsed)
+	}
+}
@@ -0,0 +1,237 @@
+package fileserver
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+)
+
+const (
+	uploadTempPrefix    = ".upload-" // 上传中的临时文件前缀
+	accessTouchInterval = 60         // 最后访问时间的最小更新间隔，单位秒
+)
+
+var (
+	// ErrFileTooLarge 文件超过单个文件大小上限
+	ErrFileTooLarge = errors.New("file exceeds the size limit")
+	// ErrQuotaExceeded 文件超过文件总大小上限
+	ErrQuotaExceeded = errors.New("file server storage quota exceeded")
+	// ErrNotStarted 文件服务器未启动
+	ErrNotStarted = errors.New("file server not started")
+)
+
+// loadStorageUsage 统计已存储文件的总大小，并清理残留的临时文件与失效的元数据
+func loadStorageUsage() {
+	// 清理上次运行残留的临时文件
+	if entries, err := os.ReadDir(filePath); err == nil {
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), uploadTempPrefix) {
+				os.Remove(filepath.Join(filePath, entry.Name()))
+			}
+		}
+	}
+
+	metas, err := instance.MetaDB.GetFileMetas()
+	if err != nil {
+		log.Errorf("统计文件存储用量失败: %s", err)
+		return
+	}
+
+	var used int64
+	for ident, meta := range metas {
+		stat, err := os.Stat(filepath.Join(filePath, ident))
+		if err != nil {
+			// 文件已不存在，删除对应元数据
+			instance.MetaDB.DeleteFileMeta(ident)
+			continue
+		}
+		if meta.Size != stat.Size() {
+			// 旧版本的元数据没有记录文件大小
+			meta.Size = stat.Size()
+			instance.MetaDB.SaveFileMeta(ident, meta)
+		}
+		used += meta.Size
+	}
+
+	instance.usedBytes = used
+	log.Debugf("文件服务器已使用 %d 字节", used)
+}
+
+// writeTempFile 将文件流式写入临时文件并同时计算文件标识
+func writeTempFile(file io.Reader, platform, userId string) (string, int64, string, error) {
+	temp, err := os.CreateTemp(filePath, uploadTempPrefix+"*")
+	if err != nil {
+		return "", 0, "", err
+	}
+	tempPath := temp.Name()
+
+	h := newFileIdentHash(platform, userId)
+	reader := file
+	if instance.MaxFileSize > 0 {
+		// 多读取一个字节用于判断是否超过上限
+		reader = io.LimitReader(file, instance.MaxFileSize+1)
+	}
+
+	size, err := io.Copy(io.MultiWriter(temp, h), reader)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return "", 0, "", err
+	}
+	if instance.MaxFileSize > 0 && size > instance.MaxFileSize {
+		os.Remove(tempPath)
+		return "", 0, "", fmt.Errorf("%w: limit is %d bytes", ErrFileTooLarge, instance.MaxFileSize)
+	}
+
+	return tempPath, size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// reserveStorage 为新文件预留存储空间，空间不足时按最久未使用的顺序删除文件
+//
+// 调用前需要持有 storageMu
+func reserveStorage(size int64) error {
+	if instance.MaxTotalSize <= 0 {
+		return nil
+	}
+	if size > instance.MaxTotalSize {
+		return fmt.Errorf("%w: file size %d is larger than the quota %d", ErrQuotaExceeded, size, instance.MaxTotalSize)
+	}
+	if instance.usedBytes+size <= instance.MaxTotalSize {
+		return nil
+	}
+
+	metas, err := instance.MetaDB.GetFileMetas()
+	if err != nil {
+		return err
+	}
+	candidates := make([]*FileMetadata, 0, len(metas))
+	for _, meta := range metas {
+		candidates = append(candidates, meta)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return lastUsed(candidates[i]) < lastUsed(candidates[j])
+	})
+
+	for _, meta := range candidates {
+		if instance.usedBytes+size <= instance.MaxTotalSize {
+			break
+		}
+		log.Debugf("文件存储空间不足，删除最久未使用的文件 %s", meta.ID)
+		freed, err := deleteFileLocked(meta.ID)
+		if err != nil {
+			log.Warnf("删除文件 %s 失败: %s", meta.ID, err)
+			continue
+		}
+		gcCounters.evictedFiles.Add(1)
+		gcCounters.evictedBytes.Add(uint64(freed))
+	}
+
+	if instance.usedBytes+size > instance.MaxTotalSize {
+		return fmt.Errorf("%w: %d bytes used, %d bytes required", ErrQuotaExceeded, instance.usedBytes, size)
+	}
+	return nil
+}
+
+// lastUsed 获取文件最后使用时间
+func lastUsed(meta *FileMetadata) uint64 {
+	if meta.LastAccess > meta.CreateAt {
+		return meta.LastAccess
+	}
+	return meta.CreateAt
+}
+
+// deleteFileLocked 删除文件并释放存储空间，返回释放的大小
+//
+// 调用前需要持有 storageMu
+func deleteFileLocked(ident string) (int64, error) {
+	path := filepath.Join(filePath, ident)
+
+	var size int64
+	if meta, err := instance.MetaDB.GetFileMeta(ident); err == nil {
+		size = meta.Size
+	} else if stat, err := os.Stat(path); err == nil {
+		size = stat.Size()
+	}
+
+	// 删除文件元数据
+	if err := instance.MetaDB.DeleteFileMeta(ident); err != nil {
+		log.Errorf("删除文件元数据失败: %s", err)
+	}
+
+	// 取消过期调度
+	instance.scheduler.Unschedule(expiryFile, ident)
+
+	// 删除文件
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	instance.usedBytes -= size
+	if instance.usedBytes < 0 {
+		instance.usedBytes = 0
+	}
+	return size, nil
+}
+
+// touchFile 更新文件最后访问时间
+func touchFile(meta *FileMetadata) {
+	now := uint64(time.Now().Unix())
+	if now-meta.LastAccess < accessTouchInterval {
+		return
+	}
+	meta.LastAccess = now
+
+	instance.storageMu.Lock()
+	defer instance.storageMu.Unlock()
+
+	// 文件可能已被淘汰或清理，此时不能写回元数据
+	current, err := instance.MetaDB.GetFileMeta(meta.ID)
+	if err != nil {
+		return
+	}
+	current.LastAccess = now
+	if err := instance.MetaDB.SaveFileMeta(current.ID, current); err != nil {
+		log.Debugf("更新文件访问时间失败: %s", err)
+	}
+}
+
+// StorageUsage 获取文件服务器已使用的存储空间与上限，上限为 0 时表示无上限
+func StorageUsage() (int64, int64) {
+	if instance == nil || !instance.Enable {
+		return 0, 0
+	}
+
+	instance.storageMu.Lock()
+	defer instance.storageMu.Unlock()
+	return instance.usedBytes, instance.MaxTotalSize
+}
+
+// Ping 检查文件服务器的数据库与存储目录是否可用
+func Ping() error {
+	if instance == nil {
+		return ErrNotStarted
+	}
+	for _, db := range []*leveldb.DB{instance.MetaDB.DB, instance.FileInfoDB.DB} {
+		if _, err := db.GetProperty("leveldb.num-files-at-level0"); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return err
+	}
+	return nil
+}
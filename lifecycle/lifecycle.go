@@ -0,0 +1,140 @@
+// Package lifecycle 按顺序关闭 GlycCat 的各个子系统
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+)
+
+// expiredStageGrace ctx 到期后开始的阶段仍会等待的时间，保证数据库等资源有机会关闭
+const expiredStageGrace = 5 * time.Second
+
+// StopFunc 子系统的关闭函数，应在 ctx 到期前返回
+type StopFunc func(ctx context.Context) error
+
+// stage 关闭阶段
+type stage struct {
+	name   string
+	stop   StopFunc
+	strict bool // 是否需要等待之前的所有阶段返回
+}
+
+// abandoned 超时后不再等待但仍在运行的阶段
+type abandoned struct {
+	name string
+	done <-chan error
+}
+
+// Manager 生命周期管理器
+type Manager struct {
+	mu     sync.Mutex
+	stages []stage
+	grace  time.Duration // ctx 到期后开始的阶段仍会等待的时间
+}
+
+// New 创建生命周期管理器
+func New() *Manager {
+	return &Manager{grace: expiredStageGrace}
+}
+
+// Register 注册子系统的关闭函数，关闭时按照注册顺序执行
+func (m *Manager) Register(name string, stop StopFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stages = append(m.stages, stage{name: name, stop: stop})
+}
+
+// RegisterStrict 注册必须在之前所有阶段返回后才能执行的关闭函数
+//
+// 用于关闭数据库等仍可能被之前的阶段使用的资源。之前的阶段超时未返回时会继续等待，
+// 等待的时间与普通阶段相同，仍未返回时跳过该阶段
+func (m *Manager) RegisterStrict(name string, stop StopFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stages = append(m.stages, stage{name: name, stop: stop, strict: true})
+}
+
+// Shutdown 按照注册顺序关闭所有子系统
+//
+// 某个阶段出错或超时后仍会继续关闭之后的子系统，以保证数据库等资源被关闭。
+// ctx 到期后仍未返回的阶段不再等待，ctx 到期后才开始的阶段最多等待 5 秒。
+// 使用 RegisterStrict 注册的阶段会先等待之前超时的阶段返回。
+// 返回所有阶段的错误，全部成功关闭时返回 nil
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	stages := make([]stage, len(m.stages))
+	copy(stages, m.stages)
+	m.mu.Unlock()
+
+	var errs []error
+	var running []abandoned
+	for _, stage := range stages {
+		if stage.strict && len(running) > 0 {
+			if err := m.waitAbandoned(ctx, running); err != nil {
+				log.Errorf("跳过关闭 %s: %v", stage.name, err)
+				errs = append(errs, fmt.Errorf("%s: %w", stage.name, err))
+				continue
+			}
+			running = nil
+		}
+
+		log.Infof("正在关闭 %s ...", stage.name)
+		start := time.Now()
+		done, err := m.runStage(ctx, stage)
+		if done != nil {
+			running = append(running, abandoned{name: stage.name, done: done})
+		}
+		if err != nil {
+			log.Errorf("关闭 %s 时出错: %v", stage.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", stage.name, err))
+			continue
+		}
+		log.Debugf("%s 已关闭，耗时 %v", stage.name, time.Since(start))
+	}
+	return errors.Join(errs...)
+}
+
+// wait 返回等待阶段结束的期限，ctx 已到期时给予 grace 的时间
+func (m *Manager) wait(ctx context.Context) (<-chan struct{}, func()) {
+	if ctx.Err() == nil {
+		return ctx.Done(), func() {}
+	}
+	waitCtx, cancel := context.WithTimeout(context.Background(), m.grace)
+	return waitCtx.Done(), cancel
+}
+
+// runStage 执行关闭阶段，ctx 到期时不再等待，并返回仍在运行的阶段的结果通道
+func (m *Manager) runStage(ctx context.Context, stage stage) (<-chan error, error) {
+	done := make(chan error, 1)
+	go func() {
+		done <- stage.stop(ctx)
+	}()
+
+	expired, cancel := m.wait(ctx)
+	defer cancel()
+	select {
+	case err := <-done:
+		return nil, err
+	case <-expired:
+		return done, fmt.Errorf("等待关闭时超时: %w", ctx.Err())
+	}
+}
+
+// waitAbandoned 等待之前超时的阶段返回
+func (m *Manager) waitAbandoned(ctx context.Context, running []abandoned) error {
+	expired, cancel := m.wait(ctx)
+	defer cancel()
+	for _, stage := range running {
+		select {
+		case <-stage.done:
+		case <-expired:
+			return fmt.Errorf("%s 仍未关闭: %w", stage.name, ctx.Err())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestShutdownRunsAllStagesInOrder(t *testing.T) {
+	errStage := errors.New("stage failed")
+
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		stages    []StopFunc
+		wantOrder []int
+		wantErr   error
+	}{
+		{
+			name:      "all succeed",
+			timeout:   time.Second,
+			stages:    []StopFunc{nil, nil, nil},
+			wantOrder: []int{0, 1, 2},
+		},
+		{
+			name:    "error does not stop later stages",
+			timeout: time.Second,
+			stages: []StopFunc{
+				nil,
+				func(context.Context) error { return errStage },
+				nil,
+			},
+			wantOrder: []int{0, 1, 2},
+			wantErr:   errStage,
+		},
+		{
+			name:    "timeout does not stop later stages",
+			timeout: 50 * time.Millisecond,
+			stages: []StopFunc{
+				func(ctx context.Context) error {
+					time.Sleep(200 * time.Millisecond)
+					return nil
+				},
+				nil,
+			},
+			wantOrder: []int{0, 1},
+			wantErr:   context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := New()
+			order := make(chan int, len(tt.stages))
+			for i, stop := range tt.stages {
+				i, stop := i, stop
+				manager.Register("stage", func(ctx context.Context) error {
+					order <- i
+					if stop != nil {
+						return stop(ctx)
+					}
+					return nil
+				})
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			err := manager.Shutdown(ctx)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("Shutdown() error = %v, want nil", err)
+			} else if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Shutdown() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// 超时的阶段仍在运行，不能关闭 order
+			var got []int
+			for len(order) > 0 {
+				got = append(got, <-order)
+			}
+			if !reflect.DeepEqual(got, tt.wantOrder) {
+				t.Errorf("stage order = %v, want %v", got, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestStrictStageWaitsForAbandonedStage(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	tests := []struct {
+		name       string
+		stageDelay time.Duration
+		wantStrict bool
+	}{
+		{"previous stage returns within grace", 100 * time.Millisecond, true},
+		{"previous stage never returns", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := New()
+			manager.grace = 500 * time.Millisecond
+
+			returned := make(chan struct{})
+			manager.Register("server", func(ctx context.Context) error {
+				if tt.stageDelay > 0 {
+					time.Sleep(tt.stageDelay)
+				} else {
+					<-release
+				}
+				close(returned)
+				return nil
+			})
+			manager.Register("other", func(context.Context) error { return nil })
+
+			var strictRan, strictAfterReturn bool
+			manager.RegisterStrict("database", func(context.Context) error {
+				strictRan = true
+				select {
+				case <-returned:
+					strictAfterReturn = true
+				default:
+				}
+				return nil
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			err := manager.Shutdown(ctx)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+			}
+
+			if strictRan != tt.wantStrict {
+				t.Fatalf("strict stage ran = %v, want %v", strictRan, tt.wantStrict)
+			}
+			if strictRan && !strictAfterReturn {
+				t.Error("strict stage ran before the abandoned stage returned")
+			}
+		})
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/natefinch/lumberjack.v2"
@@ -80,47 +83,118 @@ func (f *CustomFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 	level := levelColor("[%s]", levelName)
 
 	// 组合日志消息
-	return []byte(fmt.Sprintf("%s %s: %s\n", timestamp, level, entry.Message)), nil
+	return []byte(fmt.Sprintf("%s %s: %s%s\n", timestamp, level, entry.Message, formatFields(entry.Data))), nil
+}
+
+// formatFields 将日志字段格式化为 key=value 的形式
+func formatFields(fields logrus.Fields) string {
+	if len(fields) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	for _, key := range keys {
+		builder.WriteString(" " + HiBlack("%s=%v", key, fields[key]))
+	}
+	return builder.String()
+}
+
+// 日志格式
+const (
+	FormatText = "text" // 带颜色的文本格式
+	FormatJSON = "json" // 每行一个 JSON 对象
+)
+
+// Options 日志输出选项
+type Options struct {
+	Format     string // 日志格式，FormatText 或 FormatJSON
+	File       string // 日志文件路径，为空时不写入文件
+	MaxSize    int    // 单个日志文件大小上限，单位 MiB
+	MaxAge     int    // 日志文件保留天数
+	MaxBackups int    // 日志文件保留数量
+	Compress   bool   // 是否压缩轮转后的日志文件
+}
+
+// defaultOptions 加载配置之前使用的日志输出选项
+var defaultOptions = Options{
+	Format:     FormatText,
+	File:       "log/glyc-cat.log",
+	MaxSize:    256,
+	MaxAge:     7,
+	MaxBackups: 10,
 }
 
 // 初始化 Logger 对象
 func init() {
 	logger.Logger = logrus.New()
 
-	// 设置自定义日志格式
-	logger.SetFormatter(&CustomFormatter{
-		TimestampFormat: "2006-01-02 15:04:05",
-		ForceColors:     true,
-	})
-	// 获取 lumberjack.Logger 对象
-	logger.lumberjack = getLumberjackLogger()
-
-	// 配置多输出（控制台和文件）
-	if logger.lumberjack != nil {
-		logger.SetOutput(io.MultiWriter(os.Stdout, logger.lumberjack))
-	} else {
-		logger.SetOutput(os.Stdout)
+	if err := Configure(defaultOptions); err != nil {
+		// 无法创建日志文件时只输出到控制台
+		options := defaultOptions
+		options.File = ""
+		Configure(options)
 	}
 
 	logger.Level = INFO
 	logger.SetLevel(logrus.InfoLevel)
 }
 
-// getLumberjackLogger 获取一个可用的 lumberjack.Logger 对象
-func getLumberjackLogger() *lumberjack.Logger {
-	// 创建 log 文件夹
-	err := os.MkdirAll("log", os.ModePerm)
-	if err != nil {
-		return nil
+// Configure 设置日志格式与输出，日志文件会按大小轮转
+func Configure(options Options) error {
+	var formatter logrus.Formatter
+	switch options.Format {
+	case FormatText, "":
+		formatter = &CustomFormatter{
+			TimestampFormat: "2006-01-02 15:04:05",
+			ForceColors:     true,
+		}
+	case FormatJSON:
+		formatter = &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+		}
+	default:
+		return fmt.Errorf("unknown log format %q", options.Format)
+	}
+
+	var fileLogger *lumberjack.Logger
+	if options.File != "" {
+		// 创建日志文件夹
+		if err := os.MkdirAll(filepath.Dir(options.File), os.ModePerm); err != nil {
+			return err
+		}
+		fileLogger = &lumberjack.Logger{
+			Filename:   options.File,
+			MaxSize:    options.MaxSize,
+			MaxAge:     options.MaxAge,
+			MaxBackups: options.MaxBackups,
+			LocalTime:  true,
+			Compress:   options.Compress,
+		}
 	}
 
-	return &lumberjack.Logger{
-		Filename:   "log/glyc-cat.log",
-		MaxSize:    256,
-		MaxAge:     7,
-		MaxBackups: 10,
-		LocalTime:  true,
+	logger.Mutex.Lock()
+	defer logger.Mutex.Unlock()
+
+	logger.SetFormatter(&redactFormatter{formatter: formatter})
+
+	// 配置多输出（控制台和文件）
+	if fileLogger != nil {
+		logger.SetOutput(io.MultiWriter(os.Stdout, fileLogger))
+	} else {
+		logger.SetOutput(os.Stdout)
 	}
+	if logger.lumberjack != nil {
+		logger.lumberjack.Close()
+	}
+	logger.lumberjack = fileLogger
+
+	return nil
 }
 
 // convertLogLevel 转换自定义日志级别到 Logrus 级别
@@ -154,6 +228,32 @@ func GetLogger() *Logger {
 	return &logger
 }
 
+// 日志字段名
+const (
+	FieldPlatform  = "platform"    // 平台
+	FieldChannel   = "channel"     // 频道 ID
+	FieldEventType = "event_type"  // 事件类型
+	FieldRequestID = "request_id"  // 请求 ID
+	FieldAPI       = "api"         // Satori API
+	FieldQQTraceID = "qq_trace_id" // QQ 开放平台返回的 trace ID
+)
+
+// Fields 日志字段
+type Fields = logrus.Fields
+
+// Entry 带有字段的日志记录器
+type Entry = logrus.Entry
+
+// WithFields 创建带有字段的日志记录器，text 格式下字段会以 key=value 的形式附加在消息之后
+func WithFields(fields Fields) *Entry {
+	return logger.WithFields(fields)
+}
+
+// WithField 创建带有单个字段的日志记录器
+func WithField(key string, value interface{}) *Entry {
+	return logger.WithField(key, value)
+}
+
 // Println 打印日志
 func (l *Logger) Println(level LogLevel, v ...interface{}) {
 	// 判断日志等级
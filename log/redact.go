@@ -0,0 +1,116 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// redactedText 替换敏感信息的文本
+const redactedText = "******"
+
+// minSecretLength 短于该长度的敏感字符串不会被登记，避免误替换普通文本
+const minSecretLength = 6
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string // 已登记的敏感字符串，按长度降序排列
+
+	// authSchemePattern 匹配 Authorization 请求头中的凭据
+	authSchemePattern = regexp.MustCompile(`(?i)\b(Bearer|QQBot|Basic)(\s+)[A-Za-z0-9._~+/=-]+`)
+	// secretFieldPattern 匹配键值对形式的令牌与密钥
+	secretFieldPattern = regexp.MustCompile(`(?i)(\b(?:access_token|token|app_secret|appsecret|client_secret|clientsecret|secret|sign_key|password)"?\s*[:=]\s*"?)([^\s"',;&}\]]+)`)
+)
+
+// AddSecret 登记需要在日志中隐藏的敏感字符串，如令牌与密钥
+func AddSecret(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if len(value) < minSecretLength || containsString(secrets, value) {
+			continue
+		}
+		secrets = append(secrets, value)
+	}
+	// 先替换较长的字符串，避免部分替换
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+}
+
+// Redact 隐藏文本中的敏感信息
+//
+// 会替换已登记的敏感字符串、Authorization 凭据以及 token 、app_secret 等键对应的值
+func Redact(text string) string {
+	secretsMu.RLock()
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, redactedText)
+	}
+	secretsMu.RUnlock()
+
+	text = authSchemePattern.ReplaceAllString(text, "${1}${2}"+redactedText)
+	text = secretFieldPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := secretFieldPattern.FindStringSubmatch(match)
+		if groups[2] == redactedText {
+			return match
+		}
+		return groups[1] + redactedText
+	})
+	return text
+}
+
+// redactFormatter 在格式化前隐藏消息与字段中的敏感信息
+type redactFormatter struct {
+	formatter logrus.Formatter
+}
+
+// Format 实现 logrus.Formatter 接口
+func (f *redactFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	redacted := *entry
+	redacted.Message = Redact(entry.Message)
+	if len(entry.Data) > 0 {
+		redacted.Data = make(logrus.Fields, len(entry.Data))
+		for key, value := range entry.Data {
+			if isSecretKey(key) {
+				redacted.Data[key] = redactedText
+				continue
+			}
+			switch v := value.(type) {
+			case string:
+				redacted.Data[key] = Redact(v)
+			case error:
+				redacted.Data[key] = Redact(v.Error())
+			case fmt.Stringer:
+				redacted.Data[key] = Redact(v.String())
+			default:
+				redacted.Data[key] = value
+			}
+		}
+	}
+	return f.formatter.Format(&redacted)
+}
+
+// isSecretKey 判断字段名是否表示敏感信息
+func isSecretKey(key string) bool {
+	switch strings.ToLower(key) {
+	case "token", "access_token", "app_secret", "secret", "authorization", "password", "sign_key":
+		return true
+	}
+	return false
+}
+
+// containsString 判断列表中是否含有指定字符串
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
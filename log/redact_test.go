@@ -0,0 +1,96 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRedact(t *testing.T) {
+	AddSecret("registered-secret-value", "short")
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "bearer header",
+			text: "Authorization: Bearer abc.DEF-123_~+/=",
+			want: "Authorization: Bearer ******",
+		},
+		{
+			name: "qqbot header",
+			text: "Authorization: QQBot ABCDEFGHIJ",
+			want: "Authorization: QQBot ******",
+		},
+		{
+			name: "lowercase scheme",
+			text: "authorization: bearer abcdef",
+			want: "authorization: bearer ******",
+		},
+		{
+			name: "query token",
+			text: "GET /events?token=abc123&platform=qq",
+			want: "GET /events?token=******&platform=qq",
+		},
+		{
+			name: "json app_secret",
+			text: `{"app_id":"1024","app_secret":"s3cr3t-value"}`,
+			want: `{"app_id":"1024","app_secret":"******"}`,
+		},
+		{
+			name: "json access_token",
+			text: `{"access_token": "tok-123", "expires_in": "7200"}`,
+			want: `{"access_token": "******", "expires_in": "7200"}`,
+		},
+		{
+			name: "registered secret",
+			text: "using registered-secret-value for signing",
+			want: "using ****** for signing",
+		},
+		{
+			name: "short secret not registered",
+			text: "a short message",
+			want: "a short message",
+		},
+		{
+			name: "plain text",
+			text: "message sent to channel 123",
+			want: "message sent to channel 123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.text); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactFormatter(t *testing.T) {
+	formatter := &redactFormatter{formatter: &logrus.JSONFormatter{}}
+	entry := logrus.NewEntry(logrus.New()).WithFields(logrus.Fields{
+		"token":   "plain-token",
+		"request": "Authorization: Bearer abcdef",
+		"channel": "123",
+	})
+	entry.Message = "token=abcdef"
+
+	output, err := formatter.Format(entry)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	for _, secret := range []string{"plain-token", "abcdef"} {
+		if bytes.Contains(output, []byte(secret)) {
+			t.Errorf("Format() output %s contains %q", output, secret)
+		}
+	}
+	if !strings.Contains(string(output), `"channel":"123"`) {
+		t.Errorf("Format() output %s lost channel field", output)
+	}
+}
@@ -5,14 +5,18 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 
 	"github.com/WindowsSov8forUs/glyccat/config"
 	"github.com/WindowsSov8forUs/glyccat/database"
 	"github.com/WindowsSov8forUs/glyccat/fileserver"
 	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/WindowsSov8forUs/glyccat/metrics"
 	"github.com/WindowsSov8forUs/glyccat/processor"
+	"github.com/WindowsSov8forUs/glyccat/ratelimit"
 	"github.com/WindowsSov8forUs/glyccat/server"
+	"github.com/WindowsSov8forUs/glyccat/server/httpapi"
 	"github.com/WindowsSov8forUs/glyccat/sys"
 	"github.com/WindowsSov8forUs/glyccat/version"
 
@@ -27,9 +31,24 @@ func main() {
 	// 定义 debug 命令行标志，默认为 false
 	debug := flag.Bool("debug", false, "是否启用调试模式")
 
+	// 定义 config 命令行标志，默认为 GLYCCAT_CONFIG 环境变量或 config.yml
+	configPath := os.Getenv("GLYCCAT_CONFIG")
+	if configPath == "" {
+		configPath = "config.yml"
+	}
+	flag.StringVar(&configPath, "config", configPath, "配置文件路径")
+
+	// 定义 non-interactive 命令行标志，标准输入不是终端时自动启用
+	nonInteractive := flag.Bool("non-interactive", false, "是否以非交互模式加载配置")
+
 	// 解析命令行参数到定义的标志
 	flag.Parse()
 
+	// 执行子命令
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args(), configPath))
+	}
+
 	// 检查是否使用了 -faststart 参数
 	if !*fastStart {
 		sys.InitBase()
@@ -41,15 +60,21 @@ func main() {
 	fmt.Print("\n==========================================================\n\n")
 
 	// 加载配置
-	conf, err := config.LoadConfig("config.yml")
+	if *nonInteractive || isTruthy(os.Getenv("GLYCCAT_NON_INTERACTIVE")) || !sys.IsInteractive() {
+		config.SetNonInteractive(true)
+	}
+	conf, err := config.LoadConfig(configPath)
 	if err != nil {
 		fmt.Printf("%s 加载配置文件时出错: %v\n", log.FailMark, log.Red(fmt.Sprint(err)))
-		os.Exit(0)
+		os.Exit(1)
 		return
 	}
 
-	// 配置日志等级
+	// 配置日志等级与输出
 	log.SetLogLevel(conf.LogLevel)
+	if err := log.Configure(conf.Log.Options()); err != nil {
+		log.Warnf("配置日志输出时出错，将使用默认设置: %v", err)
+	}
 
 	// 设置 gin 运行模式
 	if *debug {
@@ -84,13 +109,32 @@ func main() {
 		log.Warn("消息数据库未启动，将无法使用消息缓存。")
 	}
 
+	// 启动成员数据库
+	if conf.Database.MemberDatabase.Enable {
+		log.Info("正在启动成员数据库...")
+		err := database.StartMemberDB()
+		if err != nil {
+			log.Errorf("启动成员数据库时出错，将无法获取单聊/群聊成员信息: %v", err)
+		}
+	} else {
+		log.Warn("成员数据库未启动，将无法获取单聊/群聊成员信息。")
+	}
+
+	// 限制 OpenAPI 请求频率，需要在其他过滤器之前注册
+	ratelimit.Configure(conf.RateLimit.Options())
+	ratelimit.RegisterBotgoFilters()
+
+	// 记录 QQ 开放平台的事件与 OpenAPI 请求指标，并将 trace ID 关联到请求 ID
+	metrics.RegisterBotgoFilters()
+	httpapi.RegisterTraceFilters()
+
 	// 初始化消息处理器
 	p, ctx, err := processor.NewProcessor(conf)
 	if err != nil {
 		log.Fatalf("建立与 QQ 开放平台连接时出错: %v", err)
 	}
 	// 创建 Satori 服务端
-	server, err := server.NewServer(p.Api, p.ApiV2, conf)
+	server, err := server.NewServer(p.Api, p.ApiV2, p.Token, conf)
 	if err != nil {
 		log.Fatalf("建立 Satori 服务端时出错: %v", err)
 	}
@@ -103,9 +147,22 @@ func main() {
 	// 使用通道来等待信号
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	notifyReload(sigCh)
 
-	// 等待信号
-	<-sigCh
+	// 等待信号，收到重新加载信号时重新加载配置
+	for sig := range sigCh {
+		if isReloadSignal(sig) {
+			server.ReloadConfig()
+			continue
+		}
+		break
+	}
+
+	os.Exit(shutdown(p, server, sigCh))
+}
 
-	server.Close()
+// isTruthy 判断环境变量的值是否表示启用
+func isTruthy(value string) bool {
+	enable, err := strconv.ParseBool(value)
+	return err == nil && enable
 }
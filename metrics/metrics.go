@@ -0,0 +1,148 @@
+// Package metrics 提供 GlycCat 的运行指标，以 Prometheus 文本格式输出
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/tencent-connect/botgo/dto"
+	"github.com/tencent-connect/botgo/event"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+// Default 默认指标注册表，只含有 GlycCat 自身的指标
+var Default = prometheus.NewRegistry()
+
+var factory = promauto.With(Default)
+
+var (
+	// QQEventsReceived 从 QQ 开放平台收到的事件数
+	QQEventsReceived = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "glyccat_qq_events_received_total",
+		Help: "Events received from the QQ open platform.",
+	}, []string{"event_type"})
+
+	// SatoriEventsDelivered 向 Satori 应用推送的事件数
+	SatoriEventsDelivered = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "glyccat_satori_events_delivered_total",
+		Help: "Events delivered to Satori subscribers.",
+	}, []string{"transport", "subscriber", "result"})
+
+	// HTTPRequests Satori HTTP API 的调用数
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "glyccat_http_requests_total",
+		Help: "Satori HTTP API calls.",
+	}, []string{"method", "status"})
+
+	// HTTPRequestDuration Satori HTTP API 的处理耗时
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "glyccat_http_request_duration_seconds",
+		Help:    "Satori HTTP API call latency in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	// OpenAPIRequestDuration QQ 开放平台 OpenAPI 的请求耗时
+	OpenAPIRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "glyccat_openapi_request_duration_seconds",
+		Help:    "QQ OpenAPI request latency in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path", "status"})
+
+	// OpenAPIRateLimited 超出频率限制的 OpenAPI 请求数
+	OpenAPIRateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "glyccat_openapi_rate_limited_total",
+		Help: "QQ OpenAPI requests that were queued or rejected by the rate limiter.",
+	}, []string{"group", "result"})
+)
+
+const filterName = "glyccat_metrics"
+
+var registerOnce sync.Once
+
+// RegisterBotgoFilters 注册 botgo 的事件过滤器与 OpenAPI 请求过滤器，重复调用无效
+func RegisterBotgoFilters() {
+	registerOnce.Do(func() {
+		event.RegisterPayloadFilter(filterName, func(payload *dto.Payload) {
+			QQEventsReceived.WithLabelValues(string(payload.Type)).Inc()
+		})
+		openapi.RegisterReqFilter(filterName, openAPIRequestStarted)
+		openapi.RegisterRespFilter(filterName, openAPIRequestFinished)
+	})
+}
+
+// openAPIStarts 正在进行的 OpenAPI 请求的开始时间
+var (
+	openAPIStarts   sync.Map
+	openAPIRequests int64
+)
+
+// openAPIStaleAfter 超过该时间仍未收到响应的请求视为失败，不再记录
+const openAPIStaleAfter = 5 * time.Minute
+
+// openAPIRequestStarted 记录 OpenAPI 请求的开始时间
+func openAPIRequestStarted(req *http.Request, _ *http.Response) error {
+	now := time.Now()
+	openAPIStarts.Store(req, now)
+
+	// 请求失败时不会调用响应过滤器，定期清理这些请求
+	if atomic.AddInt64(&openAPIRequests, 1)%1024 == 0 {
+		openAPIStarts.Range(func(key, value any) bool {
+			if now.Sub(value.(time.Time)) > openAPIStaleAfter {
+				openAPIStarts.Delete(key)
+			}
+			return true
+		})
+	}
+	return nil
+}
+
+// openAPIRequestFinished 记录 OpenAPI 请求的耗时
+func openAPIRequestFinished(req *http.Request, resp *http.Response) error {
+	if req == nil {
+		return nil
+	}
+	value, ok := openAPIStarts.LoadAndDelete(req)
+	if !ok {
+		return nil
+	}
+	status := "unknown"
+	if resp != nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	ObserveSince(OpenAPIRequestDuration, value.(time.Time), req.Method, NormalizePath(req.URL.Path), status)
+	return nil
+}
+
+// NormalizePath 将路径中的 ID 替换为占位符，避免标签值过多
+//
+// 含有数字的路径段视为 ID ，如 /v2/groups/ABCD1234/messages 会变为 /v2/groups/:id/messages
+func NormalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if i == 1 && len(segment) == 2 && segment[0] == 'v' {
+			// 保留版本前缀
+			continue
+		}
+		if strings.ContainsAny(segment, "0123456789") {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// ObserveSince 记录从 start 到现在的耗时
+func ObserveSince(histogram *prometheus.HistogramVec, start time.Time, labelValues ...string) {
+	histogram.WithLabelValues(labelValues...).Observe(time.Since(start).Seconds())
+}
+
+// Handler 默认指标注册表的 HTTP 处理函数
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Default, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v2/groups/ABCD1234/messages", "/v2/groups/:id/messages"},
+		{"/v2/users/ABCD/files", "/v2/users/ABCD/files"},
+		{"/channels/123456/messages", "/channels/:id/messages"},
+		{"/guilds/1/members/2", "/guilds/:id/members/:id"},
+		{"/users/@me", "/users/@me"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizePath(tt.path); got != tt.want {
+			t.Errorf("NormalizePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	HTTPRequests.WithLabelValues("message.create", "200").Inc()
+	ObserveSince(HTTPRequestDuration, time.Now().Add(-30*time.Millisecond), "message.create")
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(recorder.Result().Body)
+	if err != nil {
+		t.Fatalf("读取指标失败: %v", err)
+	}
+
+	for _, want := range []string{
+		`glyccat_http_requests_total{method="message.create",status="200"} 1`,
+		`glyccat_http_request_duration_seconds_bucket{method="message.create",le="0.05"} 1`,
+		`glyccat_http_request_duration_seconds_count{method="message.create"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("指标输出中缺少 %s", want)
+		}
+	}
+}
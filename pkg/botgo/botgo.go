@@ -24,6 +24,12 @@ func NewSessionManager() SessionManager {
 	return defaultSessionManager
 }
 
+// Stopper 可以停止的 manager ，默认的 session manager 与 webhook manager 都实现了该接口
+type Stopper interface {
+	// Stop 关闭所有连接，之后不再重连
+	Stop() error
+}
+
 // NewWebhookManager 获得 webhook manager 实例
 func NewWebhookManager() WebhookManager {
 	return defaultWebhookManager
@@ -42,7 +48,7 @@ func SelectOpenAPIVersion(version openapi.APIVersion) error {
 // NewOpenAPI 创建新的 openapi 实例，会返回当前的 openapi 实现的实例
 // 如果需要使用其他版本的实现，需要在调用这个方法之前调用 SelectOpenAPIVersion 方法
 func NewOpenAPI(token *token.Token) openapi.OpenAPI {
-	log.Debugf("NewOpenAPI called with token: %v\n", token)
+	log.Debugf("NewOpenAPI called with appid: %d\n", token.GetAppID())
 	return openapi.DefaultImpl.Setup(token, false)
 }
 
@@ -87,6 +87,8 @@ type eventParseFunc func(event *dto.Payload, message []byte) error
 
 // ParseAndHandle 处理回调事件
 func ParseAndHandle(payload *dto.Payload) error {
+	doPayloadFilterChains(payload)
+
 	// 指定类型的 handler
 	if h, ok := eventParseFuncMap[payload.OPCode][payload.Type]; ok {
 		return h(payload, payload.RawMessage)
@@ -0,0 +1,38 @@
+package event
+
+import (
+	"sync"
+
+	"github.com/tencent-connect/botgo/dto"
+)
+
+// 提供事件过滤器支持，开发者可以在事件分发前进行计数上报等处理。
+
+// PayloadFilter 事件过滤器
+type PayloadFilter func(payload *dto.Payload)
+
+var (
+	payloadFilterLock   = sync.RWMutex{}
+	payloadFilterSet    = map[string]PayloadFilter{}
+	payloadFilterChains []string
+)
+
+// RegisterPayloadFilter 注册事件过滤器
+func RegisterPayloadFilter(name string, filter PayloadFilter) {
+	payloadFilterLock.Lock()
+	defer payloadFilterLock.Unlock()
+	if _, ok := payloadFilterSet[name]; ok {
+		return
+	}
+	payloadFilterSet[name] = filter
+	payloadFilterChains = append(payloadFilterChains, name)
+}
+
+// doPayloadFilterChains 按照注册顺序执行事件过滤器
+func doPayloadFilterChains(payload *dto.Payload) {
+	payloadFilterLock.RLock()
+	defer payloadFilterLock.RUnlock()
+	for _, name := range payloadFilterChains {
+		payloadFilterSet[name](payload)
+	}
+}
@@ -80,10 +80,10 @@ func (o *openAPIv2) DeleteChannel(ctx context.Context, channelID string) error {
 // ChannelValueObject 中的 PrivateType 不需要填充，本方法会自动填充
 func (o *openAPIv2) CreatePrivateChannel(ctx context.Context, guildID string, value *dto.ChannelValueObject,
 	userIds []string) (*dto.Channel, error) {
-	value.PrivateType = dto.ChannelPrivateTypeAdminAndMember
+	value.PrivateType = dto.ChannelPrivateTypeOnlyAdmin
 	if len(userIds) != 0 {
 		value.PrivateUserIDs = userIds
-		value.PrivateType = dto.ChannelPrivateTypeOnlyAdmin
+		value.PrivateType = dto.ChannelPrivateTypeAdminAndMember
 	}
 	return o.PostChannel(ctx, guildID, value)
 }
@@ -2,7 +2,10 @@
 package local
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/tencent-connect/botgo/dto"
@@ -15,12 +18,20 @@ import (
 
 // New 创建本地session管理器
 func New() *ChanManager {
-	return &ChanManager{}
+	return &ChanManager{
+		stop:    make(chan struct{}),
+		clients: make(map[websocket.WebSocket]struct{}),
+	}
 }
 
 // ChanManager 默认的本地 session manager 实现
 type ChanManager struct {
 	sessionChan chan dto.Session
+
+	stop     chan struct{}                    // 停止信号，关闭后不再建立新的连接
+	stopOnce sync.Once                        // 保证停止信号只关闭一次
+	mu       sync.Mutex                       // 保护 clients
+	clients  map[websocket.WebSocket]struct{} // 正在监听的连接
 }
 
 // Start 启动本地 session manager
@@ -50,11 +61,7 @@ func (l *ChanManager) Start(apInfo *dto.WebsocketAP, token *token.Token, intents
 		l.sessionChan <- session
 	}
 
-	for session := range l.sessionChan {
-		// MaxConcurrency 代表的是每 5s 可以连多少个请求
-		time.Sleep(startInterval)
-		go l.newConnect(session)
-	}
+	l.run(startInterval)
 	return nil
 }
 
@@ -84,15 +91,83 @@ func (l *ChanManager) StartSingle(apInfo *dto.WebsocketAPSingle, token *token.To
 	}
 	l.sessionChan <- session
 
-	for session := range l.sessionChan {
-		// MaxConcurrency 代表的是每 5s 可以连多少个请求
-		time.Sleep(startInterval)
-		go l.newConnect(session)
+	l.run(startInterval)
+
+	return nil
+}
+
+// run 按照间隔启动 sessionChan 中的 session ，直到 manager 被停止
+func (l *ChanManager) run(startInterval time.Duration) {
+	for {
+		select {
+		case <-l.stop:
+			return
+		case session := <-l.sessionChan:
+			// MaxConcurrency 代表的是每 5s 可以连多少个请求
+			time.Sleep(startInterval)
+			if l.stopped() {
+				return
+			}
+			go l.newConnect(session)
+		}
+	}
+}
+
+// Stop 停止 session manager ，关闭所有连接且不再重连
+func (l *ChanManager) Stop() error {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+
+	l.mu.Lock()
+	clients := make([]websocket.WebSocket, 0, len(l.clients))
+	for client := range l.clients {
+		clients = append(clients, client)
 	}
+	l.mu.Unlock()
 
+	for _, client := range clients {
+		client.Close()
+	}
 	return nil
 }
 
+// stopped 判断 manager 是否已经停止
+func (l *ChanManager) stopped() bool {
+	select {
+	case <-l.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// track 记录正在监听的连接，manager 已经停止时返回 false
+func (l *ChanManager) track(client websocket.WebSocket) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopped() {
+		return false
+	}
+	l.clients[client] = struct{}{}
+	return true
+}
+
+// untrack 移除已经退出的连接
+func (l *ChanManager) untrack(client websocket.WebSocket) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.clients, client)
+}
+
+// requeue 将 session 放回 sessionChan 等待重连，manager 已经停止时丢弃
+func (l *ChanManager) requeue(session dto.Session) {
+	if l.stopped() {
+		return
+	}
+	l.sessionChan <- session
+}
+
 // newConnect 启动一个新的连接，如果连接在监听过程中报错了，或者被远端关闭了链接，需要识别关闭的原因，能否继续 resume
 // 如果能够 resume，则往 sessionChan 中放入带有 sessionID 的 session
 // 如果不能，则清理掉 sessionID，将 session 放入 sessionChan 中
@@ -102,15 +177,21 @@ func (l *ChanManager) newConnect(session dto.Session) {
 		// panic 留下日志，放回 session
 		if err := recover(); err != nil {
 			websocket.PanicHandler(err, &session)
-			l.sessionChan <- session
+			l.requeue(session)
 		}
 	}()
 	wsClient := websocket.ClientImpl.New(session)
 	if err := wsClient.Connect(); err != nil {
 		log.Error(err)
-		l.sessionChan <- session // 连接失败，丢回去队列排队重连
+		l.requeue(session) // 连接失败，丢回去队列排队重连
+		return
+	}
+	if !l.track(wsClient) {
+		// 连接建立期间 manager 已经停止
+		wsClient.Close()
 		return
 	}
+	defer l.untrack(wsClient)
 	var err error
 	// 如果 session id 不为空，则执行的是 resume 操作，如果为空，则执行的是 identify 操作
 	if session.ID != "" {
@@ -124,6 +205,10 @@ func (l *ChanManager) newConnect(session dto.Session) {
 		return
 	}
 	if err := wsClient.Listening(); err != nil {
+		if l.stopped() {
+			log.Infof("[ws/session] %s stopped", wsClient.Session())
+			return
+		}
 		log.Errorf("[ws/session] Listening err %+v", err)
 		currentSession := wsClient.Session()
 		// 对于不能够进行重连的session，需要清空 session id 与 seq
@@ -138,7 +223,7 @@ func (l *ChanManager) newConnect(session dto.Session) {
 			panic(msg) // 当机器人被下架，或者封禁，将不能再连接，所以 panic
 		}
 		// 将 session 放到 session chan 中，用于启动新的连接，当前连接退出
-		l.sessionChan <- *currentSession
+		l.requeue(*currentSession)
 		return
 	}
 }
@@ -146,23 +231,54 @@ func (l *ChanManager) newConnect(session dto.Session) {
 func NewWebhook() *WebhookManager {
 	return &WebhookManager{
 		config: make(chan dto.Config, 1),
+		stop:   make(chan struct{}),
 	}
 }
 
 type WebhookManager struct {
 	config chan dto.Config
+
+	stop     chan struct{}   // 停止信号，关闭后不再重新监听
+	stopOnce sync.Once       // 保证停止信号只关闭一次
+	mu       sync.Mutex      // 保护 server
+	server   webhook.WebHook // 正在监听的 webhook 服务器
 }
 
 func (w *WebhookManager) Start(config *dto.Config) error {
 	w.config <- *config
 
-	for config := range w.config {
-		if err := w.listenAndServe(config); err != nil {
-			log.Errorf("webhook server listen and serve failed: %v", err)
+	for {
+		select {
+		case <-w.stop:
+			return nil
+		case config := <-w.config:
+			if err := w.listenAndServe(config); err != nil {
+				log.Errorf("webhook server listen and serve failed: %v", err)
+			}
+			select {
+			case <-w.stop:
+				return nil
+			case <-time.After(5 * time.Second):
+			}
 		}
-		time.Sleep(5 * time.Second)
 	}
-	return nil
+}
+
+// Stop 停止 webhook manager ，关闭 webhook 服务器且不再重新监听
+func (w *WebhookManager) Stop() error {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+
+	w.mu.Lock()
+	server := w.server
+	w.server = nil
+	w.mu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	return server.Close()
 }
 
 func (w *WebhookManager) listenAndServe(config dto.Config) error {
@@ -174,7 +290,20 @@ func (w *WebhookManager) listenAndServe(config dto.Config) error {
 	}()
 	whServer := webhook.ServerImpl.New(config)
 
+	w.mu.Lock()
+	select {
+	case <-w.stop:
+		w.mu.Unlock()
+		return nil
+	default:
+	}
+	w.server = whServer
+	w.mu.Unlock()
+
 	if err := whServer.Listen(); err != nil {
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
 		log.Error(err)
 		w.config <- config
 		return err
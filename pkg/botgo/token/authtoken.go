@@ -62,7 +62,7 @@ func (atoken *AuthTokenInfo) StartRefreshAccessToken(ctx context.Context, tokenU
 		//return err
 	}
 	atoken.setAuthToken(tokenInfo)
-	log.Debugf("获取到的token是: %s\n", tokenInfo.Token) // 输出获取到的token
+	log.Debugf("获取到新的token，有效期 %d 秒", tokenInfo.ExpiresIn)
 
 	// 获取token的有效期（通常以秒为单位）
 	tokenTTL := tokenInfo.ExpiresIn
@@ -86,7 +86,7 @@ func (atoken *AuthTokenInfo) StartRefreshAccessToken(ctx context.Context, tokenU
 				tokenInfo, err := queryAccessToken(ctx, tokenURL, appID, clientSecrent)
 				if err == nil {
 					atoken.setAuthToken(tokenInfo)
-					log.Debugf("获取到的token是: %s\n", tokenInfo.Token) // 输出获取到的token
+					log.Debugf("获取到新的token，有效期 %d 秒", tokenInfo.ExpiresIn)
 					tokenTTL = tokenInfo.ExpiresIn
 				} else {
 					log.Errorf("queryAccessToken err:%v", err)
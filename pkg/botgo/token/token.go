@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
@@ -96,6 +97,15 @@ func (t *Token) GetAccessToken() string {
 	return t.authToken.getAuthToken().Token
 }
 
+// GetExpiresAt 取得鉴权Token的过期时间，尚未取得Token时返回零值
+func (t *Token) GetExpiresAt() time.Time {
+	info := t.authToken.getAuthToken()
+	if info.Token == "" {
+		return time.Time{}
+	}
+	return info.UpTime.Add(time.Duration(info.ExpiresIn) * time.Second)
+}
+
 // GetAccessToken 取得测试鉴权Token
 // func (t *Token) GetAccessToken() string {
 // 	// 固定的token值
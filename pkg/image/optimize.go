@@ -0,0 +1,327 @@
+package image
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	maxJPEGQuality = 90   // JPEG 最高质量
+	minJPEGQuality = 50   // JPEG 最低质量，仍超出大小上限时缩小图片
+	shrinkRatio    = 0.75 // 每次缩小图片的比例
+	maxShrinkTimes = 4    // 最多缩小图片的次数
+)
+
+// ErrTooLarge 图片超出大小上限且无法在不损失内容的情况下缩小
+var ErrTooLarge = errors.New("image exceeds the size limit")
+
+// Options 图片优化选项
+type Options struct {
+	MaxWidth    int  // 最大宽度，为 0 时不限制
+	MaxHeight   int  // 最大高度，为 0 时不限制
+	MaxSize     int  // 图片大小上限，为 0 时不限制
+	ConvertWebP bool // 是否转换 WebP 图片
+}
+
+// Report 图片优化结果
+type Report struct {
+	Format       string // 原始格式
+	Width        int    // 原始宽度
+	Height       int    // 原始高度
+	Size         int    // 原始大小
+	Orientation  int    // EXIF 方向
+	OutputFormat string // 输出格式
+	OutputWidth  int    // 输出宽度
+	OutputHeight int    // 输出高度
+	OutputSize   int    // 输出大小
+	Quality      int    // JPEG 质量，其他格式为 0
+}
+
+// Changed 图片是否被重新编码
+func (r *Report) Changed() bool {
+	return r.OutputFormat != r.Format || r.OutputSize != r.Size || r.Orientation > 1
+}
+
+func (r *Report) String() string {
+	if !r.Changed() {
+		return fmt.Sprintf("%s %dx%d %d bytes, unchanged", r.Format, r.Width, r.Height, r.Size)
+	}
+	output := fmt.Sprintf(
+		"%s %dx%d %d bytes -> %s %dx%d %d bytes",
+		r.Format, r.Width, r.Height, r.Size,
+		r.OutputFormat, r.OutputWidth, r.OutputHeight, r.OutputSize,
+	)
+	if r.Quality > 0 {
+		output += fmt.Sprintf(", quality %d", r.Quality)
+	}
+	if r.Orientation > 1 {
+		output += fmt.Sprintf(", orientation %d", r.Orientation)
+	}
+	return output
+}
+
+// Optimize 在内存中优化图片，使其符合尺寸与大小限制
+//
+// 已符合限制的 PNG/JPEG 保持原样，其余图片会应用 EXIF 方向、缩放并重新编码。
+// 不转换 WebP 时，只有超出限制的 WebP 图片会被重新编码。
+// GIF 重新编码会丢失动画，因此总是保持原样，超出大小上限时返回 ErrTooLarge
+func Optimize(data []byte, opts Options) ([]byte, *Report, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to detect image format: %v", err)
+	}
+
+	report := &Report{
+		Format:      format,
+		Width:       config.Width,
+		Height:      config.Height,
+		Size:        len(data),
+		Orientation: exifOrientation(data),
+	}
+	passThrough := func() ([]byte, *Report, error) {
+		report.OutputFormat = report.Format
+		report.OutputWidth = report.Width
+		report.OutputHeight = report.Height
+		report.OutputSize = report.Size
+		return data, report, nil
+	}
+
+	switch format {
+	case "gif":
+		// 重新编码会丢失动画
+		if !fitsSize(len(data), opts) {
+			return nil, nil, fmt.Errorf("%w: gif is %d bytes, limit is %d bytes", ErrTooLarge, len(data), opts.MaxSize)
+		}
+		return passThrough()
+	case "webp":
+		if !opts.ConvertWebP && fitsDimensions(config.Width, config.Height, opts) && fitsSize(len(data), opts) {
+			return passThrough()
+		}
+	case "png", "jpeg":
+		if report.Orientation <= 1 && fitsDimensions(config.Width, config.Height, opts) && fitsSize(len(data), opts) {
+			return passThrough()
+		}
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode image: %v", err)
+	}
+	img = fitImage(img, opts)
+
+	// 保留 PNG 的无损与透明度，超出大小上限时再转为 JPEG
+	if format == "png" || (format != "jpeg" && !isOpaque(img)) {
+		buffer := new(bytes.Buffer)
+		if err := png.Encode(buffer, img); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode image to png: %v", err)
+		}
+		if fitsSize(buffer.Len(), opts) {
+			return finish(report, buffer.Bytes(), "png", img, 0)
+		}
+	}
+
+	output, quality, img, err := encodeJPEG(flatten(img), opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return finish(report, output, "jpeg", img, quality)
+}
+
+// finish 填写输出信息
+func finish(report *Report, output []byte, format string, img image.Image, quality int) ([]byte, *Report, error) {
+	bounds := img.Bounds()
+	report.OutputFormat = format
+	report.OutputWidth = bounds.Dx()
+	report.OutputHeight = bounds.Dy()
+	report.OutputSize = len(output)
+	report.Quality = quality
+	return output, report, nil
+}
+
+// encodeJPEG 以不超过大小上限的最高质量编码 JPEG ，最低质量仍超出上限时缩小图片
+func encodeJPEG(img image.Image, opts Options) ([]byte, int, image.Image, error) {
+	for shrink := 0; ; shrink++ {
+		output, err := jpegEncode(img, maxJPEGQuality)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if fitsSize(len(output), opts) {
+			return output, maxJPEGQuality, img, nil
+		}
+
+		// 二分查找符合大小上限的最高质量
+		var best []byte
+		bestQuality := 0
+		low, high := minJPEGQuality, maxJPEGQuality-1
+		for low <= high {
+			quality := (low + high) / 2
+			output, err := jpegEncode(img, quality)
+			if err != nil {
+				return nil, 0, nil, err
+			}
+			if fitsSize(len(output), opts) {
+				best, bestQuality = output, quality
+				low = quality + 1
+			} else {
+				high = quality - 1
+			}
+		}
+		if best != nil {
+			return best, bestQuality, img, nil
+		}
+
+		if shrink >= maxShrinkTimes {
+			return nil, 0, nil, fmt.Errorf("failed to fit image into %d bytes", opts.MaxSize)
+		}
+		bounds := img.Bounds()
+		width := int(float64(bounds.Dx()) * shrinkRatio)
+		height := int(float64(bounds.Dy()) * shrinkRatio)
+		if width < 1 || height < 1 {
+			return nil, 0, nil, fmt.Errorf("failed to fit image into %d bytes", opts.MaxSize)
+		}
+		img = imaging.Resize(img, width, height, imaging.Lanczos)
+	}
+}
+
+// jpegEncode 以指定质量编码 JPEG
+func jpegEncode(img image.Image, quality int) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	if err := jpeg.Encode(buffer, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode image to jpg: %v", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// fitsDimensions 尺寸是否符合限制
+func fitsDimensions(width, height int, opts Options) bool {
+	return (opts.MaxWidth <= 0 || width <= opts.MaxWidth) && (opts.MaxHeight <= 0 || height <= opts.MaxHeight)
+}
+
+// fitsSize 大小是否符合限制
+func fitsSize(size int, opts Options) bool {
+	return opts.MaxSize <= 0 || size <= opts.MaxSize
+}
+
+// fitImage 将图片等比缩小至最大尺寸以内
+func fitImage(img image.Image, opts Options) image.Image {
+	bounds := img.Bounds()
+	if fitsDimensions(bounds.Dx(), bounds.Dy(), opts) {
+		return img
+	}
+	maxWidth, maxHeight := opts.MaxWidth, opts.MaxHeight
+	if maxWidth <= 0 {
+		maxWidth = bounds.Dx()
+	}
+	if maxHeight <= 0 {
+		maxHeight = bounds.Dy()
+	}
+	return imaging.Fit(img, maxWidth, maxHeight, imaging.Lanczos)
+}
+
+// isOpaque 图片是否不含透明像素
+func isOpaque(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return opaque.Opaque()
+	}
+	return false
+}
+
+// flatten 将透明图片合成到白色背景上
+func flatten(img image.Image) image.Image {
+	if isOpaque(img) {
+		return img
+	}
+	bounds := img.Bounds()
+	background := image.NewRGBA(bounds)
+	draw.Draw(background, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(background, bounds, img, bounds.Min, draw.Over)
+	return background
+}
+
+// exifOrientation 读取 JPEG 图片的 EXIF 方向，不存在时返回 1
+func exifOrientation(data []byte) int {
+	if !bytes.HasPrefix(data, []byte(HeaderJPG)) {
+		return 1
+	}
+
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xFF {
+			return 1
+		}
+		marker := data[offset+1]
+		switch {
+		case marker == 0xFF:
+			// 填充字节
+			offset++
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD8):
+			// 没有长度的标记
+			offset += 2
+			continue
+		case marker == 0xDA || marker == 0xD9:
+			// 已到达图像数据
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if length < 2 || offset+2+length > len(data) {
+			return 1
+		}
+		if marker == 0xE1 {
+			if orientation := parseExifOrientation(data[offset+4 : offset+2+length]); orientation > 0 {
+				return orientation
+			}
+		}
+		offset += 2 + length
+	}
+	return 1
+}
+
+// parseExifOrientation 从 APP1 段中解析方向标签，不存在时返回 0
+func parseExifOrientation(segment []byte) int {
+	if len(segment) < 14 || string(segment[:6]) != "Exif\x00\x00" {
+		return 0
+	}
+	tiff := segment[6:]
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 0
+			}
+			return orientation
+		}
+	}
+	return 0
+}
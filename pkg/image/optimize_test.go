@@ -0,0 +1,48 @@
+package image
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+// encodeTestGIF 生成一张指定尺寸的 GIF 图片
+func encodeTestGIF(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.Black, color.White})
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.SetColorIndex(x, y, uint8((x^y)&1))
+		}
+	}
+	buffer := new(bytes.Buffer)
+	if err := gif.Encode(buffer, img, nil); err != nil {
+		t.Fatalf("gif.Encode() error = %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestOptimizeGIFPassThrough(t *testing.T) {
+	data := encodeTestGIF(t, 16, 16)
+
+	output, report, err := Optimize(data, Options{MaxSize: len(data)})
+	if err != nil {
+		t.Fatalf("Optimize() error = %v", err)
+	}
+	if !bytes.Equal(output, data) || report.Changed() {
+		t.Errorf("Optimize() changed a gif within the limit: %s", report)
+	}
+}
+
+func TestOptimizeGIFTooLarge(t *testing.T) {
+	data := encodeTestGIF(t, 64, 64)
+
+	_, _, err := Optimize(data, Options{MaxSize: len(data) - 1})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Optimize() error = %v, want %v", err, ErrTooLarge)
+	}
+}
@@ -1,9 +1,7 @@
 package mp4
 
 import (
-	"bytes"
-	"crypto/md5"
-	"encoding/hex"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -17,23 +15,21 @@ const cachePath = "data/cache"
 
 const limit = 4 * 1024
 
-var HeadersMP4 []string = []string{
-	"ftypisom",
-	"ftypmp42",
-}
-
-// IsMP4 判断是否为 MP4 文件
-func IsMP4(file []byte) bool {
-	for _, header := range HeadersMP4 {
-		if bytes.HasPrefix(file, []byte(header)) {
-			return true
+// CheckVideo 判断给定视频流是否为合法视频
+//
+// 优先解析 ISO-BMFF 结构，无法解析时根据内容嗅探格式
+func CheckVideo(readSeeker io.ReadSeeker) (string, bool) {
+	_, _ = readSeeker.Seek(0, io.SeekStart)
+	defer readSeeker.Seek(0, io.SeekStart)
+	if data, err := io.ReadAll(readSeeker); err == nil {
+		if info, err := Probe(data); err == nil && info.VideoCodec != "" {
+			if info.IsMP4() {
+				return "video/mp4", true
+			}
+			return "video/quicktime", true
 		}
 	}
-	return false
-}
 
-// CheckVideo 判断给定视频流是否为合法视频
-func CheckVideo(readSeeker io.ReadSeeker) (string, bool) {
 	t := scanType(readSeeker)
 	if strings.Contains(t, "video") {
 		return t, true
@@ -52,44 +48,39 @@ func scanType(readerSeeker io.ReadSeeker) string {
 
 // EncoderMP4 编码为 MP4
 func EncoderMP4(data []byte) ([]byte, error) {
-	hash := md5.New()
-	_, err := hash.Write(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute md5: %v", err)
-	}
-	name := hex.EncodeToString(hash.Sum(nil))
-	return encode(data, name)
+	return EncoderMP4Context(context.Background(), data)
 }
 
-// encode 编码为 MP4
-func encode(data []byte, name string) (mp4Video []byte, err error) {
+// EncoderMP4Context 编码为 MP4 ，ctx 取消时终止编码进程
+func EncoderMP4Context(ctx context.Context, data []byte) (mp4Video []byte, err error) {
 	// 0. 创建缓存目录
 	err = createDirectoryIfNotExist(cachePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create video cache directory: %v", err)
 	}
+	tempDir, err := os.MkdirTemp(cachePath, "mp4-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
 
 	// 1. 创建临时文件
-	rawPath := path.Join(cachePath, name)
-	err = os.WriteFile(rawPath, data, os.ModePerm)
+	rawPath := path.Join(tempDir, "input")
+	err = os.WriteFile(rawPath, data, 0600)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temporary file: %v", err)
 	}
-	defer os.Remove(rawPath)
 
 	// 2. 转换 MP4
-	mp4Path := path.Join(cachePath, name+".mp4")
-	cmd := exec.Command("ffmpeg", "-i", rawPath, "-vcodec", "libx264", "-acodec", "aac", mp4Path)
+	mp4Path := path.Join(tempDir, "output.mp4")
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-i", rawPath, "-vcodec", "libx264", "-acodec", "aac", mp4Path)
 	if err := cmd.Run(); err != nil {
-
 		return nil, fmt.Errorf("failed to convert to mp4: %v", err)
 	}
 	mp4Video, err = os.ReadFile(mp4Path)
 	if err != nil {
-
 		return nil, fmt.Errorf("failed to read mp4 file: %v", err)
 	}
-	defer os.Remove(mp4Path)
 
 	return mp4Video, nil
 }
@@ -0,0 +1,272 @@
+package mp4
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// BrandsMP4 可以视为 MP4 的 ftyp 品牌
+var BrandsMP4 []string = []string{
+	"isom",
+	"iso2",
+	"iso4",
+	"iso5",
+	"iso6",
+	"mp41",
+	"mp42",
+	"avc1",
+	"M4V ",
+	"dash",
+}
+
+// 可以直接上传的编码
+var (
+	playableVideoCodecs = []string{"avc1", "avc3"}
+	playableAudioCodecs = []string{"mp4a"}
+)
+
+var (
+	ErrNotISOBMFF = errors.New("not an iso-bmff file") // 文件不以 ftyp 盒子开头
+	ErrNoMovie    = errors.New("moov box not found")   // 缺少 moov 盒子
+)
+
+// Info ISO-BMFF 文件信息
+type Info struct {
+	MajorBrand       string        // 主品牌
+	CompatibleBrands []string      // 兼容品牌
+	Duration         time.Duration // 时长
+	Width            int           // 第一个视频轨道的宽度
+	Height           int           // 第一个视频轨道的高度
+	VideoCodec       string        // 第一个视频轨道的编码，如 avc1 、hvc1
+	AudioCodec       string        // 第一个音频轨道的编码，如 mp4a
+	FastStart        bool          // moov 是否位于 mdat 之前
+}
+
+// IsMP4 品牌是否属于 MP4
+func (i *Info) IsMP4() bool {
+	if contains(BrandsMP4, i.MajorBrand) {
+		return true
+	}
+	for _, brand := range i.CompatibleBrands {
+		if contains(BrandsMP4, brand) {
+			return true
+		}
+	}
+	return false
+}
+
+// Playable 是否为可以直接上传的 H.264/AAC MP4 视频
+func (i *Info) Playable() bool {
+	if !i.IsMP4() || !contains(playableVideoCodecs, i.VideoCodec) {
+		return false
+	}
+	return i.AudioCodec == "" || contains(playableAudioCodecs, i.AudioCodec)
+}
+
+// IsMP4 判断是否为 MP4 文件
+func IsMP4(file []byte) bool {
+	info, err := Probe(file)
+	return err == nil && info.IsMP4()
+}
+
+// Probe 解析 ISO-BMFF 文件的盒子结构，获取品牌、编码、时长与尺寸
+func Probe(data []byte) (*Info, error) {
+	if len(data) < 12 || string(data[4:8]) != "ftyp" {
+		return nil, ErrNotISOBMFF
+	}
+
+	info := &Info{}
+	foundMovie := false
+	seenMediaData := false
+	err := walkBoxes(data, func(boxType string, payload []byte) error {
+		switch boxType {
+		case "ftyp":
+			if len(payload) < 8 {
+				return fmt.Errorf("ftyp box too short")
+			}
+			info.MajorBrand = string(payload[:4])
+			for offset := 8; offset+4 <= len(payload); offset += 4 {
+				info.CompatibleBrands = append(info.CompatibleBrands, string(payload[offset:offset+4]))
+			}
+		case "moov":
+			foundMovie = true
+			info.FastStart = !seenMediaData
+			return parseMovie(payload, info)
+		case "mdat":
+			seenMediaData = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !foundMovie {
+		return nil, ErrNoMovie
+	}
+	return info, nil
+}
+
+// walkBoxes 遍历同一层级的盒子
+func walkBoxes(data []byte, fn func(boxType string, payload []byte) error) error {
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < 8 {
+			return fmt.Errorf("truncated box header at offset %d", offset)
+		}
+		size := uint64(binary.BigEndian.Uint32(data[offset:]))
+		boxType := string(data[offset+4 : offset+8])
+		header := uint64(8)
+		switch size {
+		case 0:
+			// 延伸至文件末尾
+			size = uint64(len(data) - offset)
+		case 1:
+			// 64 位大小
+			if len(data)-offset < 16 {
+				return fmt.Errorf("truncated %s box header", boxType)
+			}
+			size = binary.BigEndian.Uint64(data[offset+8:])
+			header = 16
+		}
+		if size < header || size > uint64(len(data)-offset) {
+			return fmt.Errorf("invalid %s box size %d at offset %d", boxType, size, offset)
+		}
+
+		if err := fn(boxType, data[offset+int(header):offset+int(size)]); err != nil {
+			return err
+		}
+		offset += int(size)
+	}
+	return nil
+}
+
+// parseMovie 解析 moov 盒子
+func parseMovie(data []byte, info *Info) error {
+	return walkBoxes(data, func(boxType string, payload []byte) error {
+		switch boxType {
+		case "mvhd":
+			duration, err := parseMovieHeader(payload)
+			if err != nil {
+				return err
+			}
+			info.Duration = duration
+		case "trak":
+			return parseTrack(payload, info)
+		}
+		return nil
+	})
+}
+
+// parseMovieHeader 解析 mvhd 盒子中的时长
+func parseMovieHeader(data []byte) (time.Duration, error) {
+	var timescale, duration uint64
+	switch {
+	case len(data) >= 20 && data[0] == 0:
+		timescale = uint64(binary.BigEndian.Uint32(data[12:]))
+		duration = uint64(binary.BigEndian.Uint32(data[16:]))
+	case len(data) >= 32 && data[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(data[20:]))
+		duration = binary.BigEndian.Uint64(data[24:])
+	default:
+		return 0, fmt.Errorf("invalid mvhd box")
+	}
+	if timescale == 0 {
+		return 0, nil
+	}
+	return scaleDuration(duration, timescale), nil
+}
+
+// parseTrack 解析 trak 盒子，只记录第一个视频轨道与第一个音频轨道
+func parseTrack(data []byte, info *Info) error {
+	var width, height int
+	var handler, codec string
+
+	err := walkBoxes(data, func(boxType string, payload []byte) error {
+		switch boxType {
+		case "tkhd":
+			width, height = parseTrackHeader(payload)
+		case "mdia":
+			return walkBoxes(payload, func(boxType string, payload []byte) error {
+				switch boxType {
+				case "hdlr":
+					if len(payload) >= 12 {
+						handler = string(payload[8:12])
+					}
+				case "minf":
+					codec = findSampleEntry(payload)
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	switch handler {
+	case "vide":
+		if info.VideoCodec == "" {
+			info.VideoCodec = codec
+			info.Width, info.Height = width, height
+		}
+	case "soun":
+		if info.AudioCodec == "" {
+			info.AudioCodec = codec
+		}
+	}
+	return nil
+}
+
+// parseTrackHeader 解析 tkhd 盒子中的尺寸
+func parseTrackHeader(data []byte) (int, int) {
+	var offset int
+	switch {
+	case len(data) >= 84 && data[0] == 0:
+		offset = 76
+	case len(data) >= 96 && data[0] == 1:
+		offset = 88
+	default:
+		return 0, 0
+	}
+	// 16.16 定点数
+	width := int(binary.BigEndian.Uint32(data[offset:]) >> 16)
+	height := int(binary.BigEndian.Uint32(data[offset+4:]) >> 16)
+	return width, height
+}
+
+// findSampleEntry 在 minf 盒子中查找第一个样本描述的编码
+func findSampleEntry(data []byte) string {
+	var codec string
+	walkBoxes(data, func(boxType string, payload []byte) error {
+		if boxType != "stbl" {
+			return nil
+		}
+		return walkBoxes(payload, func(boxType string, payload []byte) error {
+			// stsd 为完整盒子，版本与标志之后是条目数，之后是样本描述盒子
+			if boxType == "stsd" && len(payload) >= 16 && codec == "" {
+				codec = string(payload[12:16])
+			}
+			return nil
+		})
+	})
+	return codec
+}
+
+// scaleDuration 将时间刻度下的时长转换为 time.Duration
+func scaleDuration(duration, timescale uint64) time.Duration {
+	seconds := duration / timescale
+	remainder := duration % timescale
+	return time.Duration(seconds)*time.Second + time.Duration(remainder*uint64(time.Second)/timescale)
+}
+
+// contains 判断列表中是否含有指定值
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
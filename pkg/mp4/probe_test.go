@@ -0,0 +1,193 @@
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// box 构造盒子
+func box(boxType string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	data := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	data = append(data, boxType...)
+	return append(data, body...)
+}
+
+// largeBox 构造使用 64 位大小的盒子
+func largeBox(boxType string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	data := binary.BigEndian.AppendUint32(nil, 1)
+	data = append(data, boxType...)
+	data = binary.BigEndian.AppendUint64(data, uint64(16+len(body)))
+	return append(data, body...)
+}
+
+// ftypBox 构造 ftyp 盒子
+func ftypBox(major string, compatible ...string) []byte {
+	payload := append([]byte(major), 0, 0, 0, 0)
+	for _, brand := range compatible {
+		payload = append(payload, brand...)
+	}
+	return box("ftyp", payload)
+}
+
+// mvhdBox 构造版本 0 的 mvhd 盒子
+func mvhdBox(timescale, duration uint32) []byte {
+	payload := make([]byte, 20)
+	binary.BigEndian.PutUint32(payload[12:], timescale)
+	binary.BigEndian.PutUint32(payload[16:], duration)
+	return box("mvhd", payload)
+}
+
+// mvhdBoxV1 构造版本 1 的 mvhd 盒子
+func mvhdBoxV1(timescale uint32, duration uint64) []byte {
+	payload := make([]byte, 32)
+	payload[0] = 1
+	binary.BigEndian.PutUint32(payload[20:], timescale)
+	binary.BigEndian.PutUint64(payload[24:], duration)
+	return box("mvhd", payload)
+}
+
+// trakBox 构造含有处理器类型与样本描述的 trak 盒子
+func trakBox(handler, codec string, width, height int) []byte {
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], uint32(width)<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], uint32(height)<<16)
+
+	hdlr := make([]byte, 12)
+	copy(hdlr[8:], handler)
+
+	stsd := make([]byte, 8)
+	binary.BigEndian.PutUint32(stsd[4:], 1)
+	stsd = append(stsd, box(codec)...)
+
+	return box("trak",
+		box("tkhd", tkhd),
+		box("mdia",
+			box("hdlr", hdlr),
+			box("minf", box("stbl", box("stsd", stsd))),
+		),
+	)
+}
+
+func TestProbe(t *testing.T) {
+	video := trakBox("vide", "avc1", 1280, 720)
+	audio := trakBox("soun", "mp4a", 0, 0)
+
+	tests := []struct {
+		name      string
+		data      []byte
+		duration  time.Duration
+		codec     string
+		fastStart bool
+		playable  bool
+	}{
+		{
+			name:      "fast start",
+			data:      bytes.Join([][]byte{ftypBox("isom", "mp41"), box("moov", mvhdBox(1000, 5500), video, audio), box("mdat")}, nil),
+			duration:  5500 * time.Millisecond,
+			codec:     "avc1",
+			fastStart: true,
+			playable:  true,
+		},
+		{
+			name:     "moov after mdat",
+			data:     bytes.Join([][]byte{ftypBox("mp42"), box("mdat", make([]byte, 16)), box("moov", mvhdBox(600, 1200), video)}, nil),
+			duration: 2 * time.Second,
+			codec:    "avc1",
+			playable: true,
+		},
+		{
+			name:      "64-bit box size",
+			data:      bytes.Join([][]byte{ftypBox("isom"), largeBox("moov", mvhdBoxV1(90000, 90000*3), video)}, nil),
+			duration:  3 * time.Second,
+			codec:     "avc1",
+			fastStart: true,
+			playable:  true,
+		},
+		{
+			name:      "hevc",
+			data:      bytes.Join([][]byte{ftypBox("isom"), box("moov", mvhdBox(1000, 1000), trakBox("vide", "hvc1", 1920, 1080))}, nil),
+			duration:  time.Second,
+			codec:     "hvc1",
+			fastStart: true,
+		},
+		{
+			name:      "quicktime",
+			data:      bytes.Join([][]byte{ftypBox("qt  "), box("moov", mvhdBox(1000, 1000), video)}, nil),
+			duration:  time.Second,
+			codec:     "avc1",
+			fastStart: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := Probe(tt.data)
+			if err != nil {
+				t.Fatalf("Probe() error = %v", err)
+			}
+			if info.Duration != tt.duration {
+				t.Errorf("Duration = %v, want %v", info.Duration, tt.duration)
+			}
+			if info.VideoCodec != tt.codec {
+				t.Errorf("VideoCodec = %q, want %q", info.VideoCodec, tt.codec)
+			}
+			if info.FastStart != tt.fastStart {
+				t.Errorf("FastStart = %v, want %v", info.FastStart, tt.fastStart)
+			}
+			if info.Playable() != tt.playable {
+				t.Errorf("Playable() = %v, want %v", info.Playable(), tt.playable)
+			}
+		})
+	}
+}
+
+func TestProbeTrackSize(t *testing.T) {
+	data := bytes.Join([][]byte{ftypBox("isom"), box("moov", mvhdBox(1000, 1000), trakBox("vide", "avc1", 1280, 720))}, nil)
+	info, err := Probe(data)
+	if err != nil {
+		t.Fatalf("Probe() error = %v", err)
+	}
+	if info.Width != 1280 || info.Height != 720 {
+		t.Errorf("size = %dx%d, want 1280x720", info.Width, info.Height)
+	}
+}
+
+func TestProbeInvalid(t *testing.T) {
+	moov := box("moov", mvhdBox(1000, 1000), trakBox("vide", "avc1", 0, 0))
+	oversized := box("moov", mvhdBox(1000, 1000))
+	binary.BigEndian.PutUint32(oversized, uint32(len(oversized)+1))
+	large := largeBox("moov", mvhdBox(1000, 1000))
+	binary.BigEndian.PutUint64(large[8:], 1<<40)
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{name: "not iso-bmff", data: []byte("RIFF\x00\x00\x00\x00WAVE"), want: ErrNotISOBMFF},
+		{name: "no moov", data: bytes.Join([][]byte{ftypBox("isom"), box("mdat")}, nil), want: ErrNoMovie},
+		{name: "truncated box header", data: bytes.Join([][]byte{ftypBox("isom"), moov, {0, 0, 0}}, nil)},
+		{name: "truncated box", data: bytes.Join([][]byte{ftypBox("isom"), moov[:len(moov)-4]}, nil)},
+		{name: "oversized box", data: bytes.Join([][]byte{ftypBox("isom"), oversized}, nil)},
+		{name: "truncated 64-bit header", data: bytes.Join([][]byte{ftypBox("isom"), large[:12]}, nil)},
+		{name: "oversized 64-bit box", data: bytes.Join([][]byte{ftypBox("isom"), large}, nil)},
+		{name: "invalid mvhd", data: bytes.Join([][]byte{ftypBox("isom"), box("moov", box("mvhd", make([]byte, 8)))}, nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Probe(tt.data)
+			if err == nil {
+				t.Fatal("Probe() error = nil, want error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Probe() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
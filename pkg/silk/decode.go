@@ -0,0 +1,145 @@
+package silk
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strconv"
+)
+
+const (
+	FormatWAV string = "wav" // WAV 格式
+	FormatOGG string = "ogg" // OGG/Opus 格式
+)
+
+// decodeSampleRate QQ 语音的采样率
+const decodeSampleRate = 24000
+
+// DecoderSilk 将 SILK/AMR 语音解码为 WAV 或 OGG
+//
+// SILK 使用内嵌的编解码器解码，AMR 解码与 OGG 编码需要 ffmpeg ，ctx 取消时终止解码进程
+func DecoderSilk(ctx context.Context, data []byte, format string) ([]byte, error) {
+	if format != FormatWAV && format != FormatOGG {
+		return nil, fmt.Errorf("unsupported audio format: %s", format)
+	}
+	if !IsAMRorSILK(data) {
+		return nil, errors.New("data is not silk or amr audio")
+	}
+
+	err := createDirectoryIfNotExist(cachePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audio cache directory: %v", err)
+	}
+	tempDir, err := os.MkdirTemp(cachePath, "decode-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// AMR 直接交由 ffmpeg 处理
+	if bytes.HasPrefix(data, []byte(HeaderAmr)) {
+		amrPath := path.Join(tempDir, "voice.amr")
+		if err := os.WriteFile(amrPath, data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to create temporary file: %v", err)
+		}
+		return ffmpegConvert(ctx, tempDir, format, "-i", amrPath)
+	}
+
+	// 1. 解码 SILK 为 PCM
+	silkPath := path.Join(tempDir, "voice.silk")
+	if err := os.WriteFile(silkPath, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	pcmPath := path.Join(tempDir, "voice.pcm")
+	if err := decodeSilkToPCM(ctx, silkPath, pcmPath); err != nil {
+		return nil, err
+	}
+
+	// 2. 转换为目标格式
+	if format == FormatWAV {
+		pcm, err := os.ReadFile(pcmPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pcm file: %v", err)
+		}
+		return pcmToWAV(pcm, decodeSampleRate), nil
+	}
+	return ffmpegConvert(
+		ctx, tempDir, format,
+		"-f", "s16le", "-ar", strconv.Itoa(decodeSampleRate), "-ac", "1", "-i", pcmPath,
+	)
+}
+
+// decodeSilkToPCM 使用内嵌的编解码器将 SILK 解码为 PCM
+func decodeSilkToPCM(ctx context.Context, silkPath, pcmPath string) error {
+	if runtime.GOOS == "windows" {
+		return errors.New("silk decoding is not supported on windows")
+	}
+
+	codecPath, err := extractSilkCodec()
+	if err != nil {
+		return err
+	}
+	defer os.Remove(codecPath)
+
+	cmd := exec.CommandContext(ctx, codecPath, "stp", "-i", silkPath, "-o", pcmPath, "-s", strconv.Itoa(decodeSampleRate))
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to decode silk: %v", err)
+	}
+	return nil
+}
+
+// ffmpegConvert 使用 ffmpeg 将输入转换为目标格式
+func ffmpegConvert(ctx context.Context, tempDir, format string, inputArgs ...string) ([]byte, error) {
+	outputPath := path.Join(tempDir, "output."+format)
+	args := append([]string{"-y"}, inputArgs...)
+	if format == FormatOGG {
+		args = append(args, "-c:a", "libopus")
+	}
+	args = append(args, outputPath)
+
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	if errors.Is(cmd.Err, exec.ErrDot) {
+		cmd.Err = nil
+	}
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to convert audio to %s: %v", format, err)
+	}
+
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s file: %v", format, err)
+	}
+	return output, nil
+}
+
+// pcmToWAV 为 16 位单声道 PCM 添加 WAV 文件头
+func pcmToWAV(pcm []byte, sampleRate int) []byte {
+	const (
+		channels      = 1
+		bitsPerSample = 16
+	)
+	blockAlign := channels * bitsPerSample / 8
+
+	buffer := bytes.NewBuffer(make([]byte, 0, 44+len(pcm)))
+	buffer.WriteString("RIFF")
+	binary.Write(buffer, binary.LittleEndian, uint32(36+len(pcm)))
+	buffer.WriteString("WAVE")
+	buffer.WriteString("fmt ")
+	binary.Write(buffer, binary.LittleEndian, uint32(16))
+	binary.Write(buffer, binary.LittleEndian, uint16(1)) // PCM
+	binary.Write(buffer, binary.LittleEndian, uint16(channels))
+	binary.Write(buffer, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(buffer, binary.LittleEndian, uint32(sampleRate*blockAlign))
+	binary.Write(buffer, binary.LittleEndian, uint16(blockAlign))
+	binary.Write(buffer, binary.LittleEndian, uint16(bitsPerSample))
+	buffer.WriteString("data")
+	binary.Write(buffer, binary.LittleEndian, uint32(len(pcm)))
+	buffer.Write(pcm)
+	return buffer.Bytes()
+}
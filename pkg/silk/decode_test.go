@@ -0,0 +1,51 @@
+package silk
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPCMToWAV(t *testing.T) {
+	tests := []struct {
+		sampleRate int
+		samples    int
+		want       time.Duration
+	}{
+		{decodeSampleRate, decodeSampleRate, time.Second},
+		{decodeSampleRate, decodeSampleRate / 2, 500 * time.Millisecond},
+		{16000, 0, 0},
+	}
+
+	for _, tt := range tests {
+		wav := pcmToWAV(make([]byte, tt.samples*2), tt.sampleRate)
+		if len(wav) != 44+tt.samples*2 {
+			t.Errorf("pcmToWAV() length = %d, want %d", len(wav), 44+tt.samples*2)
+		}
+		info, err := ProbeAudio(wav)
+		if err != nil {
+			t.Fatalf("ProbeAudio() error = %v", err)
+		}
+		if info.Format != AudioWAV || info.SampleRate != tt.sampleRate || info.Channels != 1 || info.Duration != tt.want {
+			t.Errorf("ProbeAudio(pcmToWAV()) = %+v, want wav %d Hz mono %v", info, tt.sampleRate, tt.want)
+		}
+	}
+}
+
+func TestDecoderSilkRejectsInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		format string
+	}{
+		{"unsupported format", silkFrames(1), "mp3"},
+		{"not silk or amr", wavFile(16000, 1, 32), FormatWAV},
+		{"empty", nil, FormatOGG},
+	}
+
+	for _, tt := range tests {
+		if _, err := DecoderSilk(context.Background(), tt.data, tt.format); err == nil {
+			t.Errorf("%s: DecoderSilk() error = nil, want error", tt.name)
+		}
+	}
+}
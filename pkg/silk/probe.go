@@ -0,0 +1,328 @@
+package silk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// 音频格式
+const (
+	AudioSILK = "silk"
+	AudioAMR  = "amr"
+	AudioWAV  = "wav"
+	AudioMP3  = "mp3"
+	AudioOGG  = "ogg"
+	AudioFLAC = "flac"
+	AudioAAC  = "aac"
+)
+
+// audioMimeTypes 音频格式对应的资源类型
+var audioMimeTypes = map[string]string{
+	AudioSILK: "audio/silk",
+	AudioAMR:  "audio/amr",
+	AudioWAV:  "audio/wav",
+	AudioMP3:  "audio/mpeg",
+	AudioOGG:  "audio/ogg",
+	AudioFLAC: "audio/flac",
+	AudioAAC:  "audio/aac",
+}
+
+// ErrUnknownAudio 无法识别的音频格式
+var ErrUnknownAudio = errors.New("unknown audio format")
+
+// AudioInfo 音频信息，无法确定的字段为零值
+type AudioInfo struct {
+	Format     string        // 音频格式
+	Codec      string        // 编码，仅 OGG 有效，如 opus 、vorbis
+	SampleRate int           // 采样率
+	Channels   int           // 声道数
+	Duration   time.Duration // 时长
+}
+
+// MimeType 音频格式对应的资源类型
+func (i *AudioInfo) MimeType() string {
+	return audioMimeTypes[i.Format]
+}
+
+// ProbeAudio 根据文件头识别音频格式，并尽可能获取采样率、声道数与时长
+func ProbeAudio(data []byte) (*AudioInfo, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte(HeaderSilk)), bytes.HasPrefix(data, []byte(HeaderSilk[1:])):
+		return probeSILK(data), nil
+	case bytes.HasPrefix(data, []byte(HeaderAmr)):
+		return probeAMR(data), nil
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WAVE":
+		return probeWAV(data), nil
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return probeOGG(data), nil
+	case bytes.HasPrefix(data, []byte("fLaC")):
+		return probeFLAC(data), nil
+	case bytes.HasPrefix(data, []byte("ID3")):
+		return probeMP3(data)
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xF6 == 0xF0:
+		return probeADTS(data), nil
+	case len(data) >= 2 && data[0] == 0xFF && data[1]&0xE0 == 0xE0:
+		return probeMP3(data)
+	}
+	return nil, ErrUnknownAudio
+}
+
+// probeSILK 解析 SILK ，每帧 20 毫秒
+func probeSILK(data []byte) *AudioInfo {
+	info := &AudioInfo{Format: AudioSILK, Channels: 1}
+	offset := len(HeaderSilk)
+	if data[0] != HeaderSilk[0] {
+		offset--
+	}
+
+	frames := 0
+	for offset+2 <= len(data) {
+		size := int(int16(binary.LittleEndian.Uint16(data[offset:])))
+		if size <= 0 || offset+2+size > len(data) {
+			break
+		}
+		frames++
+		offset += 2 + size
+	}
+	info.Duration = time.Duration(frames) * 20 * time.Millisecond
+	return info
+}
+
+// AMR 各模式的帧数据长度，不含帧头
+var (
+	amrNBFrameSizes = [16]int{12, 13, 15, 17, 19, 20, 26, 31, 5, 0, 0, 0, 0, 0, 0, 0}
+	amrWBFrameSizes = [16]int{17, 23, 32, 36, 40, 46, 50, 58, 60, 5, 0, 0, 0, 0, 0, 0}
+)
+
+// probeAMR 解析单声道 AMR-NB/AMR-WB ，每帧 20 毫秒
+func probeAMR(data []byte) *AudioInfo {
+	info := &AudioInfo{Format: AudioAMR, Channels: 1}
+	var offset int
+	var frameSizes [16]int
+	switch {
+	case bytes.HasPrefix(data, []byte("#!AMR-WB\n")):
+		offset, frameSizes, info.SampleRate = 9, amrWBFrameSizes, 16000
+	case bytes.HasPrefix(data, []byte("#!AMR\n")):
+		offset, frameSizes, info.SampleRate = 6, amrNBFrameSizes, 8000
+	default:
+		// 多声道等其他变体只识别格式
+		return info
+	}
+
+	frames := 0
+	for offset < len(data) {
+		offset += 1 + frameSizes[(data[offset]>>3)&0x0F]
+		frames++
+	}
+	info.Duration = time.Duration(frames) * 20 * time.Millisecond
+	return info
+}
+
+// probeWAV 解析 WAV 的 fmt 与 data 块
+func probeWAV(data []byte) *AudioInfo {
+	info := &AudioInfo{Format: AudioWAV}
+	var byteRate, dataSize int
+	for offset := 12; offset+8 <= len(data); {
+		id := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		body := data[offset+8:]
+		switch id {
+		case "fmt ":
+			if len(body) >= 16 {
+				info.Channels = int(binary.LittleEndian.Uint16(body[2:]))
+				info.SampleRate = int(binary.LittleEndian.Uint32(body[4:]))
+				byteRate = int(binary.LittleEndian.Uint32(body[8:]))
+			}
+		case "data":
+			// 流式写入的 WAV 可能没有正确的 data 长度
+			dataSize = size
+			if dataSize <= 0 || dataSize > len(body) {
+				dataSize = len(body)
+			}
+		}
+		if size < 0 || size > len(data) {
+			break
+		}
+		// 块按偶数字节对齐
+		offset += 8 + size + size&1
+	}
+	if byteRate > 0 {
+		info.Duration = time.Duration(int64(dataSize) * int64(time.Second) / int64(byteRate))
+	}
+	return info
+}
+
+// probeOGG 解析 OGG 中的 Opus/Vorbis 头部，时长根据最后一页的粒度位置计算
+func probeOGG(data []byte) *AudioInfo {
+	info := &AudioInfo{Format: AudioOGG}
+
+	// 第一页的数据包为编码标识头
+	if len(data) >= 27 {
+		segments := int(data[26])
+		packet := 27 + segments
+		if packet <= len(data) {
+			header := data[packet:]
+			switch {
+			case len(header) >= 19 && bytes.HasPrefix(header, []byte("OpusHead")):
+				info.Codec = "opus"
+				info.Channels = int(header[9])
+				// Opus 的粒度位置总是以 48 kHz 计算
+				info.SampleRate = 48000
+			case len(header) >= 16 && bytes.HasPrefix(header, []byte("\x01vorbis")):
+				info.Codec = "vorbis"
+				info.Channels = int(header[11])
+				info.SampleRate = int(binary.LittleEndian.Uint32(header[12:]))
+			case len(header) >= 13 && bytes.HasPrefix(header, []byte("\x7fFLAC")):
+				info.Codec = "flac"
+			}
+		}
+	}
+
+	last := bytes.LastIndex(data, []byte("OggS"))
+	if last >= 0 && last+14 <= len(data) && info.SampleRate > 0 {
+		granule := int64(binary.LittleEndian.Uint64(data[last+6:]))
+		if granule > 0 {
+			info.Duration = time.Duration(granule * int64(time.Second) / int64(info.SampleRate))
+		}
+	}
+	return info
+}
+
+// probeFLAC 解析 FLAC 的 STREAMINFO 块
+func probeFLAC(data []byte) *AudioInfo {
+	info := &AudioInfo{Format: AudioFLAC}
+	// fLaC 后为 4 字节块头，STREAMINFO 总是第一个元数据块
+	if len(data) < 8+18 || data[4]&0x7F != 0 {
+		return info
+	}
+	streamInfo := data[8:]
+	packed := binary.BigEndian.Uint64(streamInfo[10:])
+	info.SampleRate = int(packed >> 44)
+	info.Channels = int((packed>>41)&0x07) + 1
+	samples := int64(packed & 0xFFFFFFFFF)
+	if info.SampleRate > 0 {
+		info.Duration = time.Duration(samples * int64(time.Second) / int64(info.SampleRate))
+	}
+	return info
+}
+
+// ADTS 采样率索引
+var adtsSampleRates = [16]int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+// probeADTS 解析 ADTS 封装的 AAC ，每帧 1024 个采样
+func probeADTS(data []byte) *AudioInfo {
+	info := &AudioInfo{Format: AudioAAC}
+	if len(data) < 7 {
+		return info
+	}
+	info.SampleRate = adtsSampleRates[(data[2]>>2)&0x0F]
+	info.Channels = int((data[2]&0x01)<<2 | data[3]>>6)
+
+	frames := 0
+	for offset := 0; offset+7 <= len(data); frames++ {
+		if data[offset] != 0xFF || data[offset+1]&0xF6 != 0xF0 {
+			break
+		}
+		length := int(data[offset+3]&0x03)<<11 | int(data[offset+4])<<3 | int(data[offset+5])>>5
+		if length < 7 {
+			break
+		}
+		offset += length
+	}
+	if info.SampleRate > 0 {
+		info.Duration = time.Duration(int64(frames) * 1024 * int64(time.Second) / int64(info.SampleRate))
+	}
+	return info
+}
+
+// MPEG 音频的比特率与采样率表
+var (
+	mp3Bitrates = [2][3][16]int{
+		// MPEG-1
+		{
+			{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+			{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+		},
+		// MPEG-2/2.5
+		{
+			{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+			{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		},
+	}
+	mp3SampleRates = [4][3]int{
+		{11025, 12000, 8000},  // MPEG-2.5
+		{0, 0, 0},             // 保留
+		{22050, 24000, 16000}, // MPEG-2
+		{44100, 48000, 32000}, // MPEG-1
+	}
+)
+
+// probeMP3 解析 MPEG 音频帧头，存在 Xing/Info 头时使用其中的帧数，否则按固定比特率估算时长
+func probeMP3(data []byte) (*AudioInfo, error) {
+	offset := 0
+	// 跳过 ID3v2 标签
+	if bytes.HasPrefix(data, []byte("ID3")) && len(data) >= 10 {
+		size := int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F)
+		offset = 10 + size
+		if data[5]&0x10 != 0 {
+			offset += 10
+		}
+	}
+	// 查找帧同步
+	for offset+4 <= len(data) && !(data[offset] == 0xFF && data[offset+1]&0xE0 == 0xE0) {
+		offset++
+	}
+	if offset+4 > len(data) {
+		return nil, ErrUnknownAudio
+	}
+
+	header := data[offset:]
+	version := (header[1] >> 3) & 0x03
+	layer := 3 - int((header[1]>>1)&0x03)
+	bitrateIndex := header[2] >> 4
+	sampleRateIndex := (header[2] >> 2) & 0x03
+	if version == 1 || layer == 3 || bitrateIndex == 0x0F || sampleRateIndex == 0x03 {
+		return nil, ErrUnknownAudio
+	}
+
+	info := &AudioInfo{Format: AudioMP3, Channels: 2}
+	if header[3]>>6 == 0x03 {
+		info.Channels = 1
+	}
+	info.SampleRate = mp3SampleRates[version][sampleRateIndex]
+	table := 0
+	if version != 3 {
+		table = 1
+	}
+	bitrate := mp3Bitrates[table][layer][bitrateIndex] * 1000
+
+	// 每帧采样数
+	samplesPerFrame := 1152
+	if layer == 0 {
+		samplesPerFrame = 384
+	} else if layer == 2 && version != 3 {
+		samplesPerFrame = 576
+	}
+
+	// Xing/Info 头位于第一帧的边信息之后
+	for _, tag := range []string{"Xing", "Info"} {
+		index := bytes.Index(header[:min(len(header), 64)], []byte(tag))
+		if index < 0 || index+12 > len(header) {
+			continue
+		}
+		if binary.BigEndian.Uint32(header[index+4:])&0x01 != 0 {
+			frames := int64(binary.BigEndian.Uint32(header[index+8:]))
+			info.Duration = time.Duration(frames * int64(samplesPerFrame) * int64(time.Second) / int64(info.SampleRate))
+			return info, nil
+		}
+	}
+
+	if bitrate > 0 {
+		info.Duration = time.Duration(int64(len(data)-offset) * 8 * int64(time.Second) / int64(bitrate))
+	}
+	return info, nil
+}
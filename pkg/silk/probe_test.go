@@ -0,0 +1,195 @@
+package silk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// mp3XingFrame 构造含有 Xing 头的 MPEG-1 Layer III 帧，128 kbps 44.1 kHz 联合立体声
+func mp3XingFrame(tag string, frames uint32) []byte {
+	frame := []byte{0xFF, 0xFB, 0x90, 0x64}
+	// 立体声的边信息长度为 32 字节
+	frame = append(frame, make([]byte, 32)...)
+	frame = append(frame, tag...)
+	frame = binary.BigEndian.AppendUint32(frame, 0x01)
+	frame = binary.BigEndian.AppendUint32(frame, frames)
+	return append(frame, make([]byte, 417-len(frame))...)
+}
+
+// adtsFrames 构造 AAC LC 44.1 kHz 立体声的 ADTS 帧
+func adtsFrames(count int) []byte {
+	const length = 10
+	var data []byte
+	for i := 0; i < count; i++ {
+		data = append(data, 0xFF, 0xF1, 0x50, 0x80, length>>3, (length&0x07)<<5|0x1F, 0xFC)
+		data = append(data, make([]byte, length-7)...)
+	}
+	return data
+}
+
+// flacStreamInfo 构造只含有 STREAMINFO 块的 FLAC 头部
+func flacStreamInfo(sampleRate, channels int, samples uint64) []byte {
+	data := []byte("fLaC")
+	data = append(data, 0x80, 0, 0, 34)
+	streamInfo := make([]byte, 34)
+	packed := uint64(sampleRate)<<44 | uint64(channels-1)<<41 | 15<<36 | samples
+	binary.BigEndian.PutUint64(streamInfo[10:], packed)
+	return append(data, streamInfo...)
+}
+
+// wavFile 构造 16 位 PCM 的 WAV 文件
+func wavFile(sampleRate, channels, dataSize int) []byte {
+	fmtChunk := make([]byte, 16)
+	binary.LittleEndian.PutUint16(fmtChunk[0:], 1)
+	binary.LittleEndian.PutUint16(fmtChunk[2:], uint16(channels))
+	binary.LittleEndian.PutUint32(fmtChunk[4:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(fmtChunk[8:], uint32(sampleRate*channels*2))
+	binary.LittleEndian.PutUint16(fmtChunk[12:], uint16(channels*2))
+	binary.LittleEndian.PutUint16(fmtChunk[14:], 16)
+
+	data := []byte("RIFF\x00\x00\x00\x00WAVE")
+	data = append(data, "fmt "...)
+	data = binary.LittleEndian.AppendUint32(data, 16)
+	data = append(data, fmtChunk...)
+	data = append(data, "data"...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(dataSize))
+	return append(data, make([]byte, dataSize)...)
+}
+
+// silkFrames 构造指定帧数的 SILK 数据
+func silkFrames(count int) []byte {
+	data := []byte(HeaderSilk)
+	for i := 0; i < count; i++ {
+		data = append(data, 4, 0, 1, 2, 3, 4)
+	}
+	return data
+}
+
+func TestProbeAudio(t *testing.T) {
+	id3 := append([]byte("ID3\x04\x00\x00\x00\x00\x00\x0A"), make([]byte, 10)...)
+
+	tests := []struct {
+		name       string
+		data       []byte
+		format     string
+		sampleRate int
+		channels   int
+		duration   time.Duration
+	}{
+		{
+			name:       "mp3 xing",
+			data:       mp3XingFrame("Xing", 100),
+			format:     AudioMP3,
+			sampleRate: 44100,
+			channels:   2,
+			duration:   time.Duration(100 * 1152 * int64(time.Second) / 44100),
+		},
+		{
+			name:       "mp3 info with id3",
+			data:       append(id3, mp3XingFrame("Info", 441)...),
+			format:     AudioMP3,
+			sampleRate: 44100,
+			channels:   2,
+			duration:   time.Duration(441 * 1152 * int64(time.Second) / 44100),
+		},
+		{
+			name:       "adts",
+			data:       adtsFrames(43),
+			format:     AudioAAC,
+			sampleRate: 44100,
+			channels:   2,
+			duration:   time.Duration(43 * 1024 * int64(time.Second) / 44100),
+		},
+		{
+			name:       "adts truncated header",
+			data:       adtsFrames(3)[:25],
+			format:     AudioAAC,
+			sampleRate: 44100,
+			channels:   2,
+			duration:   time.Duration(2 * 1024 * int64(time.Second) / 44100),
+		},
+		{
+			name:       "flac streaminfo",
+			data:       flacStreamInfo(48000, 2, 48000*10),
+			format:     AudioFLAC,
+			sampleRate: 48000,
+			channels:   2,
+			duration:   10 * time.Second,
+		},
+		{
+			name:   "flac truncated",
+			data:   flacStreamInfo(48000, 2, 48000*10)[:20],
+			format: AudioFLAC,
+		},
+		{
+			name:       "wav",
+			data:       wavFile(16000, 1, 16000*2*3),
+			format:     AudioWAV,
+			sampleRate: 16000,
+			channels:   1,
+			duration:   3 * time.Second,
+		},
+		{
+			name:     "silk",
+			data:     silkFrames(50),
+			format:   AudioSILK,
+			channels: 1,
+			duration: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ProbeAudio(tt.data)
+			if err != nil {
+				t.Fatalf("ProbeAudio() error = %v", err)
+			}
+			if info.Format != tt.format {
+				t.Errorf("Format = %q, want %q", info.Format, tt.format)
+			}
+			if info.SampleRate != tt.sampleRate {
+				t.Errorf("SampleRate = %d, want %d", info.SampleRate, tt.sampleRate)
+			}
+			if info.Channels != tt.channels {
+				t.Errorf("Channels = %d, want %d", info.Channels, tt.channels)
+			}
+			if info.Duration != tt.duration {
+				t.Errorf("Duration = %v, want %v", info.Duration, tt.duration)
+			}
+		})
+	}
+}
+
+func TestProbeAudioUnknown(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "text", data: []byte("hello world")},
+		{name: "id3 without frame", data: append([]byte("ID3\x04\x00\x00\x00\x00\x00\x02"), 0, 0)},
+		{name: "reserved mpeg version", data: []byte{0xFF, 0xEB, 0x90, 0x64}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ProbeAudio(tt.data); !errors.Is(err, ErrUnknownAudio) {
+				t.Errorf("ProbeAudio() error = %v, want %v", err, ErrUnknownAudio)
+			}
+		})
+	}
+}
+
+func TestProbeAudioSilkWithoutPrefix(t *testing.T) {
+	data := bytes.TrimPrefix(silkFrames(5), []byte(HeaderSilk[:1]))
+	info, err := ProbeAudio(data)
+	if err != nil {
+		t.Fatalf("ProbeAudio() error = %v", err)
+	}
+	if info.Duration != 100*time.Millisecond {
+		t.Errorf("Duration = %v, want %v", info.Duration, 100*time.Millisecond)
+	}
+}
@@ -2,9 +2,8 @@ package silk
 
 import (
 	"bytes"
-	"crypto/md5"
+	"context"
 	"embed"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -35,7 +34,17 @@ func IsAMRorSILK(file []byte) bool {
 }
 
 // CheckAudio 判断给定音频流是否为合法音频
+//
+// 优先根据文件头识别格式，无法识别时根据内容嗅探格式
 func CheckAudio(readSeeker io.ReadSeeker) (string, bool) {
+	_, _ = readSeeker.Seek(0, io.SeekStart)
+	defer readSeeker.Seek(0, io.SeekStart)
+	if data, err := io.ReadAll(readSeeker); err == nil {
+		if info, err := ProbeAudio(data); err == nil {
+			return info.MimeType(), true
+		}
+	}
+
 	t := scanType(readSeeker)
 	if strings.Contains(t, "audio") {
 		return t, true
@@ -54,53 +63,76 @@ func scanType(readerSeeker io.ReadSeeker) string {
 
 // EncoderSilk 编码为 SILK
 func EncoderSilk(data []byte) ([]byte, error) {
-	hash := md5.New()
-	_, err := hash.Write(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute md5: %v", err)
-	}
-	name := hex.EncodeToString(hash.Sum(nil))
-	return encode(data, name)
+	return EncoderSilkContext(context.Background(), data)
 }
 
-// encode 编码为 SILK
-func encode(data []byte, name string) (silkWav []byte, err error) {
+// EncoderSilkContext 编码为 SILK ，ctx 取消时终止编码进程
+func EncoderSilkContext(ctx context.Context, data []byte) (silkWav []byte, err error) {
 	// 0. 创建缓存目录
 	err = createDirectoryIfNotExist(cachePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create audio cache directory: %v", err)
 	}
+	tempDir, err := os.MkdirTemp(cachePath, "silk-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
 
 	// 1. 创建临时文件
-	rawPath := path.Join(cachePath, name+".wav")
-	err = os.WriteFile(rawPath, data, os.ModePerm)
+	rawPath := path.Join(tempDir, "input")
+	err = os.WriteFile(rawPath, data, 0600)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temporary file: %v", err)
 	}
-	defer os.Remove(rawPath)
 
 	// 2. 转换 PCM
 	sampleRate := 24000 // 固定采样率，之后可能采取配置或动态决定
-	pcmPath := path.Join(cachePath, name+".pcm")
-	cmd := exec.Command("ffmpeg", "-i", rawPath, "-f", "s16le", "-ar", strconv.Itoa(sampleRate), "-ac", "1", pcmPath)
+	pcmPath := path.Join(tempDir, "input.pcm")
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-i", rawPath, "-f", "s16le", "-ar", strconv.Itoa(sampleRate), "-ac", "1", pcmPath)
 	if errors.Is(cmd.Err, exec.ErrDot) {
 		cmd.Err = nil
 	}
 	if err = cmd.Run(); err != nil {
 		return nil, fmt.Errorf("failed to convert to pcm: %v", err)
 	}
-	defer os.Remove(pcmPath)
 
-	silkPath := path.Join(cachePath, name+".silk")
+	silkPath := path.Join(tempDir, "output.silk")
 
 	// 3. 转换 SILK
+	codecPath, err := extractSilkCodec()
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(codecPath)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, codecPath, "-i", pcmPath, "-o", silkPath, "-s", strconv.Itoa(sampleRate))
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("failed to encode silk: %v", err)
+		}
+	} else {
+		cmd = exec.CommandContext(ctx, codecPath, "pts", "-i", pcmPath, "-o", silkPath, "-s", strconv.Itoa(sampleRate))
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("failed to encode silk: %v", err)
+		}
+	}
+	silkWav, err = os.ReadFile(silkPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read silk file: %v", err)
+	}
+
+	return silkWav, nil
+}
+
+// extractSilkCodec 将内嵌的 SILK 编解码器写入临时文件，返回其路径
+func extractSilkCodec() (string, error) {
 	codecFileName, err := getSilkCodecPath()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get silk codec path: %v", err)
+		return "", fmt.Errorf("failed to get silk codec path: %v", err)
 	}
 	codecData, err := silkCodecs.ReadFile(codecFileName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read silk codec: %v", err)
+		return "", fmt.Errorf("failed to read silk codec: %v", err)
 	}
 	filePattern := "silk_codec*"
 	if runtime.GOOS == "windows" {
@@ -108,36 +140,22 @@ func encode(data []byte, name string) (silkWav []byte, err error) {
 	}
 	file, err := os.CreateTemp("", filePattern)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create silk codec temporary file: %v", err)
+		return "", fmt.Errorf("failed to create silk codec temporary file: %v", err)
 	}
-	defer os.Remove(file.Name())
 	if _, err := file.Write(codecData); err != nil {
-		return nil, fmt.Errorf("failed to write silk codec temporary file: %v", err)
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write silk codec temporary file: %v", err)
 	}
 	if err := file.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close silk codec temporary file: %v", err)
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to close silk codec temporary file: %v", err)
 	}
 	if err := os.Chmod(file.Name(), 0700); err != nil {
-		return nil, fmt.Errorf("failed to change silk codec temporary file permission: %v", err)
-	}
-	if runtime.GOOS == "windows" {
-		cmd = exec.Command(file.Name(), "-i", pcmPath, "-o", silkPath, "-s", strconv.Itoa(sampleRate))
-		if err := cmd.Run(); err != nil {
-			return nil, fmt.Errorf("failed to encode silk: %v", err)
-		}
-	} else {
-		cmd = exec.Command(file.Name(), "pts", "-i", pcmPath, "-o", silkPath, "-s", strconv.Itoa(sampleRate))
-		if err := cmd.Run(); err != nil {
-			return nil, fmt.Errorf("failed to encode silk: %v", err)
-		}
-	}
-	silkWav, err = os.ReadFile(silkPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read silk file: %v", err)
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to change silk codec temporary file permission: %v", err)
 	}
-	defer os.Remove(silkPath)
-
-	return silkWav, nil
+	return file.Name(), nil
 }
 
 // createDirectoryIfNotExist 检查目录是否存在，不存在则创建
@@ -0,0 +1,242 @@
+package transcode
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// cacheSweepEvery 缓存清理间隔
+const cacheSweepEvery = 10 * time.Minute
+
+// ErrTimeout 转码超时
+var ErrTimeout = errors.New("transcoding timed out")
+
+// Func 转码函数，ctx 取消时应尽快终止
+type Func func(ctx context.Context, data []byte) ([]byte, error)
+
+// Options 转码服务选项
+type Options struct {
+	Workers  int           // 最大并发转码数，不大于 0 时为 1
+	Timeout  time.Duration // 单个转码任务的超时时间，为 0 时不限制
+	CacheDir string        // 转码结果缓存目录，为空时不缓存
+	CacheTTL time.Duration // 转码结果缓存有效期，为 0 时不过期
+}
+
+// call 进行中的转码任务
+type call struct {
+	done    chan struct{}
+	result  []byte
+	err     error
+	waiters int                // 等待结果的调用方数量，由 Service.mu 保护
+	cancel  context.CancelFunc // 取消转码任务
+}
+
+// Service 转码服务
+//
+// 限制同时进行的转码任务数，相同内容的并发请求只会转码一次，转码结果以内容哈希为键缓存
+type Service struct {
+	opts  Options
+	slots chan struct{}
+	calls map[string]*call
+	mu    sync.Mutex
+
+	lastSweep time.Time
+	sweepMu   sync.Mutex
+}
+
+// New 创建转码服务
+func New(opts Options) *Service {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	return &Service{
+		opts:  opts,
+		slots: make(chan struct{}, opts.Workers),
+		calls: make(map[string]*call),
+	}
+}
+
+// Do 转码数据，kind 用于区分不同的转码方式与参数
+//
+// 转码任务在独立的 context 中执行，受 Options.Timeout 限制；
+// 某个调用方取消时不会影响等待相同结果的其他调用方，所有调用方都取消后转码任务随之取消并释放并发名额
+func (s *Service) Do(ctx context.Context, kind string, data []byte, fn Func) ([]byte, error) {
+	key := cacheKey(kind, data)
+
+	// 优先使用缓存
+	if result, ok := s.loadCache(key); ok {
+		return result, nil
+	}
+
+	// 合并相同内容的转码任务
+	s.mu.Lock()
+	c, ok := s.calls[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.Background())
+		c = &call{done: make(chan struct{}), cancel: cancel}
+		s.calls[key] = c
+		go s.execute(callCtx, key, c, data, fn)
+	}
+	c.waiters++
+	s.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.result, c.err
+	case <-ctx.Done():
+		s.leave(key, c)
+		return nil, ctx.Err()
+	}
+}
+
+// leave 调用方不再等待转码结果，最后一个调用方离开时取消转码任务
+func (s *Service) leave(key string, c *call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c.waiters--
+	if c.waiters > 0 {
+		return
+	}
+	// 之后的调用方不再加入已取消的任务
+	if s.calls[key] == c {
+		delete(s.calls, key)
+	}
+	c.cancel()
+}
+
+// execute 执行转码任务并通知所有等待的调用方
+func (s *Service) execute(ctx context.Context, key string, c *call, data []byte, fn Func) {
+	defer c.cancel()
+
+	c.result, c.err = s.run(ctx, data, fn)
+	if c.err == nil {
+		s.storeCache(key, c.result)
+	}
+
+	s.mu.Lock()
+	if s.calls[key] == c {
+		delete(s.calls, key)
+	}
+	s.mu.Unlock()
+	close(c.done)
+}
+
+// run 在并发限制内执行转码
+func (s *Service) run(ctx context.Context, data []byte, fn Func) ([]byte, error) {
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-s.slots }()
+
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+
+	result, err := fn(ctx, data)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %s", ErrTimeout, s.opts.Timeout)
+	}
+	return result, err
+}
+
+// cacheKey 计算缓存键
+func cacheKey(kind string, data []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(kind))
+	hash.Write([]byte{0})
+	hash.Write(data)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// loadCache 读取缓存
+func (s *Service) loadCache(key string) ([]byte, bool) {
+	if s.opts.CacheDir == "" {
+		return nil, false
+	}
+
+	path := filepath.Join(s.opts.CacheDir, key)
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if s.opts.CacheTTL > 0 && time.Since(stat.ModTime()) > s.opts.CacheTTL {
+		os.Remove(path)
+		return nil, false
+	}
+	result, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	// 刷新有效期
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return result, true
+}
+
+// storeCache 写入缓存
+func (s *Service) storeCache(key string, result []byte) {
+	if s.opts.CacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(s.opts.CacheDir, 0755); err != nil {
+		return
+	}
+
+	// 先写入临时文件再重命名，避免读取到不完整的缓存
+	temp, err := os.CreateTemp(s.opts.CacheDir, "tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = temp.Write(result)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(temp.Name(), filepath.Join(s.opts.CacheDir, key)) != nil {
+		os.Remove(temp.Name())
+		return
+	}
+
+	s.sweepCache()
+}
+
+// sweepCache 定期清理过期的缓存
+func (s *Service) sweepCache() {
+	if s.opts.CacheTTL <= 0 {
+		return
+	}
+
+	s.sweepMu.Lock()
+	if time.Since(s.lastSweep) < cacheSweepEvery {
+		s.sweepMu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.sweepMu.Unlock()
+
+	entries, err := os.ReadDir(s.opts.CacheDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if time.Since(info.ModTime()) > s.opts.CacheTTL {
+			os.Remove(filepath.Join(s.opts.CacheDir, entry.Name()))
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package transcode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoCoalescesCalls(t *testing.T) {
+	service := New(Options{Workers: 1})
+	release := make(chan struct{})
+	var runs atomic.Int32
+	fn := func(ctx context.Context, data []byte) ([]byte, error) {
+		runs.Add(1)
+		<-release
+		return bytes.ToUpper(data), nil
+	}
+
+	results := make(chan []byte, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			result, err := service.Do(context.Background(), "upper", []byte("voice"), fn)
+			if err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+			results <- result
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < 2; i++ {
+		if result := <-results; string(result) != "VOICE" {
+			t.Errorf("Do() = %q, want %q", result, "VOICE")
+		}
+	}
+	if got := runs.Load(); got != 1 {
+		t.Errorf("fn ran %d times, want 1", got)
+	}
+}
+
+func TestDoFirstCallerCancelDoesNotAffectOthers(t *testing.T) {
+	service := New(Options{Workers: 1})
+	release := make(chan struct{})
+	fn := func(ctx context.Context, data []byte) ([]byte, error) {
+		select {
+		case <-release:
+			return data, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// 第一个调用方发起任务后取消
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := service.Do(ctx, "copy", []byte("video"), fn)
+		firstErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := service.Do(context.Background(), "copy", []byte("video"), fn)
+		second <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("first Do() error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	if err := <-second; err != nil {
+		t.Errorf("second Do() error = %v, want nil", err)
+	}
+}
+
+func TestDoTimeout(t *testing.T) {
+	service := New(Options{Workers: 1, Timeout: 10 * time.Millisecond})
+	fn := func(ctx context.Context, data []byte) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	if _, err := service.Do(context.Background(), "slow", []byte("image"), fn); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Do() error = %v, want %v", err, ErrTimeout)
+	}
+}
+
+func TestDoAllCallersCancelReleasesSlot(t *testing.T) {
+	service := New(Options{Workers: 1})
+	started := make(chan struct{}, 1)
+	stopped := make(chan struct{})
+	blocking := func(ctx context.Context, data []byte) ([]byte, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		close(stopped)
+		return nil, ctx.Err()
+	}
+
+	// 两个调用方等待同一个任务后都取消
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := service.Do(ctx, "block", []byte("video"), blocking)
+			errs <- err
+		}()
+	}
+	<-started
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Errorf("Do() error = %v, want %v", err, context.Canceled)
+		}
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("transcoding was not cancelled after every caller left")
+	}
+
+	// 并发名额已释放，之后的任务可以执行
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second)
+	defer waitCancel()
+	result, err := service.Do(waitCtx, "copy", []byte("image"), func(ctx context.Context, data []byte) ([]byte, error) {
+		return data, nil
+	})
+	if err != nil || string(result) != "image" {
+		t.Errorf("Do() = %q, %v, want %q, nil", result, err, "image")
+	}
+
+	// 相同内容的新调用方会重新转码，不会加入已取消的任务
+	result, err = service.Do(waitCtx, "block", []byte("video"), func(ctx context.Context, data []byte) ([]byte, error) {
+		return data, nil
+	})
+	if err != nil || string(result) != "video" {
+		t.Errorf("Do() = %q, %v, want %q, nil", result, err, "video")
+	}
+}
@@ -45,6 +45,10 @@ func ReadyHandler(p *Processor) event.ReadyHandler {
 // ErrorNotifyHandler 处理错误通知事件
 func ErrorNotifyHandler(p *Processor) event.ErrorNotifyHandler {
 	return func(err error) {
+		// 停止时主动断开的连接不视为错误
+		if p.stopping.Load() {
+			return
+		}
 		log.Errorf("QQ 开放平台连接出现错误：%v", err)
 		SetStatus("qq", login.StatusOffline)
 		SetStatus("qqguild", login.StatusOffline)
@@ -321,6 +325,15 @@ func (p *Processor) getHandlersByName(intentName string) ([]interface{}, bool) {
 	}
 }
 
+// IsKnownIntent 判断是否为可以订阅的事件名称
+func IsKnownIntent(intentName string) bool {
+	if intentName == "DEFAULT" {
+		return false
+	}
+	_, ok := (&Processor{}).getHandlersByName(intentName)
+	return ok
+}
+
 func (p *Processor) getWebHookAvailableHandlers() ([]interface{}, bool) {
 	handlers := []interface{}{
 		ErrorNotifyHandler(p),
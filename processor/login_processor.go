@@ -153,9 +153,10 @@ func establishWebSocket(p *Processor, apiV2 openapi.OpenAPI, token *token.Token,
 
 	// 启动 session manager 管理 websocket 连接
 	// Gensokyo 强行设置分片数为 1 了，所以我也这么做吧
+	p.sessionManager = botgo.NewSessionManager()
 	go func() {
 		wsInfo.Shards = conf.Account.WebSocket.Shards
-		if err = botgo.NewSessionManager().Start(wsInfo, token, &intent); err != nil {
+		if err = p.sessionManager.Start(wsInfo, token, &intent); err != nil {
 			log.Fatalf("启动 WebSocket 失败: %s", err)
 		}
 	}()
@@ -180,9 +181,10 @@ func establishWebHook(p *Processor, conf *config.Config) error {
 		webhook.RegisterHandlers(handler)
 	}
 
+	p.webhookManager = botgo.NewWebhookManager()
 	go func() {
 		// 启动 WebHook 服务器
-		if err := botgo.NewWebhookManager().Start(webhookConfig); err != nil {
+		if err := p.webhookManager.Start(webhookConfig); err != nil {
 			log.Infof("WebHook 服务器关闭: %s", err)
 		}
 	}()
@@ -2,6 +2,7 @@ package processor
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io"
@@ -10,7 +11,11 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/WindowsSov8forUs/glyccat/config"
+	"github.com/WindowsSov8forUs/glyccat/fileserver"
+	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/pkg/image"
 	"github.com/WindowsSov8forUs/glyccat/pkg/mp4"
 	"github.com/WindowsSov8forUs/glyccat/pkg/silk"
@@ -33,8 +38,32 @@ func (src *fileSrc) GetReader() (io.Reader, error) {
 	return bytes.NewReader(src.Data), nil
 }
 
+// detectMimeType 检测文件的资源类型，优先解析音视频的文件头
+func detectMimeType(data []byte) string {
+	if info, err := silk.ProbeAudio(data); err == nil {
+		return info.MimeType()
+	}
+	if info, err := mp4.Probe(data); err == nil && info.VideoCodec != "" {
+		return "video/mp4"
+	}
+	return http.DetectContentType(data)
+}
+
 // ParseSrc 解析 src 字符串
 func ParseSrc(src string) (string, *fileSrc, error) {
+	// 本地文件服务器的链接直接读取本地文件，避免交给开放平台无法访问的链接
+	if meta, ok := fileserver.ResolveInternalURL(src); ok {
+		data, err := os.ReadFile(meta.Path)
+		if err != nil {
+			return "", nil, fmt.Errorf("读取文件失败: %w", err)
+		}
+		mimeType := meta.ContentType
+		if mimeType == "" {
+			mimeType = detectMimeType(data)
+		}
+		return "", &fileSrc{MimeType: mimeType, Data: data}, nil
+	}
+
 	// 检查是否为 URL ，是则直接返回
 	u, err := url.Parse(src)
 	if err == nil && u.Scheme != "" && u.Host != "" {
@@ -79,7 +108,7 @@ func ParseSrc(src string) (string, *fileSrc, error) {
 			if err != nil {
 				return "", nil, fmt.Errorf("读取文件失败: %w", err)
 			}
-			return "", &fileSrc{MimeType: http.DetectContentType(data), Data: data}, nil
+			return "", &fileSrc{MimeType: detectMimeType(data), Data: data}, nil
 		}
 	}
 
@@ -106,7 +135,9 @@ func ParseSrcToString(src string) (string, error) {
 }
 
 // ParseSrcToAvailavle 解析 src 字符串，返回可用的 URL 或 base64 字符串
-func ParseSrcToAvailavle(src string) (string, string, error) {
+//
+// 需要转码的文件资源会交由转码服务处理，ctx 取消时转码随之终止
+func ParseSrcToAvailavle(ctx context.Context, src string) (string, string, error) {
 	url, fileSrc, err := ParseSrc(src)
 	if err != nil {
 		return "", "", err
@@ -120,7 +151,7 @@ func ParseSrcToAvailavle(src string) (string, string, error) {
 	if fileSrc != nil {
 		// 如果是 base64 字符串，返回没有 base64 头的 base64 编码字符串
 		// 对于图片、音频与视频，需要转码为可接受的格式
-		data, err := convertToAvailableFormat(fileSrc)
+		data, err := convertToAvailableFormat(ctx, fileSrc)
 		if err != nil {
 			return "", "", fmt.Errorf("转换文件格式失败: %w", err)
 		}
@@ -132,60 +163,135 @@ func ParseSrcToAvailavle(src string) (string, string, error) {
 }
 
 // convertToAvailableFormat 将文件资源转换为可用格式
-func convertToAvailableFormat(src *fileSrc) ([]byte, error) {
+func convertToAvailableFormat(ctx context.Context, src *fileSrc) ([]byte, error) {
 	if src == nil || src.Data == nil {
 		return nil, fmt.Errorf("无效的文件资源")
 	}
 
 	// 判断并转码
 	if strings.HasPrefix(src.MimeType, "audio/") {
-		return convertAudioToSilk(src.Data)
+		return convertAudioToSilk(ctx, src.Data)
 	} else if strings.HasPrefix(src.MimeType, "video/") {
-		return convertVideoToMP4(src.Data)
+		return convertVideoToMP4(ctx, src.Data)
 	} else if strings.HasPrefix(src.MimeType, "image/") {
-		return convertImage(src.Data)
+		return convertImage(ctx, src.Data)
 	}
 
 	return src.Data, nil
 }
 
 // convertAudioToSilk 将音频文件转换为 silk 格式
-func convertAudioToSilk(data []byte) ([]byte, error) {
-	// 判断并转码
-	if !silk.IsAMRorSILK(data) {
+func convertAudioToSilk(ctx context.Context, data []byte) ([]byte, error) {
+	limits := config.GetMediaLimitsConfig()
+
+	info, err := silk.ProbeAudio(data)
+	if err != nil {
+		// 无法识别文件头的格式交由 ffmpeg 尝试转码
 		mimeType, ok := silk.CheckAudio(bytes.NewReader(data))
 		if !ok {
 			return nil, fmt.Errorf("错误的音频格式: %s", mimeType)
 		}
-		return silk.EncoderSilk(data)
+	} else if err := checkMediaDuration("音频", info.Duration, limits.AudioMaxDuration); err != nil {
+		return nil, err
 	}
-	return data, nil
+
+	// 判断并转码
+	if info == nil || (info.Format != silk.AudioSILK && info.Format != silk.AudioAMR) {
+		data, err = getTranscoder().Do(ctx, "silk", data, silk.EncoderSilkContext)
+		if err != nil {
+			return nil, err
+		}
+
+		// 无法获取原始时长时，根据转码结果检查时长
+		if limits.AudioMaxDuration > 0 && (info == nil || info.Duration == 0) {
+			output, err := silk.ProbeAudio(data)
+			if err != nil {
+				return nil, fmt.Errorf("解析转码后的音频失败: %w", err)
+			}
+			if err := checkMediaDuration("音频", output.Duration, limits.AudioMaxDuration); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return data, checkMediaSize("音频", len(data), limits.AudioMaxSize)
 }
 
 // convertVideoToMP4 将视频文件转换为 MP4 格式
-func convertVideoToMP4(data []byte) ([]byte, error) {
-	// 判断并转码
-	if !mp4.IsMP4(data) {
+func convertVideoToMP4(ctx context.Context, data []byte) ([]byte, error) {
+	limits := config.GetMediaLimitsConfig()
+
+	info, err := mp4.Probe(data)
+	if err != nil {
+		// 非 ISO-BMFF 格式交由 ffmpeg 尝试转码
 		mimeType, ok := mp4.CheckVideo(bytes.NewReader(data))
 		if !ok {
 			return nil, fmt.Errorf("错误的视频格式: %s", mimeType)
 		}
-		return mp4.EncoderMP4(data)
+	} else if err := checkMediaDuration("视频", info.Duration, limits.VideoMaxDuration); err != nil {
+		return nil, err
 	}
-	return data, nil
-}
 
-// convertImage 将图像文件转换为可用格式
-func convertImage(data []byte) ([]byte, error) {
 	// 判断并转码
-	if !image.IsGIForPNGorJPG(data) {
-		mimeType, ok := image.CheckImage(bytes.NewReader(data))
-		if !ok {
-			return nil, fmt.Errorf("错误的视频格式: %s", mimeType)
+	if info == nil || !info.Playable() {
+		data, err = getTranscoder().Do(ctx, "mp4", data, mp4.EncoderMP4Context)
+		if err != nil {
+			return nil, err
 		}
-		return image.EncoderImage(data)
+
+		// 无法获取原始时长时，根据转码结果检查时长
+		if limits.VideoMaxDuration > 0 && (info == nil || info.Duration == 0) {
+			output, err := mp4.Probe(data)
+			if err != nil {
+				return nil, fmt.Errorf("解析转码后的视频失败: %w", err)
+			}
+			if err := checkMediaDuration("视频", output.Duration, limits.VideoMaxDuration); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return data, checkMediaSize("视频", len(data), limits.VideoMaxSize)
+}
+
+// checkMediaDuration 检查音视频时长是否超出限制，maxDuration 单位为秒
+func checkMediaDuration(kind string, duration time.Duration, maxDuration uint64) error {
+	if maxDuration > 0 && duration > time.Duration(maxDuration)*time.Second {
+		return fmt.Errorf("%s时长 %s 超出限制 %ds", kind, duration.Round(time.Millisecond), maxDuration)
+	}
+	return nil
+}
+
+// checkMediaSize 检查音视频大小是否超出限制
+func checkMediaSize(kind string, size, maxSize int) error {
+	if maxSize > 0 && size > maxSize {
+		return fmt.Errorf("%s大小 %d 超出限制 %d", kind, size, maxSize)
 	}
-	return data, nil
+	return nil
+}
+
+// convertImage 将图像文件转换为可用格式
+func convertImage(ctx context.Context, data []byte) ([]byte, error) {
+	mimeType, ok := image.CheckImage(bytes.NewReader(data))
+	if !ok {
+		return nil, fmt.Errorf("错误的图片格式: %s", mimeType)
+	}
+
+	conf := config.GetImageConfig()
+	options := image.Options{
+		MaxWidth:    conf.MaxWidth,
+		MaxHeight:   conf.MaxHeight,
+		MaxSize:     conf.MaxSize,
+		ConvertWebP: conf.ConvertWebP,
+	}
+	// 处理参数不同时结果不同，需要区分缓存
+	kind := fmt.Sprintf("image:%d:%d:%d:%t", options.MaxWidth, options.MaxHeight, options.MaxSize, options.ConvertWebP)
+	return getTranscoder().Do(ctx, kind, data, func(_ context.Context, data []byte) ([]byte, error) {
+		imageData, report, err := image.Optimize(data, options)
+		if err != nil {
+			return nil, err
+		}
+		log.Debugf("图片处理: %s", report)
+		return imageData, nil
+	})
 }
 
 // getMessageLog 获取消息日志
@@ -355,11 +461,13 @@ func getMessageLog(data interface{}) string {
 func ConvertToMessageContent(data interface{}) string {
 	// 强制类型转换获取 Message 结构
 	var msg *dto.Message
-	var isAt bool = false // 是否为 at 消息
+	var isAt bool = false    // 是否为 at 消息
+	var platform = "qqguild" // 消息所属平台
 	switch v := data.(type) {
 	case *dto.GroupATMessageData:
 		msg = (*dto.Message)(v)
 		isAt = true
+		platform = "qq"
 	case *dto.ATMessageData:
 		msg = (*dto.Message)(v)
 	case *dto.MessageData:
@@ -368,8 +476,12 @@ func ConvertToMessageContent(data interface{}) string {
 		msg = (*dto.Message)(v)
 	case *dto.C2CMessageData:
 		msg = (*dto.Message)(v)
+		platform = "qq"
 	case *dto.Message:
 		msg = v
+		if v.GroupID != "" {
+			platform = "qq"
+		}
 	default:
 		return ""
 	}
@@ -449,6 +561,7 @@ func ConvertToMessageContent(data interface{}) string {
 	}
 
 	// 处理 Attachments 字段
+	var audios []*satoriMessage.MessageElementAudio
 	for _, attachment := range msg.Attachments {
 		// 根据 ContentType 前缀判断文件类型
 		switch {
@@ -468,14 +581,16 @@ func ConvertToMessageContent(data interface{}) string {
 				image.Height = uint32(attachment.Height)
 			}
 			messageSegments = append(messageSegments, &image)
-		case strings.HasPrefix(attachment.ContentType, "audio"):
+		case attachment.ContentType == "voice" || strings.HasPrefix(attachment.ContentType, "audio"):
 			audio := satoriMessage.MessageElementAudio{}
 			if strings.HasPrefix(attachment.URL, "http") {
 				audio.Src = attachment.URL
 			} else {
 				audio.Src = "https://" + attachment.URL
 			}
+
 			messageSegments = append(messageSegments, &audio)
+			audios = append(audios, &audio)
 		case strings.HasPrefix(attachment.ContentType, "video"):
 			video := satoriMessage.MessageElementVideo{}
 			if strings.HasPrefix(attachment.URL, "http") {
@@ -495,6 +610,11 @@ func ConvertToMessageContent(data interface{}) string {
 		}
 	}
 
+	// 将 silk/amr 语音解码为通用格式
+	if config.GetAudioConfig().Decode {
+		decodeVoices(audios, platform)
+	}
+
 	// 添加消息回复
 	if msg.MessageReference != nil {
 		quote := satoriMessage.MessageElementQuote{
@@ -0,0 +1,145 @@
+package processor
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	GroupPassiveWindow = 5 * time.Minute  // 群聊消息的被动回复有效期
+	C2CPassiveWindow   = 60 * time.Minute // 单聊消息的被动回复有效期
+	EventPassiveWindow = 5 * time.Minute  // 事件的被动回复有效期
+	MaxPassiveReplies  = 5                // 每条消息或事件最多被动回复的次数
+)
+
+// PassiveReply 被动回复上下文
+type PassiveReply struct {
+	MsgID   string // 回复的消息 ID ，与 EventID 只有一个不为空
+	EventID string // 回复的事件 ID
+	MsgSeq  int    // 消息序号
+}
+
+// passiveContext 收到的消息或事件的被动回复记录
+type passiveContext struct {
+	id        string
+	isEvent   bool
+	expiresAt time.Time
+	seq       int // 已经使用的最大消息序号
+}
+
+// available 是否仍然可以被动回复
+func (c *passiveContext) available(now time.Time) bool {
+	return now.Before(c.expiresAt) && c.seq < MaxPassiveReplies
+}
+
+// PassiveMapping 被动回复上下文映射
+type PassiveMapping struct {
+	latest  map[string]*passiveContext // 频道中最近收到的消息或事件
+	byId    map[string]*passiveContext // 消息或事件 ID 对应的记录
+	updates int
+	mu      sync.Mutex
+}
+
+// passivePruneEvery 每记录该数量的消息或事件清理一次过期的记录
+const passivePruneEvery = 256
+
+// globalPassiveMapping 全局被动回复上下文映射
+var globalPassiveMapping = &PassiveMapping{
+	latest: make(map[string]*passiveContext),
+	byId:   make(map[string]*passiveContext),
+}
+
+// SetPassiveMessage 记录频道中收到的消息，用于之后的被动回复
+func SetPassiveMessage(channelId, msgId string, window time.Duration) {
+	setPassiveContext(channelId, msgId, false, window)
+}
+
+// SetPassiveEvent 记录频道中收到的事件，用于之后的被动回复
+func SetPassiveEvent(channelId, eventId string, window time.Duration) {
+	setPassiveContext(channelId, eventId, true, window)
+}
+
+// setPassiveContext 记录频道中最近收到的消息或事件
+func setPassiveContext(channelId, id string, isEvent bool, window time.Duration) {
+	if channelId == "" || id == "" {
+		return
+	}
+
+	globalPassiveMapping.mu.Lock()
+	defer globalPassiveMapping.mu.Unlock()
+
+	now := time.Now()
+	globalPassiveMapping.updates++
+	if globalPassiveMapping.updates%passivePruneEvery == 0 {
+		prunePassiveContexts(now)
+	}
+
+	record, ok := globalPassiveMapping.byId[id]
+	if !ok {
+		record = &passiveContext{
+			id:        id,
+			isEvent:   isEvent,
+			expiresAt: now.Add(window),
+		}
+		globalPassiveMapping.byId[id] = record
+	}
+	globalPassiveMapping.latest[channelId] = record
+}
+
+// prunePassiveContexts 删除已经过期的记录
+func prunePassiveContexts(now time.Time) {
+	for channelId, record := range globalPassiveMapping.latest {
+		if !now.Before(record.expiresAt) {
+			delete(globalPassiveMapping.latest, channelId)
+		}
+	}
+	for id, record := range globalPassiveMapping.byId {
+		if !now.Before(record.expiresAt) {
+			delete(globalPassiveMapping.byId, id)
+		}
+	}
+}
+
+// NextPassiveReply 获取频道中最近收到的仍可被动回复的消息或事件，并分配下一个消息序号
+//
+// 没有收到过消息或事件、已经超出有效期或回复次数已用完时返回 false
+func NextPassiveReply(channelId string) (*PassiveReply, bool) {
+	globalPassiveMapping.mu.Lock()
+	defer globalPassiveMapping.mu.Unlock()
+
+	record, ok := globalPassiveMapping.latest[channelId]
+	if !ok || !record.available(time.Now()) {
+		return nil, false
+	}
+
+	record.seq++
+	reply := &PassiveReply{MsgSeq: record.seq}
+	if record.isEvent {
+		reply.EventID = record.id
+	} else {
+		reply.MsgID = record.id
+	}
+	return reply, true
+}
+
+// ReservePassiveSeq 记录 Satori 应用指定的消息或事件 ID 所使用的消息序号，seq 为 0 时分配下一个消息序号
+//
+// 未记录过的 ID 按照单聊消息的有效期开始记录
+func ReservePassiveSeq(id string, seq int) int {
+	globalPassiveMapping.mu.Lock()
+	defer globalPassiveMapping.mu.Unlock()
+
+	record, ok := globalPassiveMapping.byId[id]
+	if !ok {
+		record = &passiveContext{
+			id:        id,
+			expiresAt: time.Now().Add(C2CPassiveWindow),
+		}
+		globalPassiveMapping.byId[id] = record
+	}
+	if seq <= 0 {
+		seq = record.seq + 1
+	}
+	record.seq = max(record.seq, seq)
+	return seq
+}
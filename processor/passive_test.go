@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"testing"
+	"time"
+)
+
+// resetPassiveMapping 使用空的被动回复上下文映射，测试结束后恢复
+func resetPassiveMapping(t *testing.T) {
+	previous := globalPassiveMapping
+	globalPassiveMapping = &PassiveMapping{
+		latest: make(map[string]*passiveContext),
+		byId:   make(map[string]*passiveContext),
+	}
+	t.Cleanup(func() {
+		globalPassiveMapping = previous
+	})
+}
+
+func TestNextPassiveReply(t *testing.T) {
+	resetPassiveMapping(t)
+	SetPassiveMessage("passive-group", "group-msg", GroupPassiveWindow)
+	SetPassiveEvent("passive-event", "event", EventPassiveWindow)
+	SetPassiveMessage("passive-expired", "expired-msg", -time.Second)
+
+	tests := []struct {
+		channelId string
+		want      *PassiveReply
+	}{
+		{"passive-group", &PassiveReply{MsgID: "group-msg", MsgSeq: 1}},
+		{"passive-group", &PassiveReply{MsgID: "group-msg", MsgSeq: 2}},
+		{"passive-event", &PassiveReply{EventID: "event", MsgSeq: 1}},
+		{"passive-expired", nil},
+		{"passive-unknown", nil},
+	}
+
+	for _, tt := range tests {
+		got, ok := NextPassiveReply(tt.channelId)
+		if tt.want == nil {
+			if ok {
+				t.Errorf("NextPassiveReply(%q) = %+v, want none", tt.channelId, got)
+			}
+			continue
+		}
+		if !ok || *got != *tt.want {
+			t.Errorf("NextPassiveReply(%q) = %+v, %v, want %+v", tt.channelId, got, ok, tt.want)
+		}
+	}
+}
+
+func TestPassiveReplyLimit(t *testing.T) {
+	resetPassiveMapping(t)
+	SetPassiveMessage("passive-limit", "limit-msg", C2CPassiveWindow)
+
+	// 应用指定的消息序号同样计入回复次数
+	if seq := ReservePassiveSeq("limit-msg", 3); seq != 3 {
+		t.Fatalf("ReservePassiveSeq() = %d, want 3", seq)
+	}
+	for want := 4; want <= MaxPassiveReplies; want++ {
+		reply, ok := NextPassiveReply("passive-limit")
+		if !ok || reply.MsgSeq != want {
+			t.Fatalf("NextPassiveReply() = %+v, %v, want msg_seq %d", reply, ok, want)
+		}
+	}
+	if reply, ok := NextPassiveReply("passive-limit"); ok {
+		t.Errorf("NextPassiveReply() = %+v after %d replies, want none", reply, MaxPassiveReplies)
+	}
+
+	// 较小的消息序号不会回退已经使用的序号
+	ReservePassiveSeq("limit-msg", 1)
+	if seq := ReservePassiveSeq("limit-msg", 0); seq != MaxPassiveReplies+1 {
+		t.Errorf("ReservePassiveSeq() = %d, want %d", seq, MaxPassiveReplies+1)
+	}
+}
@@ -17,7 +17,7 @@ import (
 // ProcessC2CMessage 处理私聊消息
 func (p *Processor) ProcessC2CMessage(payload *dto.Payload, data *dto.C2CMessageData) error {
 	// 打印消息日志
-	printC2CMessage(data)
+	printC2CMessage(payload, data)
 
 	// 构建事件数据
 	var event *operation.Event
@@ -37,6 +37,7 @@ func (p *Processor) ProcessC2CMessage(payload *dto.Payload, data *dto.C2CMessage
 		Type: channel.ChannelTypeDirect,
 	}
 	SetOpenIdType(data.Author.UserOpenID, "private")
+	SetPassiveMessage(data.Author.UserOpenID, data.ID, C2CPassiveWindow)
 
 	// 构建 message
 	message := &message.Message{
@@ -68,13 +69,18 @@ func (p *Processor) ProcessC2CMessage(payload *dto.Payload, data *dto.C2CMessage
 	messageToSave.User = user
 	database.SaveMessage(messageToSave, data.Author.UserOpenID, "private")
 
+	// 记录用户
+	if err := database.ObserveUser(user.Id, user.Avatar, t.UnixMilli()); err != nil {
+		log.Warnf("记录用户 %s 时出错: %v", user.Id, err)
+	}
+
 	// 上报消息到 Satori 应用
 	return p.BroadcastEvent(event)
 }
 
-func printC2CMessage(data *dto.C2CMessageData) {
+func printC2CMessage(payload *dto.Payload, data *dto.C2CMessageData) {
 	// 构建消息日志
 	msgContent := getMessageLog(data)
 
-	log.Infof("收到来自用户 %s 的私聊消息: %s", data.Author.UserOpenID, msgContent)
+	eventLog(payload, "qq", data.Author.UserOpenID).Infof("收到来自用户 %s 的私聊消息: %s", data.Author.UserOpenID, msgContent)
 }
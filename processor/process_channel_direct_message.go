@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/operation"
 
 	"github.com/satori-protocol-go/satori-model-go/pkg/channel"
@@ -19,7 +18,7 @@ import (
 // ProcessChannelDirectMessage 处理频道私聊消息
 func (p *Processor) ProcessChannelDirectMessage(payload *dto.Payload, data *dto.DirectMessageData) error {
 	// 打印消息日志
-	printChannelDirectMessage(data)
+	printChannelDirectMessage(payload, data)
 
 	// 构建事件数据
 	var event *operation.Event
@@ -102,7 +101,7 @@ func (p *Processor) ProcessChannelDirectMessage(payload *dto.Payload, data *dto.
 	return p.BroadcastEvent(event)
 }
 
-func printChannelDirectMessage(data *dto.DirectMessageData) {
+func printChannelDirectMessage(payload *dto.Payload, data *dto.DirectMessageData) {
 	// 构建用户名称
 	var userName string
 	if data.Member.Nick != "" {
@@ -117,5 +116,5 @@ func printChannelDirectMessage(data *dto.DirectMessageData) {
 	msgContent := getMessageLog(data)
 
 	// 打印消息
-	log.Infof("收到来自用户 %s 的私聊频道消息: %s", userName, msgContent)
+	eventLog(payload, "qqguild", data.ChannelID).Infof("收到来自用户 %s 的私聊频道消息: %s", userName, msgContent)
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/operation"
 
 	"github.com/tencent-connect/botgo/dto"
@@ -62,7 +61,7 @@ func printChannelEvent(payload *dto.Payload, data *dto.ChannelData) {
 		logContent = "未知的子频道事件: " + string(payload.Type)
 	}
 
-	log.Info(logContent)
+	eventLog(payload, "qqguild", data.ID).Info(logContent)
 }
 
 func channelTypeToString(channelType dto.ChannelType) string {
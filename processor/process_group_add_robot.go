@@ -1,7 +1,6 @@
 package processor
 
 import (
-	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/operation"
 
 	"github.com/satori-protocol-go/satori-model-go/pkg/channel"
@@ -14,7 +13,7 @@ import (
 // ProcessGroupAddRobot 处理群组添加机器人
 func (p *Processor) ProcessGroupAddRobot(payload *dto.Payload, data *dto.GroupAddBotEvent) error {
 	// 输出日志
-	printGroupAddRobot(data)
+	printGroupAddRobot(payload, data)
 
 	// 构建事件数据
 	var event *operation.Event
@@ -28,6 +27,7 @@ func (p *Processor) ProcessGroupAddRobot(payload *dto.Payload, data *dto.GroupAd
 		Type: channel.ChannelTypeText,
 	}
 	SetOpenIdType(data.GroupOpenID, "group")
+	SetPassiveEvent(data.GroupOpenID, payload.ID, EventPassiveWindow)
 
 	// 构建 guild
 	guild := &guild.Guild{
@@ -58,6 +58,6 @@ func (p *Processor) ProcessGroupAddRobot(payload *dto.Payload, data *dto.GroupAd
 	return p.BroadcastEvent(event)
 }
 
-func printGroupAddRobot(data *dto.GroupAddBotEvent) {
-	log.Infof("机器人被 %s 添加进了群组 %s", data.OpMemberOpenID, data.GroupOpenID)
+func printGroupAddRobot(payload *dto.Payload, data *dto.GroupAddBotEvent) {
+	eventLog(payload, "qq", data.GroupOpenID).Infof("机器人被 %s 添加进了群组 %s", data.OpMemberOpenID, data.GroupOpenID)
 }
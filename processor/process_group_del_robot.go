@@ -1,7 +1,6 @@
 package processor
 
 import (
-	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/operation"
 
 	"github.com/satori-protocol-go/satori-model-go/pkg/channel"
@@ -14,7 +13,7 @@ import (
 // ProcessGroupDelRobot 处理群组删除机器人
 func (p *Processor) ProcessGroupDelRobot(payload *dto.Payload, data *dto.GroupAddBotEvent) error {
 	// 输出日志
-	printGroupDelRobot(data)
+	printGroupDelRobot(payload, data)
 
 	// 构建事件数据
 	var event *operation.Event
@@ -58,6 +57,6 @@ func (p *Processor) ProcessGroupDelRobot(payload *dto.Payload, data *dto.GroupAd
 	return p.BroadcastEvent(event)
 }
 
-func printGroupDelRobot(data *dto.GroupAddBotEvent) {
-	log.Infof("机器人被 %s 移出了群组 %s", data.OpMemberOpenID, data.GroupOpenID)
+func printGroupDelRobot(payload *dto.Payload, data *dto.GroupAddBotEvent) {
+	eventLog(payload, "qq", data.GroupOpenID).Infof("机器人被 %s 移出了群组 %s", data.OpMemberOpenID, data.GroupOpenID)
 }
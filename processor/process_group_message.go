@@ -19,7 +19,7 @@ import (
 // ProcessGroupMessage 处理群组消息
 func (p *Processor) ProcessGroupMessage(payload *dto.Payload, data *dto.GroupATMessageData) error {
 	// 打印消息日志
-	printGroupMessage(data)
+	printGroupMessage(payload, data)
 
 	// 构建事件数据
 	var event *operation.Event
@@ -39,6 +39,7 @@ func (p *Processor) ProcessGroupMessage(payload *dto.Payload, data *dto.GroupATM
 		Type: channel.ChannelTypeText,
 	}
 	SetOpenIdType(data.GroupID, "group")
+	SetPassiveMessage(data.GroupID, data.ID, GroupPassiveWindow)
 
 	// 构建 guild
 	guild := &guild.Guild{
@@ -82,13 +83,18 @@ func (p *Processor) ProcessGroupMessage(payload *dto.Payload, data *dto.GroupATM
 	messageToSave.User = user
 	database.SaveMessage(messageToSave, data.GroupID, "group")
 
+	// 记录群成员
+	if err := database.ObserveMember(data.GroupID, user.Id, user.Avatar, t.UnixMilli()); err != nil {
+		log.Warnf("记录群 %s 成员 %s 时出错: %v", data.GroupID, user.Id, err)
+	}
+
 	// 上报消息到 Satori 应用
 	return p.BroadcastEvent(event)
 }
 
-func printGroupMessage(data *dto.GroupATMessageData) {
+func printGroupMessage(payload *dto.Payload, data *dto.GroupATMessageData) {
 	// 构建消息日志
 	msgContent := getMessageLog(data)
 
-	log.Infof("收到来自群 %s 用户 %s 的消息: %s", data.GroupID, data.Author.MemberOpenID, msgContent)
+	eventLog(payload, "qq", data.GroupID).Infof("收到来自群 %s 用户 %s 的消息: %s", data.GroupID, data.Author.MemberOpenID, msgContent)
 }
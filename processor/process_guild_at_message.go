@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/operation"
 
 	"github.com/satori-protocol-go/satori-model-go/pkg/channel"
@@ -19,7 +18,7 @@ import (
 // ProcessGuildATMessage 处理群组 AT 消息
 func (p *Processor) ProcessGuildATMessage(payload *dto.Payload, data *dto.ATMessageData) error {
 	// 打印消息日志
-	printGuildATMessage(data)
+	printGuildATMessage(payload, data)
 
 	// 构建事件数据
 	var event *operation.Event
@@ -100,7 +99,7 @@ func (p *Processor) ProcessGuildATMessage(payload *dto.Payload, data *dto.ATMess
 	return p.BroadcastEvent(event)
 }
 
-func printGuildATMessage(data *dto.ATMessageData) {
+func printGuildATMessage(payload *dto.Payload, data *dto.ATMessageData) {
 	// 构建用户名称
 	var userName string
 	if data.Member.Nick != "" {
@@ -114,5 +113,5 @@ func printGuildATMessage(data *dto.ATMessageData) {
 	// 构建消息日志
 	msgContent := getMessageLog(data)
 
-	log.Infof("收到来自频道 %s 的子频道 %s 的用户 %s 的消息: %s", data.GuildID, data.ChannelID, userName, msgContent)
+	eventLog(payload, "qqguild", data.ChannelID).Infof("收到来自频道 %s 的子频道 %s 的用户 %s 的消息: %s", data.GuildID, data.ChannelID, userName, msgContent)
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/operation"
 
 	"github.com/satori-protocol-go/satori-model-go/pkg/guild"
@@ -100,5 +99,5 @@ func printGuildEvent(payload *dto.Payload, data *dto.GuildData) {
 	}
 
 	// 打印日志
-	log.Info(logContent)
+	eventLog(payload, "qqguild", "").Info(logContent)
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/operation"
 
 	"github.com/satori-protocol-go/satori-model-go/pkg/channel"
@@ -19,7 +18,7 @@ import (
 // ProcessGuildNormalMessage 处理群组私域消息
 func (p *Processor) ProcessGuildNormalMessage(payload *dto.Payload, data *dto.MessageData) error {
 	// 打印消息日志
-	printGuildMessage(data)
+	printGuildMessage(payload, data)
 
 	// 构建事件数据
 	var event *operation.Event
@@ -100,7 +99,7 @@ func (p *Processor) ProcessGuildNormalMessage(payload *dto.Payload, data *dto.Me
 	return p.BroadcastEvent(event)
 }
 
-func printGuildMessage(data *dto.MessageData) {
+func printGuildMessage(payload *dto.Payload, data *dto.MessageData) {
 	// 构建用户名称
 	var userName string
 	if data.Member.Nick != "" {
@@ -114,5 +113,5 @@ func printGuildMessage(data *dto.MessageData) {
 	// 构建消息日志
 	msgContent := getMessageLog(data)
 
-	log.Infof("收到来自频道 %s 的子频道 %s 的用户 %s 的消息: %s", data.GuildID, data.ChannelID, userName, msgContent)
+	eventLog(payload, "qqguild", data.ChannelID).Infof("收到来自频道 %s 的子频道 %s 的用户 %s 的消息: %s", data.GuildID, data.ChannelID, userName, msgContent)
 }
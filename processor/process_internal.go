@@ -97,6 +97,14 @@ func (p *Processor) ProcessInteractionEvent(data *dto.InteractionEventData) erro
 		platform = "qq"
 	}
 
+	// 群聊与单聊中的互动事件可以用于被动回复
+	switch data.ChatType {
+	case 1:
+		SetPassiveEvent(data.GroupOpenID, data.ID, EventPassiveWindow)
+	case 2:
+		SetPassiveEvent(data.UserOpenID, data.ID, EventPassiveWindow)
+	}
+
 	event = &operation.Event{
 		Sn:        id,
 		Type:      operation.EventTypeInternal,
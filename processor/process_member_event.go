@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/operation"
 
 	"github.com/satori-protocol-go/satori-model-go/pkg/guild"
@@ -134,5 +133,5 @@ func printMemberEvent(payload *dto.Payload, data *dto.GuildMemberData) {
 	}
 
 	// 打印日志
-	log.Info(logContent)
+	eventLog(payload, "qqguild", "").Info(logContent)
 }
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/operation"
 
 	"github.com/satori-protocol-go/satori-model-go/pkg/channel"
@@ -144,5 +143,5 @@ func printMessageDeleteEvent(payload *dto.Payload, data *dto.MessageDelete) {
 	}
 
 	// 打印日志
-	log.Info(logContent)
+	eventLog(payload, "qqguild", data.Message.ChannelID).Info(logContent)
 }
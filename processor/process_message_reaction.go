@@ -5,7 +5,6 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/operation"
 
 	"github.com/satori-protocol-go/satori-model-go/pkg/channel"
@@ -112,7 +111,7 @@ func printMessageReaction(payload *dto.Payload, data *dto.MessageReactionData) {
 		logContent = fmt.Sprintf("频道 %s 的子频道 %s 的用户 %s 对 %s 发生了表态事件: %s", data.GuildID, data.ChannelID, data.UserID, targetName, emojiName)
 	}
 
-	log.Info(logContent)
+	eventLog(payload, "qqguild", data.ChannelID).Info(logContent)
 }
 
 func targetTypeToString(targetType dto.ReactionTargetType) string {
@@ -2,12 +2,15 @@ package processor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/satori-protocol-go/satori-model-go/pkg/login"
 	"github.com/satori-protocol-go/satori-model-go/pkg/user"
+	"github.com/tencent-connect/botgo"
 	"github.com/tencent-connect/botgo/dto"
 	"github.com/tencent-connect/botgo/openapi"
 	"github.com/tencent-connect/botgo/token"
@@ -48,6 +51,7 @@ type BotMapping struct {
 // StatusMapping 机器人状态映射
 type StatusMapping struct {
 	mapping map[string]login.LoginStatus
+	since   map[string]time.Time // 状态开始的时间
 	mu      sync.Mutex
 }
 
@@ -59,6 +63,7 @@ var globalBotMapping = &BotMapping{
 
 var globalStatusMapping = &StatusMapping{
 	mapping: make(map[string]login.LoginStatus),
+	since:   make(map[string]time.Time),
 }
 
 // SetBot 设置机器人
@@ -86,6 +91,9 @@ func GetBots() map[string]*user.User {
 func SetStatus(platform string, status login.LoginStatus) {
 	globalStatusMapping.mu.Lock()
 	defer globalStatusMapping.mu.Unlock()
+	if current, ok := globalStatusMapping.mapping[platform]; !ok || current != status {
+		globalStatusMapping.since[platform] = time.Now()
+	}
 	globalStatusMapping.mapping[platform] = status
 }
 
@@ -96,6 +104,13 @@ func GetStatus(platform string) login.LoginStatus {
 	return globalStatusMapping.mapping[platform]
 }
 
+// GetStatusSince 获取机器人状态与该状态开始的时间
+func GetStatusSince(platform string) (login.LoginStatus, time.Time) {
+	globalStatusMapping.mu.Lock()
+	defer globalStatusMapping.mu.Unlock()
+	return globalStatusMapping.mapping[platform], globalStatusMapping.since[platform]
+}
+
 // GetReadyBody 创建 READY 信令的信令数据
 func GetReadyBody() *operation.ReadyBody {
 	var logins []*login.Login
@@ -187,7 +202,10 @@ func Features() []string {
 
 // 获取代理路径
 func ProxyUrls() []string {
-	return []string{}
+	urls := config.GetProxyConfig().URLs
+	result := make([]string, len(urls))
+	copy(result, urls)
+	return result
 }
 
 // Server 服务端接口
@@ -205,6 +223,10 @@ type Processor struct {
 	Token  *token.Token
 	Server Server
 	conf   *config.Config
+
+	sessionManager botgo.SessionManager // QQ 开放平台 WebSocket 连接管理器
+	webhookManager botgo.WebhookManager // QQ 开放平台 WebHook 服务器管理器
+	stopping       atomic.Bool          // 是否正在停止
 }
 
 // NewProcessor 创建消息处理器
@@ -281,6 +303,35 @@ func (p *Processor) Run(ctx context.Context, server Server) error {
 	return nil
 }
 
+// Stop 停止接收 QQ 开放平台的事件，并通知 Satori 应用机器人已离线，重复调用无效
+func (p *Processor) Stop() error {
+	if !p.stopping.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	var errs []error
+	for _, manager := range []any{p.sessionManager, p.webhookManager} {
+		if stopper, ok := manager.(botgo.Stopper); ok {
+			if err := stopper.Stop(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if p.Server != nil {
+		for _, platform := range []string{"qq", "qqguild"} {
+			SetStatus(platform, login.StatusOffline)
+			p.BroadcastEvent(&operation.Event{
+				Sn:        SaveEventID(""),
+				Type:      operation.EventTypeLoginUpdated,
+				Timestamp: time.Now().UnixMilli(),
+				Login:     buildLoginEventLogin(platform),
+			})
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // BroadcastEvent 向 Satori 应用发送事件
 func (p *Processor) BroadcastEvent(event *operation.Event) error {
 	p.Server.Send(event)
@@ -292,3 +343,15 @@ func (p *Processor) getUserAvatar(userId string) string {
 	url := fmt.Sprintf("https://q.qlogo.cn/qqapp/%v/%s/3", p.conf.Account.AppID, userId)
 	return url
 }
+
+// eventLog 创建带有平台、频道与事件类型字段的日志记录器
+func eventLog(payload *dto.Payload, platform, channelId string) *log.Entry {
+	fields := log.Fields{
+		log.FieldPlatform:  platform,
+		log.FieldEventType: string(payload.Type),
+	}
+	if channelId != "" {
+		fields[log.FieldChannel] = channelId
+	}
+	return log.WithFields(fields)
+}
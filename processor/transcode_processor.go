@@ -0,0 +1,34 @@
+package processor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/config"
+	"github.com/WindowsSov8forUs/glyccat/pkg/transcode"
+)
+
+// transcodeCachePath 转码结果缓存目录
+const transcodeCachePath = "data/cache/transcode"
+
+var (
+	transcoder     *transcode.Service
+	transcoderOnce sync.Once
+)
+
+// getTranscoder 获取转码服务，第一次调用时根据配置创建
+func getTranscoder() *transcode.Service {
+	transcoderOnce.Do(func() {
+		conf := config.GetTranscodeConfig()
+		options := transcode.Options{
+			Workers: conf.Workers,
+			Timeout: time.Duration(conf.Timeout) * time.Second,
+		}
+		if conf.Cache {
+			options.CacheDir = transcodeCachePath
+			options.CacheTTL = time.Duration(conf.CacheTTL) * time.Second
+		}
+		transcoder = transcode.New(options)
+	})
+	return transcoder
+}
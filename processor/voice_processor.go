@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/config"
+	"github.com/WindowsSov8forUs/glyccat/fileserver"
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/WindowsSov8forUs/glyccat/pkg/silk"
+	satoriMessage "github.com/satori-protocol-go/satori-model-go/pkg/message"
+)
+
+const (
+	voiceDecodeTimeout = 3 * time.Second  // 下载并解码一条消息中所有语音的最长时间，解码在事件处理中进行，超时后保留原始链接
+	voiceMaxSize       = 20 * 1024 * 1024 // 语音文件大小上限
+	voiceOriginSrcAttr = "origin-src"     // 保存原始语音链接的属性
+)
+
+// voiceMimeTypes 解码格式对应的资源类型
+var voiceMimeTypes = map[string]string{
+	silk.FormatWAV: "audio/wav",
+	silk.FormatOGG: "audio/ogg",
+}
+
+// voiceClient 下载语音使用的客户端
+var voiceClient = &http.Client{
+	Timeout: voiceDecodeTimeout,
+}
+
+// decodeVoices 并发解码一条消息中的所有语音，成功时替换链接并将原始链接保存在属性中
+//
+// 解码会阻塞事件的分发，因此所有语音共用 voiceDecodeTimeout 的期限
+func decodeVoices(audios []*satoriMessage.MessageElementAudio, platform string) {
+	if len(audios) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), voiceDecodeTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, audio := range audios {
+		wg.Add(1)
+		go func(audio *satoriMessage.MessageElementAudio) {
+			defer wg.Done()
+			src, err := decodeVoice(ctx, audio.Src, platform)
+			if err != nil {
+				log.Warnf("解码语音 %s 失败: %v", audio.Src, err)
+				return
+			}
+			audio.ExtendAttributes = audio.ExtendAttributes.AddAttribute(voiceOriginSrcAttr, audio.Src)
+			audio.Src = src
+		}(audio)
+	}
+	wg.Wait()
+}
+
+// decodeVoice 下载并解码收到的语音，返回保存至本地文件服务器后的链接
+func decodeVoice(ctx context.Context, src, platform string) (string, error) {
+	if !fileserver.Available() {
+		return "", fmt.Errorf("本地文件服务器未启用")
+	}
+
+	format := config.GetAudioConfig().Format
+	mimeType, ok := voiceMimeTypes[format]
+	if !ok {
+		return "", fmt.Errorf("不支持的语音解码格式: %s", format)
+	}
+
+	// 确定文件归属的机器人
+	bot := GetBot(platform)
+	if bot == nil {
+		for p, b := range GetBots() {
+			platform, bot = p, b
+			break
+		}
+	}
+	if bot == nil {
+		return "", fmt.Errorf("没有可用的机器人")
+	}
+
+	// 下载语音
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return "", fmt.Errorf("下载语音失败: %w", err)
+	}
+	response, err := voiceClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("下载语音失败: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("下载语音失败: %s", response.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(response.Body, voiceMaxSize+1))
+	if err != nil {
+		return "", fmt.Errorf("下载语音失败: %w", err)
+	}
+	if len(data) > voiceMaxSize {
+		return "", fmt.Errorf("语音文件超过大小上限 %d", voiceMaxSize)
+	}
+
+	// 解码并保存
+	decoded, err := getTranscoder().Do(ctx, "voice:"+format, data, func(ctx context.Context, data []byte) ([]byte, error) {
+		return silk.DecoderSilk(ctx, data, format)
+	})
+	if err != nil {
+		return "", fmt.Errorf("解码语音失败: %w", err)
+	}
+	meta, err := fileserver.SaveFile(bytes.NewReader(decoded), platform, bot.Id, "voice."+format, mimeType)
+	if err != nil {
+		return "", fmt.Errorf("保存语音失败: %w", err)
+	}
+
+	return fileserver.InternalURL(meta), nil
+}
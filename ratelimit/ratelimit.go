@@ -0,0 +1,333 @@
+// Package ratelimit 限制向 QQ 开放平台发起 OpenAPI 请求的频率
+//
+// 每个接口分组有一个共享的令牌桶，分组内的每个目标（频道、私信、群聊、用户或频道服务器）另有独立的令牌桶，
+// 请求需要同时从两者取得令牌。取不到令牌时按照配置排队等待，或直接返回 *Error
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/WindowsSov8forUs/glyccat/metrics"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+// Group 接口分组
+type Group string
+
+const (
+	GroupMessage Group = "message" // 发送消息
+	GroupManage  Group = "manage"  // 除发送消息以外的写操作
+	GroupQuery   Group = "query"   // 查询
+)
+
+// Rule 令牌桶规则，Rate 为 0 时不限制
+type Rule struct {
+	Rate  float64 // 每秒补充的令牌数
+	Burst int     // 令牌桶容量，小于 1 时按 1 处理
+}
+
+// GroupRule 接口分组的令牌桶规则
+type GroupRule struct {
+	Group  Rule // 分组内所有请求共享的令牌桶
+	Target Rule // 分组内每个目标独立的令牌桶
+}
+
+// Options 限流选项
+type Options struct {
+	Enable       bool                // 是否启用限流
+	Queue        bool                // 取不到令牌时是否排队等待
+	QueueSize    int                 // 每个令牌桶最多排队的请求数，为 0 时不限制
+	QueueTimeout time.Duration       // 最长排队时间，预计等待时间超出时直接拒绝
+	Rules        map[Group]GroupRule // 各个分组的令牌桶规则
+}
+
+// Error 请求超出频率限制
+type Error struct {
+	Group      Group         // 接口分组
+	Target     string        // 目标，分组共享的令牌桶耗尽时为空
+	RetryAfter time.Duration // 建议的重试等待时间
+	QueueFull  bool          // 是否由于排队已满被拒绝
+}
+
+func (e *Error) Error() string {
+	scope := string(e.Group)
+	if e.Target != "" {
+		scope += " " + e.Target
+	}
+	if e.QueueFull {
+		return fmt.Sprintf("rate limit queue for %s is full, retry after %v", scope, e.RetryAfter)
+	}
+	return fmt.Sprintf("rate limit exceeded for %s, retry after %v", scope, e.RetryAfter)
+}
+
+// bucket 令牌桶
+type bucket struct {
+	rate    float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+	waiting int // 正在排队的请求数
+}
+
+// newBucket 创建装满令牌的令牌桶
+func newBucket(rule Rule, now time.Time) *bucket {
+	burst := float64(rule.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{
+		rate:   rule.Rate,
+		burst:  burst,
+		tokens: burst,
+		last:   now,
+	}
+}
+
+// advance 补充从上次补充到 now 之间的令牌
+func (b *bucket) advance(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// reserve 取出一个令牌，返回令牌可用前需要等待的时间
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.advance(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// release 归还取出的令牌
+func (b *bucket) release() {
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+// idle 令牌桶是否已经装满且没有请求排队，此时可以删除
+func (b *bucket) idle(now time.Time) bool {
+	b.advance(now)
+	return b.waiting == 0 && b.tokens >= b.burst
+}
+
+// pruneEvery 每处理该数量的请求清理一次空闲的令牌桶
+const pruneEvery = 1024
+
+// Limiter 限流器
+type Limiter struct {
+	mu      sync.Mutex
+	opts    Options
+	buckets map[string]*bucket
+	calls   int64
+}
+
+// New 创建限流器
+func New(opts Options) *Limiter {
+	return &Limiter{
+		opts:    opts,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Configure 更新限流选项，已有的令牌桶会被重置，正在排队的请求不受影响
+func (l *Limiter) Configure(opts Options) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.opts = opts
+	l.buckets = make(map[string]*bucket)
+}
+
+// Wait 等待分组与目标的令牌桶中都有可用的令牌
+//
+// 未启用排队、排队已满或预计等待时间超出最长排队时间时返回 *Error ，
+// 排队期间 ctx 结束时返回 ctx 的错误
+func (l *Limiter) Wait(ctx context.Context, group Group, target string) error {
+	l.mu.Lock()
+	if !l.opts.Enable {
+		l.mu.Unlock()
+		return nil
+	}
+
+	now := time.Now()
+	l.calls++
+	if l.calls%pruneEvery == 0 {
+		l.prune(now)
+	}
+
+	buckets, targets := l.bucketsFor(group, target, now)
+	var delay time.Duration
+	var limitedTarget string
+	for i, b := range buckets {
+		if d := b.reserve(now); d > delay {
+			delay, limitedTarget = d, targets[i]
+		}
+	}
+	if delay == 0 {
+		l.mu.Unlock()
+		return nil
+	}
+
+	queueFull := false
+	for _, b := range buckets {
+		if l.opts.QueueSize > 0 && b.waiting >= l.opts.QueueSize {
+			queueFull = true
+		}
+	}
+	if !l.opts.Queue || queueFull || delay > l.opts.QueueTimeout {
+		for _, b := range buckets {
+			b.release()
+		}
+		l.mu.Unlock()
+		metrics.OpenAPIRateLimited.WithLabelValues(string(group), "rejected").Inc()
+		return &Error{
+			Group:      group,
+			Target:     limitedTarget,
+			RetryAfter: delay,
+			QueueFull:  queueFull,
+		}
+	}
+
+	for _, b := range buckets {
+		b.waiting++
+	}
+	l.mu.Unlock()
+	metrics.OpenAPIRateLimited.WithLabelValues(string(group), "queued").Inc()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, b := range buckets {
+		b.waiting--
+		if err != nil {
+			b.release()
+		}
+	}
+	return err
+}
+
+// bucketsFor 获取请求需要取得令牌的令牌桶以及对应的目标，不限制的令牌桶不会返回
+func (l *Limiter) bucketsFor(group Group, target string, now time.Time) ([]*bucket, []string) {
+	rule, ok := l.opts.Rules[group]
+	if !ok {
+		return nil, nil
+	}
+
+	var buckets []*bucket
+	var targets []string
+	if rule.Group.Rate > 0 {
+		buckets = append(buckets, l.bucket(string(group), rule.Group, now))
+		targets = append(targets, "")
+	}
+	if rule.Target.Rate > 0 && target != "" {
+		buckets = append(buckets, l.bucket(string(group)+" "+target, rule.Target, now))
+		targets = append(targets, target)
+	}
+	return buckets, targets
+}
+
+// bucket 获取令牌桶，不存在时创建
+func (l *Limiter) bucket(key string, rule Rule, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = newBucket(rule, now)
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// prune 删除空闲的令牌桶，避免目标过多时占用内存
+func (l *Limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.idle(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Classify 根据 OpenAPI 请求的方法与路径获取接口分组与目标
+//
+// 目标为路径中的资源类型与 ID ，如 /v2/groups/ABCD/messages 的目标为 groups/ABCD 。
+// 获取网关与 WebHook 会话的请求不进行限制，此时 ok 为 false
+func Classify(method, path string) (group Group, target string, ok bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) > 0 && segments[0] == "v2" {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 || segments[0] == "gateway" {
+		return "", "", false
+	}
+
+	switch segments[0] {
+	case "channels", "dms", "groups", "users", "guilds":
+		if len(segments) >= 2 {
+			target = segments[0] + "/" + segments[1]
+		}
+	}
+
+	switch {
+	case method == http.MethodGet:
+		group = GroupQuery
+	case method == http.MethodPost && len(segments) == 3 && (segments[2] == "messages" || segments[2] == "threads"):
+		group = GroupMessage
+	default:
+		group = GroupManage
+	}
+	return group, target, true
+}
+
+// limiter 默认限流器，未配置时不进行限制
+var limiter = New(Options{})
+
+// Configure 更新默认限流器的选项
+func Configure(opts Options) {
+	limiter.Configure(opts)
+	if opts.Enable {
+		log.Debugf("OpenAPI 限流已启用，排队: %t ，最长排队时间: %v", opts.Queue, opts.QueueTimeout)
+	}
+}
+
+const filterName = "glyccat_rate_limit"
+
+var registerOnce sync.Once
+
+// RegisterBotgoFilters 注册 OpenAPI 请求过滤器，在请求发出前使用默认限流器等待令牌，重复调用无效
+//
+// 应在其他请求过滤器之前注册，使排队时间不计入请求耗时
+func RegisterBotgoFilters() {
+	registerOnce.Do(func() {
+		openapi.RegisterReqFilter(filterName, waitForToken)
+	})
+}
+
+// waitForToken 等待 OpenAPI 请求的令牌
+func waitForToken(req *http.Request, _ *http.Response) error {
+	if req == nil {
+		return nil
+	}
+	group, target, ok := Classify(req.Method, req.URL.Path)
+	if !ok {
+		return nil
+	}
+	if err := limiter.Wait(req.Context(), group, target); err != nil {
+		log.Debugf("OpenAPI 请求 %s %s 被限流: %v", req.Method, req.URL.Path, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBucketReserve(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBucket(Rule{Rate: 2, Burst: 2}, now)
+
+	tests := []struct {
+		elapsed time.Duration
+		want    time.Duration
+	}{
+		{0, 0},
+		{0, 0},
+		{0, 500 * time.Millisecond},
+		{0, time.Second},
+		// 1 秒后补充 2 个令牌，仍有 1 个令牌的欠额
+		{time.Second, 500 * time.Millisecond},
+	}
+
+	for i, tt := range tests {
+		now = now.Add(tt.elapsed)
+		if got := b.reserve(now); got != tt.want {
+			t.Errorf("reserve() #%d = %v, want %v", i, got, tt.want)
+		}
+	}
+}
+
+func TestBucketBurstAtLeastOne(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBucket(Rule{Rate: 1}, now)
+	if got := b.reserve(now); got != 0 {
+		t.Errorf("reserve() = %v, want 0", got)
+	}
+	if got := b.reserve(now); got != time.Second {
+		t.Errorf("reserve() = %v, want %v", got, time.Second)
+	}
+	b.release()
+	if got := b.reserve(now.Add(time.Hour)); got != 0 {
+		t.Errorf("reserve() after refill = %v, want 0", got)
+	}
+}
+
+// newTestLimiter 创建只限制发送消息分组的限流器
+func newTestLimiter(queue bool, queueSize int, queueTimeout time.Duration) *Limiter {
+	return New(Options{
+		Enable:       true,
+		Queue:        queue,
+		QueueSize:    queueSize,
+		QueueTimeout: queueTimeout,
+		Rules: map[Group]GroupRule{
+			GroupMessage: {
+				Group:  Rule{Rate: 1, Burst: 1},
+				Target: Rule{Rate: 0},
+			},
+		},
+	})
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	l := New(Options{Rules: map[Group]GroupRule{GroupMessage: {Group: Rule{Rate: 1}}}})
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background(), GroupMessage, ""); err != nil {
+			t.Fatalf("Wait() #%d error = %v", i, err)
+		}
+	}
+}
+
+func TestLimiterUnlimitedGroup(t *testing.T) {
+	l := newTestLimiter(false, 0, 0)
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background(), GroupQuery, "channels/1"); err != nil {
+			t.Fatalf("Wait() #%d error = %v", i, err)
+		}
+	}
+}
+
+func TestLimiterRejectWithoutQueue(t *testing.T) {
+	l := newTestLimiter(false, 0, time.Minute)
+	if err := l.Wait(context.Background(), GroupMessage, ""); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	err := l.Wait(context.Background(), GroupMessage, "")
+	var limitErr *Error
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Wait() error = %v, want *Error", err)
+	}
+	if limitErr.QueueFull || limitErr.RetryAfter <= 0 || limitErr.RetryAfter > time.Second {
+		t.Errorf("Wait() error = %+v", limitErr)
+	}
+}
+
+func TestLimiterQueueTimeout(t *testing.T) {
+	l := newTestLimiter(true, 0, 100*time.Millisecond)
+	if err := l.Wait(context.Background(), GroupMessage, ""); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	start := time.Now()
+	err := l.Wait(context.Background(), GroupMessage, "")
+	var limitErr *Error
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Wait() error = %v, want *Error", err)
+	}
+	if limitErr.QueueFull {
+		t.Error("QueueFull = true, want false")
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Wait() took %v, want immediate rejection", elapsed)
+	}
+
+	// 被拒绝的请求会归还令牌
+	l.mu.Lock()
+	tokens := l.buckets[string(GroupMessage)].tokens
+	l.mu.Unlock()
+	if tokens < -0.01 {
+		t.Errorf("tokens = %g after rejection, want about 0", tokens)
+	}
+}
+
+func TestLimiterQueueFull(t *testing.T) {
+	l := newTestLimiter(true, 1, time.Minute)
+	if err := l.Wait(context.Background(), GroupMessage, ""); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queued := make(chan error, 1)
+	go func() {
+		queued <- l.Wait(ctx, GroupMessage, "")
+	}()
+
+	// 等待第二个请求开始排队
+	deadline := time.Now().Add(time.Second)
+	for {
+		l.mu.Lock()
+		b := l.buckets[string(GroupMessage)]
+		waiting := b != nil && b.waiting == 1
+		l.mu.Unlock()
+		if waiting {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("second request is not queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	err := l.Wait(context.Background(), GroupMessage, "")
+	var limitErr *Error
+	if !errors.As(err, &limitErr) || !limitErr.QueueFull {
+		t.Fatalf("Wait() error = %v, want queue full *Error", err)
+	}
+
+	cancel()
+	if err := <-queued; !errors.Is(err, context.Canceled) {
+		t.Errorf("queued Wait() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		group  Group
+		target string
+		ok     bool
+	}{
+		{http.MethodPost, "/v2/groups/ABCD/messages", GroupMessage, "groups/ABCD", true},
+		{http.MethodPost, "/v2/users/ABCD/messages", GroupMessage, "users/ABCD", true},
+		{http.MethodPost, "/channels/123/messages", GroupMessage, "channels/123", true},
+		{http.MethodPost, "/dms/456/messages", GroupMessage, "dms/456", true},
+		{http.MethodPut, "/channels/123/threads", GroupManage, "channels/123", true},
+		{http.MethodPost, "/channels/123/threads", GroupMessage, "channels/123", true},
+		{http.MethodPost, "/v2/groups/ABCD/files", GroupManage, "groups/ABCD", true},
+		{http.MethodDelete, "/channels/123/messages/789", GroupManage, "channels/123", true},
+		{http.MethodPatch, "/guilds/1/members/2/mute", GroupManage, "guilds/1", true},
+		{http.MethodGet, "/guilds/1/channels", GroupQuery, "guilds/1", true},
+		{http.MethodGet, "/users/@me", GroupQuery, "users/@me", true},
+		{http.MethodGet, "/users", GroupQuery, "", true},
+		{http.MethodGet, "/gateway/bot", "", "", false},
+	}
+
+	for _, tt := range tests {
+		group, target, ok := Classify(tt.method, tt.path)
+		if group != tt.group || target != tt.target || ok != tt.ok {
+			t.Errorf("Classify(%q, %q) = (%q, %q, %v), want (%q, %q, %v)",
+				tt.method, tt.path, group, target, ok, tt.group, tt.target, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package main
+
+import "os"
+
+// notifyReload Windows 不支持 SIGHUP ，只能通过 API 重新加载配置
+func notifyReload(sigCh chan os.Signal) {}
+
+// isReloadSignal 判断是否为重新加载配置的信号
+func isReloadSignal(sig os.Signal) bool {
+	return false
+}
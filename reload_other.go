@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReload 监听 SIGHUP 信号用于重新加载配置
+func notifyReload(sigCh chan os.Signal) {
+	signal.Notify(sigCh, syscall.SIGHUP)
+}
+
+// isReloadSignal 判断是否为重新加载配置的信号
+func isReloadSignal(sig os.Signal) bool {
+	return sig == syscall.SIGHUP
+}
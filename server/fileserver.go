@@ -0,0 +1,162 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/WindowsSov8forUs/glyccat/config"
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/WindowsSov8forUs/glyccat/server/httpapi"
+)
+
+// certCheckInterval 证书文件变更的检查间隔
+const certCheckInterval = 30 * time.Second
+
+// certReloader 在证书文件变更时自动重新加载的 TLS 证书
+type certReloader struct {
+	certFile  string           // 证书文件路径
+	keyFile   string           // 私钥文件路径
+	cert      *tls.Certificate // 当前证书
+	modTime   time.Time        // 当前证书文件的修改时间
+	lastCheck time.Time        // 上次检查文件变更的时间
+	mu        sync.Mutex
+}
+
+// newCertReloader 加载证书并创建证书重载器
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	reloader := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// latestModTime 获取证书与私钥文件中较新的修改时间
+func (r *certReloader) latestModTime() (time.Time, error) {
+	certStat, err := os.Stat(r.certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	keyStat, err := os.Stat(r.keyFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if keyStat.ModTime().After(certStat.ModTime()) {
+		return keyStat.ModTime(), nil
+	}
+	return certStat.ModTime(), nil
+}
+
+// reload 重新加载证书，调用前需要持有锁或处于初始化阶段
+func (r *certReloader) reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// GetCertificate 获取证书，文件发生变更时重新加载
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.lastCheck) >= certCheckInterval {
+		r.lastCheck = time.Now()
+		if modTime, err := r.latestModTime(); err == nil && modTime.After(r.modTime) {
+			// 加载失败时继续使用旧证书
+			if err := r.reload(); err != nil {
+				log.Warnf("重新加载文件服务器 TLS 证书失败: %v", err)
+			} else {
+				log.Info("文件服务器 TLS 证书已重新加载")
+			}
+		}
+	}
+	return r.cert, nil
+}
+
+// setupFileEngine 创建只提供文件的路由，不挂载 Satori API 路由
+func (server *Server) setupFileEngine() *gin.Engine {
+	engine := gin.New()
+	engine.Use(
+		gin.Recovery(),
+	)
+
+	proxyGroup := engine.Group(fmt.Sprintf("%s/v1/proxy", server.conf.Satori.Path))
+	proxyGroup.Use(
+		httpapi.ProxyValidateMiddleware(),
+	)
+	proxyGroup.GET("/*url", func(c *gin.Context) {
+		url := c.Param("url")
+		// 去除开头斜线
+		url = strings.TrimPrefix(url, "/")
+
+		// 只提供本地文件服务器中的文件
+		if !strings.HasPrefix(url, "internal:") {
+			c.String(http.StatusNotFound, "not found")
+			return
+		}
+
+		log.Tracef(
+			"收到文件请求: %s /proxy/%s ，请求头：%v",
+			c.Request.Method,
+			url,
+			c.Request.Header,
+		)
+		httpapi.ProxyMiddleware(satoriVersion)(c)
+	})
+
+	return engine
+}
+
+// newFileHTTPServer 创建独立的文件服务器
+func (server *Server) newFileHTTPServer(conf config.FileServerListener) (*http.Server, error) {
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf("%s:%d", conf.Host, conf.Port),
+		Handler: server.setupFileEngine(),
+	}
+
+	if conf.TLSCert != "" && conf.TLSKey != "" {
+		reloader, err := newCertReloader(conf.TLSCert, conf.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		httpServer.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+		}
+	}
+
+	return httpServer, nil
+}
+
+// runFileServer 运行独立的文件服务器
+func (server *Server) runFileServer() {
+	var err error
+	if server.fileServer.TLSConfig != nil {
+		log.Infof("文件服务器已启动，监听地址: https://%s", server.fileServer.Addr)
+		err = server.fileServer.ListenAndServeTLS("", "")
+	} else {
+		log.Infof("文件服务器已启动，监听地址: http://%s", server.fileServer.Addr)
+		err = server.fileServer.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		log.Errorf("文件服务器运行时出错: %v", err)
+	}
+}
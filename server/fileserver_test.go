@@ -0,0 +1,128 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/WindowsSov8forUs/glyccat/config"
+)
+
+// writeTestCert 生成自签名证书并写入文件
+func writeTestCert(t *testing.T, certFile, keyFile, commonName string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("生成证书失败: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("编码私钥失败: %v", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("写入证书失败: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatalf("写入私钥失败: %v", err)
+	}
+}
+
+// certCommonName 获取证书的通用名称
+func certCommonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("解析证书失败: %v", err)
+	}
+	return parsed.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeTestCert(t, certFile, keyFile, "first")
+
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("newCertReloader() error = %v", err)
+	}
+
+	steps := []struct {
+		name   string
+		change func()
+		want   string
+	}{
+		{"initial", func() {}, "first"},
+		{"renewed", func() {
+			writeTestCert(t, certFile, keyFile, "second")
+			future := time.Now().Add(time.Minute)
+			os.Chtimes(certFile, future, future)
+		}, "second"},
+		{"broken keeps previous", func() {
+			os.WriteFile(certFile, []byte("broken"), 0600)
+			future := time.Now().Add(2 * time.Minute)
+			os.Chtimes(certFile, future, future)
+		}, "second"},
+	}
+
+	for _, step := range steps {
+		step.change()
+		reloader.lastCheck = time.Time{} // 跳过检查间隔
+		cert, err := reloader.GetCertificate(nil)
+		if err != nil {
+			t.Fatalf("%s: GetCertificate() error = %v", step.name, err)
+		}
+		if got := certCommonName(t, cert); got != step.want {
+			t.Errorf("%s: certificate = %q, want %q", step.name, got, step.want)
+		}
+	}
+}
+
+func TestFileEngineServesOnlyFiles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	server := &Server{conf: config.DefaultConfig()}
+	engine := server.setupFileEngine()
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodPost, "/v1/message.create", http.StatusNotFound},
+		{http.MethodGet, "/v1/events", http.StatusNotFound},
+		{http.MethodGet, "/healthz", http.StatusNotFound},
+		{http.MethodGet, "/v1/proxy/https://example.com/a.png", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/satori-protocol-go/satori-model-go/pkg/login"
+
+	"github.com/WindowsSov8forUs/glyccat/database"
+	"github.com/WindowsSov8forUs/glyccat/fileserver"
+	"github.com/WindowsSov8forUs/glyccat/processor"
+)
+
+// livenessGracePeriod QQ 开放平台会话异常或鉴权令牌过期超过该时间时视为卡死
+const livenessGracePeriod = 5 * time.Minute
+
+// tokenRefreshGrace 鉴权令牌在过期时刷新，刷新期间短暂过期不视为失败
+const tokenRefreshGrace = 30 * time.Second
+
+// 检查结果
+const (
+	checkOK       = "ok"
+	checkFail     = "fail"
+	checkDisabled = "disabled"
+)
+
+// HealthCheck 单项检查结果
+type HealthCheck struct {
+	Status  string `json:"status"`            // 检查结果，ok 、fail 或 disabled
+	Message string `json:"message,omitempty"` // 检查信息
+}
+
+// SessionHealth QQ 开放平台会话状态
+type SessionHealth struct {
+	Status string    `json:"status"` // 会话状态，如 online 、reconnect
+	Since  time.Time `json:"since"`  // 该状态开始的时间
+}
+
+// TokenHealth 鉴权令牌状态
+type TokenHealth struct {
+	Status    string     `json:"status"`               // 检查结果
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // 过期时间，尚未取得令牌时为空
+	ExpiresIn int64      `json:"expires_in"`           // 剩余有效期，单位秒，已过期时为负数
+}
+
+// HealthReport 健康检查报告
+type HealthReport struct {
+	Status      string                    `json:"status"`             // 总体结果，ok 或 fail
+	Sessions    map[string]SessionHealth  `json:"sessions"`           // 各平台的 QQ 开放平台会话状态
+	Token       TokenHealth               `json:"token"`              // 鉴权令牌状态
+	Databases   map[string]HealthCheck    `json:"databases"`          // 消息、成员与文件数据库状态
+	Subscribers map[string]int            `json:"subscribers"`        // Satori 订阅者数量
+	Failures    []string                  `json:"failures,omitempty"` // 导致失败的检查项
+	checks      map[string]healthCriteria // 失败的检查项
+}
+
+// healthCriteria 检查项是否影响存活与就绪状态
+type healthCriteria struct {
+	live  bool
+	ready bool
+}
+
+// fail 记录检查失败
+func (report *HealthReport) fail(name string, live, ready bool) {
+	report.checks[name] = healthCriteria{live: live, ready: ready}
+}
+
+// collectHealth 收集健康状态
+func (server *Server) collectHealth() *HealthReport {
+	now := time.Now()
+	report := &HealthReport{
+		Sessions:    make(map[string]SessionHealth),
+		Databases:   make(map[string]HealthCheck),
+		Subscribers: make(map[string]int),
+		checks:      make(map[string]healthCriteria),
+	}
+
+	// QQ 开放平台会话状态，重新连接中不可用，持续过久视为卡死
+	for platform := range processor.GetBots() {
+		status, since := processor.GetStatusSince(platform)
+		report.Sessions[platform] = SessionHealth{
+			Status: loginStatusNames[status],
+			Since:  since,
+		}
+		if status != login.StatusOnline {
+			report.fail("session."+platform, now.Sub(since) > livenessGracePeriod, true)
+		}
+	}
+	if len(report.Sessions) == 0 {
+		report.fail("session", false, true)
+	}
+
+	// 鉴权令牌有效期，刷新失败超过宽限期视为卡死
+	report.Token.Status = checkOK
+	if server.token != nil {
+		expiresAt := server.token.GetExpiresAt()
+		if expiresAt.IsZero() {
+			report.Token.Status = checkFail
+			report.fail("token", false, true)
+		} else {
+			report.Token.ExpiresAt = &expiresAt
+			report.Token.ExpiresIn = int64(expiresAt.Sub(now).Seconds())
+			if now.After(expiresAt.Add(tokenRefreshGrace)) {
+				report.Token.Status = checkFail
+				report.fail("token", now.Sub(expiresAt) > livenessGracePeriod, true)
+			}
+		}
+	}
+
+	// 数据库状态，已启用的数据库不可用时不再就绪
+	databases := []struct {
+		name    string
+		enabled bool
+		ping    func() error
+	}{
+		{"message", server.conf.Database.MessageDatabase.Enable, database.PingMessageDB},
+		{"member", server.conf.Database.MemberDatabase.Enable, database.PingMemberDB},
+		{"file", server.conf.FileServer.Enable, fileserver.Ping},
+	}
+	for _, db := range databases {
+		if !db.enabled {
+			report.Databases[db.name] = HealthCheck{Status: checkDisabled}
+			continue
+		}
+		if err := db.ping(); err != nil {
+			report.Databases[db.name] = HealthCheck{Status: checkFail, Message: err.Error()}
+			report.fail("database."+db.name, false, true)
+			continue
+		}
+		report.Databases[db.name] = HealthCheck{Status: checkOK}
+	}
+
+	// Satori 订阅者数量
+	server.rwMutex.RLock()
+	report.Subscribers["websocket"] = len(server.websockets)
+	report.Subscribers["webhook"] = len(server.webhooks)
+	server.rwMutex.RUnlock()
+
+	return report
+}
+
+// respond 根据检查项输出报告
+func (report *HealthReport) respond(c *gin.Context, failed func(healthCriteria) bool) {
+	report.Status = checkOK
+	for name, criteria := range report.checks {
+		if failed(criteria) {
+			report.Failures = append(report.Failures, name)
+		}
+	}
+	sort.Strings(report.Failures)
+
+	code := http.StatusOK
+	if len(report.Failures) > 0 {
+		report.Status = checkFail
+		code = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, report)
+}
+
+// HealthzHandler 存活检查处理函数
+//
+// QQ 开放平台会话或鉴权令牌长时间无法恢复时返回 503 ，以便重启卡死的实例
+func (server *Server) HealthzHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		server.collectHealth().respond(c, func(criteria healthCriteria) bool {
+			return criteria.live
+		})
+	}
+}
+
+// ReadyzHandler 就绪检查处理函数
+//
+// QQ 开放平台会话未连接、正在重新连接、鉴权令牌过期或已启用的数据库不可用时返回 503
+func (server *Server) ReadyzHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		server.collectHealth().respond(c, func(criteria healthCriteria) bool {
+			return criteria.ready
+		})
+	}
+}
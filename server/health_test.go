@@ -0,0 +1,117 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/satori-protocol-go/satori-model-go/pkg/login"
+	"github.com/satori-protocol-go/satori-model-go/pkg/user"
+
+	"github.com/WindowsSov8forUs/glyccat/config"
+	"github.com/WindowsSov8forUs/glyccat/processor"
+)
+
+// serveHealth 调用健康检查处理函数并解析报告
+func serveHealth(t *testing.T, handler gin.HandlerFunc) (int, *HealthReport) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	handler(c)
+
+	var report HealthReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("解析健康检查报告失败: %v", err)
+	}
+	return w.Code, &report
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 测试用例按顺序执行，机器人状态是全局的
+	tests := []struct {
+		name         string
+		setup        func(conf *config.Config)
+		wantHealthz  int
+		wantReadyz   int
+		wantFailures []string
+	}{
+		{
+			name:         "no session",
+			setup:        func(conf *config.Config) {},
+			wantHealthz:  http.StatusOK,
+			wantReadyz:   http.StatusServiceUnavailable,
+			wantFailures: []string{"session"},
+		},
+		{
+			name: "reconnecting",
+			setup: func(conf *config.Config) {
+				processor.SetBot("qq", &user.User{Id: "1"})
+				processor.SetStatus("qq", login.StatusReconnect)
+			},
+			wantHealthz:  http.StatusOK,
+			wantReadyz:   http.StatusServiceUnavailable,
+			wantFailures: []string{"session.qq"},
+		},
+		{
+			name: "online",
+			setup: func(conf *config.Config) {
+				processor.SetStatus("qq", login.StatusOnline)
+			},
+			wantHealthz: http.StatusOK,
+			wantReadyz:  http.StatusOK,
+		},
+		{
+			name: "database not started",
+			setup: func(conf *config.Config) {
+				conf.Database.MessageDatabase.Enable = true
+			},
+			wantHealthz:  http.StatusOK,
+			wantReadyz:   http.StatusServiceUnavailable,
+			wantFailures: []string{"database.message"},
+		},
+	}
+
+	// 机器人无法从全局状态中移除，重复运行时跳过没有会话的用例
+	if len(processor.GetBots()) > 0 {
+		tests = tests[1:]
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.DefaultConfig()
+			conf.Database.MessageDatabase.Enable = false
+			conf.Database.MemberDatabase.Enable = false
+			tt.setup(conf)
+			server := &Server{conf: conf}
+
+			code, report := serveHealth(t, server.HealthzHandler())
+			if code != tt.wantHealthz {
+				t.Errorf("healthz status = %d, want %d", code, tt.wantHealthz)
+			}
+			if len(report.Failures) != 0 {
+				t.Errorf("healthz failures = %v, want none", report.Failures)
+			}
+
+			code, report = serveHealth(t, server.ReadyzHandler())
+			if code != tt.wantReadyz {
+				t.Errorf("readyz status = %d, want %d", code, tt.wantReadyz)
+			}
+			if !reflect.DeepEqual(report.Failures, tt.wantFailures) {
+				t.Errorf("readyz failures = %v, want %v", report.Failures, tt.wantFailures)
+			}
+			wantStatus := checkOK
+			if tt.wantReadyz != http.StatusOK {
+				wantStatus = checkFail
+			}
+			if report.Status != wantStatus {
+				t.Errorf("readyz report status = %q, want %q", report.Status, wantStatus)
+			}
+		})
+	}
+}
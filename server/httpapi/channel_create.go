@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 
@@ -17,8 +16,10 @@ func init() {
 
 // RequestChannelCreate 创建群组频道请求
 type RequestChannelCreate struct {
-	GuildId string           `json:"guild_id"` // 群组 ID
-	Data    *channel.Channel `json:"data"`     // 频道数据
+	GuildId string           `json:"guild_id"`           // 群组 ID
+	Data    *channel.Channel `json:"data"`               // 频道数据
+	Private bool             `json:"private,omitempty"`  // 是否创建私密子频道
+	UserIds []string         `json:"user_ids,omitempty"` // 私密子频道的成员 ID 列表
 }
 
 // ResponseChannelCreate 创建群组频道响应
@@ -33,6 +34,11 @@ func HandleChannelCreate(api openapi.OpenAPI, apiv2 openapi.OpenAPI, message *Ac
 	}
 
 	if message.Platform == "qqguild" {
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
 		var response ResponseChannelCreate
 
 		// 不能通过这种方式创建私聊子频道
@@ -40,8 +46,17 @@ func HandleChannelCreate(api openapi.OpenAPI, apiv2 openapi.OpenAPI, message *Ac
 			return gin.H{}, &BadRequestError{fmt.Errorf("cannot create direct channel using this api")}
 		}
 
+		// 指定成员时只能创建私密子频道
+		if !request.Private && len(request.UserIds) > 0 {
+			return gin.H{}, &BadRequestError{fmt.Errorf("user_ids can only be used with private channel")}
+		}
+
 		var dtoChannel *dto.Channel
-		dtoChannel, err = apiv2.PostChannel(context.TODO(), request.GuildId, createChannelValue(request.Data))
+		if request.Private {
+			dtoChannel, err = apiv2.CreatePrivateChannel(message.Context(), request.GuildId, createChannelValue(request.Data), request.UserIds)
+		} else {
+			dtoChannel, err = apiv2.PostChannel(message.Context(), request.GuildId, createChannelValue(request.Data))
+		}
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
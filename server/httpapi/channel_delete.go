@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
@@ -26,7 +25,7 @@ func HandleChannelDelete(api openapi.OpenAPI, apiv2 openapi.OpenAPI, message *Ac
 	}
 
 	if message.Platform == "qqguild" {
-		err = apiv2.DeleteChannel(context.TODO(), request.ChannelId)
+		err = apiv2.DeleteChannel(message.Context(), request.ChannelId)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
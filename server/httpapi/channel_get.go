@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 
@@ -44,7 +43,7 @@ func HandleChannelGet(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any,
 
 		} else {
 			var dtoChannel *dto.Channel
-			dtoChannel, err = apiv2.Channel(context.TODO(), request.ChannelID)
+			dtoChannel, err = apiv2.Channel(message.Context(), request.ChannelID)
 			if err != nil {
 				return gin.H{}, &InternalServerError{err}
 			}
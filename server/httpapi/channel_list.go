@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
@@ -34,10 +33,15 @@ func HandleChannelList(api openapi.OpenAPI, apiv2 openapi.OpenAPI, message *Acti
 	}
 
 	if message.Platform == "qqguild" {
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
 		var response ResponseChannelList
 
 		var dtoChannels []*dto.Channel
-		dtoChannels, err = apiv2.Channels(context.TODO(), request.GuildId)
+		dtoChannels, err = apiv2.Channels(message.Context(), request.GuildId)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
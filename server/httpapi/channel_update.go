@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
@@ -28,7 +27,7 @@ func HandleChannelUpdate(api openapi.OpenAPI, apiv2 openapi.OpenAPI, message *Ac
 	}
 
 	if message.Platform == "qqguild" {
-		_, err = apiv2.PatchChannel(context.TODO(), request.ChannelId, createChannelValue(request.Data))
+		_, err = apiv2.PatchChannel(message.Context(), request.ChannelId, createChannelValue(request.Data))
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 
@@ -33,10 +32,15 @@ func HandleGuildGet(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, AP
 		return gin.H{}, &BadRequestError{err}
 	}
 	if message.Platform == "qqguild" {
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
 		var response ResponseGuildGet
 		var dtoGuild *dto.Guild
 
-		dtoGuild, err = apiv2.Guild(context.TODO(), request.GuildId)
+		dtoGuild, err = apiv2.Guild(message.Context(), request.GuildId)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
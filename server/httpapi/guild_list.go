@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
@@ -35,7 +34,7 @@ func HandleGuildList(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, A
 	if message.Platform == "qqguild" {
 		var response ResponseGuildList
 		var dtoGuilds []*dto.Guild
-		dtoGuilds, err = apiv2.MeGuilds(context.TODO(), createGuildPager(request.Next))
+		dtoGuilds, err = apiv2.MeGuilds(message.Context(), createGuildPager(request.Next))
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
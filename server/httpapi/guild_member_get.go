@@ -1,10 +1,10 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 
+	"github.com/WindowsSov8forUs/glyccat/database"
 	"github.com/gin-gonic/gin"
 	"github.com/satori-protocol-go/satori-model-go/pkg/guildmember"
 	"github.com/satori-protocol-go/satori-model-go/pkg/user"
@@ -25,6 +25,20 @@ type RequestGuildMemberGet struct {
 // ResponseGuildMemberGet 获取群组成员响应
 type ResponseGuildMemberGet guildmember.GuildMember
 
+// MemberObservation 成员观察记录
+type MemberObservation struct {
+	FirstSeen    int64 `json:"first_seen"`    // 第一次发言时间
+	LastSeen     int64 `json:"last_seen"`     // 最后一次发言时间
+	MessageCount int64 `json:"message_count"` // 发言次数
+}
+
+// ResponseObservedGuildMember 由观察记录得到的群组成员响应
+type ResponseObservedGuildMember struct {
+	guildmember.GuildMember
+	Partial     bool               `json:"partial"`     // 数据是否不完整，观察记录总是不完整的
+	Observation *MemberObservation `json:"observation"` // 观察记录
+}
+
 // HandleGuildMemberGet 处理获取群组成员请求
 func HandleGuildMemberGet(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
 	var request RequestGuildMemberGet
@@ -34,10 +48,15 @@ func HandleGuildMemberGet(api, apiv2 openapi.OpenAPI, message *ActionMessage) (a
 	}
 
 	if message.Platform == "qqguild" {
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
 		var response ResponseGuildMemberGet
 
 		var dtoMember *dto.Member
-		dtoMember, err = apiv2.GuildMember(context.TODO(), request.GuildId, request.UserId)
+		dtoMember, err = apiv2.GuildMember(message.Context(), request.GuildId, request.UserId)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
@@ -50,6 +69,21 @@ func HandleGuildMemberGet(api, apiv2 openapi.OpenAPI, message *ActionMessage) (a
 		response = ResponseGuildMemberGet(guildMember)
 
 		return response, nil
+	} else if message.Platform == "qq" {
+		// 群聊没有成员查询接口，使用观察记录
+		if !database.MemberDBEnabled() {
+			return gin.H{}, &InternalServerError{fmt.Errorf("member database is not enabled")}
+		}
+
+		member, err := database.GetObservedMember(request.GuildId, request.UserId)
+		if err != nil {
+			return gin.H{}, &InternalServerError{err}
+		}
+		if member == nil {
+			return gin.H{}, &NotFoundError{err: fmt.Errorf("member %s has not been observed in guild %s", request.UserId, request.GuildId)}
+		}
+
+		return convertObservedMemberToGuildMember(member), nil
 	}
 
 	return defaultResource(message)
@@ -89,3 +123,22 @@ func convertDtoTimestampToInt64(timestamp *dto.Timestamp) (int64, error) {
 	}
 	return time.Unix(), nil
 }
+
+// convertObservedMemberToGuildMember 将观察记录转换为群组成员
+func convertObservedMemberToGuildMember(member *database.ObservedMember) *ResponseObservedGuildMember {
+	var response ResponseObservedGuildMember
+
+	response.User = &user.User{
+		Id:     member.UserId,
+		Avatar: member.Avatar,
+	}
+	response.Avatar = member.Avatar
+	response.Partial = true
+	response.Observation = &MemberObservation{
+		FirstSeen:    member.FirstSeen,
+		LastSeen:     member.LastSeen,
+		MessageCount: member.MessageCount,
+	}
+
+	return &response
+}
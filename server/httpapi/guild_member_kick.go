@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
@@ -29,11 +28,16 @@ func HandleGuildMemberKick(api, apiv2 openapi.OpenAPI, message *ActionMessage) (
 	}
 
 	if message.Platform == "qqguild" {
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
 		// 根据 Permanent 字段值选择不同的处理函数
 		if request.Permanent {
-			err = api.DeleteGuildMember(context.TODO(), request.GuildId, request.UserId, setMemberDeleteOpts)
+			err = api.DeleteGuildMember(message.Context(), request.GuildId, request.UserId, setMemberDeleteOpts)
 		} else {
-			err = api.DeleteGuildMember(context.TODO(), request.GuildId, request.UserId)
+			err = api.DeleteGuildMember(message.Context(), request.GuildId, request.UserId)
 		}
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
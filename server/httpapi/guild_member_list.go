@@ -1,9 +1,10 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
+	"fmt"
 
+	"github.com/WindowsSov8forUs/glyccat/database"
 	"github.com/gin-gonic/gin"
 	"github.com/satori-protocol-go/satori-model-go/pkg/guildmember"
 	"github.com/tencent-connect/botgo/dto"
@@ -23,6 +24,16 @@ type RequestGuildMemberList struct {
 // ResponseGuildMemberList 获取群组成员列表响应
 type ResponseGuildMemberList guildmember.GuildMemberList
 
+// ResponseObservedGuildMemberList 由观察记录得到的群组成员列表响应
+type ResponseObservedGuildMemberList struct {
+	Data    []*ResponseObservedGuildMember `json:"data"`           // 数据
+	Next    string                         `json:"next,omitempty"` // 下一页的令牌
+	Partial bool                           `json:"partial"`        // 数据是否不完整，观察记录总是不完整的
+}
+
+// observedMemberPageSize 观察记录每页的成员数
+const observedMemberPageSize = 50
+
 // HandleGuildMemberList 处理获取群组成员列表请求
 func HandleGuildMemberList(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
 	var request RequestGuildMemberList
@@ -32,10 +43,15 @@ func HandleGuildMemberList(api, apiv2 openapi.OpenAPI, message *ActionMessage) (
 	}
 
 	if message.Platform == "qqguild" {
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
 		var response ResponseGuildMemberList
 
 		var dtoMembers []*dto.Member
-		dtoMembers, err = apiv2.GuildMembers(context.TODO(), request.GuildId, createGuildMembersPager(request.Next))
+		dtoMembers, err = apiv2.GuildMembers(message.Context(), request.GuildId, createGuildMembersPager(request.Next))
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
@@ -52,6 +68,27 @@ func HandleGuildMemberList(api, apiv2 openapi.OpenAPI, message *ActionMessage) (
 			response.Data = append(response.Data, &guildMember)
 		}
 
+		return response, nil
+	} else if message.Platform == "qq" {
+		// 群聊没有成员查询接口，使用观察记录
+		if !database.MemberDBEnabled() {
+			return gin.H{}, &InternalServerError{fmt.Errorf("member database is not enabled")}
+		}
+
+		var response ResponseObservedGuildMemberList
+
+		members, next, err := database.GetObservedMemberList(request.GuildId, request.Next, observedMemberPageSize)
+		if err != nil {
+			return gin.H{}, &InternalServerError{err}
+		}
+
+		response.Data = []*ResponseObservedGuildMember{}
+		for _, member := range members {
+			response.Data = append(response.Data, convertObservedMemberToGuildMember(member))
+		}
+		response.Next = next
+		response.Partial = true
+
 		return response, nil
 	}
 
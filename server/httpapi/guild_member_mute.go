@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 	"strconv"
 
@@ -16,9 +15,10 @@ func init() {
 
 // RequestGuildMemberMute 禁言群组成员请求
 type RequestGuildMemberMute struct {
-	GuildId  string `json:"guild_id"` // 群组 ID
-	UserId   string `json:"user_id"`  // 用户 ID
-	Duration int    `json:"duration"` // 禁言时长 (毫秒)
+	GuildId  string `json:"guild_id"`           // 群组 ID
+	UserId   string `json:"user_id"`            // 用户 ID
+	Duration int    `json:"duration"`           // 禁言时长 (毫秒)，为 0 时解除禁言
+	EndTime  int64  `json:"end_time,omitempty"` // 禁言截止时间戳 (毫秒)，设置时优先于 duration
 }
 
 // HandleGuildMemberMute 处理禁言群组成员请求
@@ -30,7 +30,12 @@ func HandleGuildMemberMute(api, apiv2 openapi.OpenAPI, message *ActionMessage) (
 	}
 
 	if message.Platform == "qqguild" {
-		err = api.MemberMute(context.TODO(), request.GuildId, request.UserId, createUpdateGuildMute(&request))
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
+		err = api.MemberMute(message.Context(), request.GuildId, request.UserId, createUpdateGuildMute(request.Duration, request.EndTime, nil))
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
@@ -42,9 +47,16 @@ func HandleGuildMemberMute(api, apiv2 openapi.OpenAPI, message *ActionMessage) (
 }
 
 // createUpdateGuildMute 创建 dto.UpdateGuildMute
-func createUpdateGuildMute(request *RequestGuildMemberMute) *dto.UpdateGuildMute {
-	return &dto.UpdateGuildMute{
-		MuteSeconds: strconv.Itoa(request.Duration / 1000),
-		UserIDs:     []string{request.UserId},
+//
+// 设置了截止时间时以截止时间为准，否则使用禁言时长，时长为 0 即解除禁言
+func createUpdateGuildMute(duration int, endTime int64, userIds []string) *dto.UpdateGuildMute {
+	mute := &dto.UpdateGuildMute{
+		UserIDs: userIds,
+	}
+	if endTime > 0 {
+		mute.MuteEndTimestamp = strconv.FormatInt(endTime/1000, 10)
+	} else {
+		mute.MuteSeconds = strconv.Itoa(duration / 1000)
 	}
+	return mute
 }
@@ -0,0 +1,78 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func init() {
+	RegisterHandler("guild.member.mute.batch", HandleGuildMemberMuteBatch)
+}
+
+// RequestGuildMemberMuteBatch 批量禁言群组成员请求
+type RequestGuildMemberMuteBatch struct {
+	GuildId  string   `json:"guild_id"`           // 群组 ID
+	UserIds  []string `json:"user_ids"`           // 用户 ID 列表
+	Duration int      `json:"duration"`           // 禁言时长 (毫秒)，为 0 时解除禁言
+	EndTime  int64    `json:"end_time,omitempty"` // 禁言截止时间戳 (毫秒)，设置时优先于 duration
+}
+
+// GuildMemberMuteResult 单个成员的禁言结果
+type GuildMemberMuteResult struct {
+	UserId  string `json:"user_id"` // 用户 ID
+	Success bool   `json:"success"` // 是否操作成功
+}
+
+// ResponseGuildMemberMuteBatch 批量禁言群组成员响应
+type ResponseGuildMemberMuteBatch struct {
+	Data []*GuildMemberMuteResult `json:"data"` // 各成员的禁言结果
+}
+
+// HandleGuildMemberMuteBatch 处理批量禁言群组成员请求
+func HandleGuildMemberMuteBatch(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
+	var request RequestGuildMemberMuteBatch
+	err := json.Unmarshal(message.Data(), &request)
+	if err != nil {
+		return gin.H{}, &BadRequestError{err}
+	}
+
+	if message.Platform == "qqguild" {
+		if len(request.UserIds) == 0 {
+			return gin.H{}, &BadRequestError{fmt.Errorf("user_ids is required")}
+		}
+
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
+		var response ResponseGuildMemberMuteBatch
+
+		dtoResponse, err := api.MultiMemberMute(message.Context(), request.GuildId, createUpdateGuildMute(request.Duration, request.EndTime, request.UserIds))
+		if err != nil {
+			return gin.H{}, &InternalServerError{err}
+		}
+
+		// 开放平台只返回操作成功的成员
+		succeeded := make(map[string]bool)
+		if dtoResponse != nil {
+			for _, userId := range dtoResponse.UserIDs {
+				succeeded[userId] = true
+			}
+		}
+		response.Data = make([]*GuildMemberMuteResult, 0, len(request.UserIds))
+		for _, userId := range request.UserIds {
+			response.Data = append(response.Data, &GuildMemberMuteResult{
+				UserId:  userId,
+				Success: succeeded[userId],
+			})
+		}
+
+		return response, nil
+	}
+
+	return defaultResource(message)
+}
@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
@@ -29,10 +28,15 @@ func HandleGuildMemberRoleUnset(api, apiv2 openapi.OpenAPI, message *ActionMessa
 	}
 
 	if message.Platform == "qqguild" {
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
 		dtoMemberAddRoleBody := &dto.MemberAddRoleBody{
 			Channel: &dto.Channel{},
 		}
-		err = apiv2.MemberDeleteRole(context.TODO(), request.GuildId, dto.RoleID(request.RoleId), request.UserId, dtoMemberAddRoleBody)
+		err = apiv2.MemberDeleteRole(message.Context(), request.GuildId, dto.RoleID(request.RoleId), request.UserId, dtoMemberAddRoleBody)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
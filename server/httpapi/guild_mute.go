@@ -0,0 +1,44 @@
+package httpapi
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func init() {
+	RegisterHandler("guild.mute", HandleGuildMute)
+}
+
+// RequestGuildMute 群组全体禁言请求
+type RequestGuildMute struct {
+	GuildId  string `json:"guild_id"`           // 群组 ID
+	Duration int    `json:"duration"`           // 禁言时长 (毫秒)，为 0 时解除全体禁言
+	EndTime  int64  `json:"end_time,omitempty"` // 禁言截止时间戳 (毫秒)，设置时优先于 duration
+}
+
+// HandleGuildMute 处理群组全体禁言请求
+func HandleGuildMute(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
+	var request RequestGuildMute
+	err := json.Unmarshal(message.Data(), &request)
+	if err != nil {
+		return gin.H{}, &BadRequestError{err}
+	}
+
+	if message.Platform == "qqguild" {
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
+		err = api.GuildMute(message.Context(), request.GuildId, createUpdateGuildMute(request.Duration, request.EndTime, nil))
+		if err != nil {
+			return gin.H{}, &InternalServerError{err}
+		}
+
+		return gin.H{}, nil
+	}
+
+	return defaultResource(message)
+}
@@ -0,0 +1,99 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tencent-connect/botgo/dto"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func TestCreateUpdateGuildMute(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration int
+		endTime  int64
+		userIds  []string
+		want     dto.UpdateGuildMute
+	}{
+		{"duration", 60000, 0, nil, dto.UpdateGuildMute{MuteSeconds: "60"}},
+		{"unmute", 0, 0, []string{"1"}, dto.UpdateGuildMute{MuteSeconds: "0", UserIDs: []string{"1"}}},
+		{"end time wins", 60000, 1700000000000, []string{"1", "2"}, dto.UpdateGuildMute{MuteEndTimestamp: "1700000000", UserIDs: []string{"1", "2"}}},
+	}
+
+	for _, tt := range tests {
+		if got := createUpdateGuildMute(tt.duration, tt.endTime, tt.userIds); !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s: createUpdateGuildMute() = %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+}
+
+// fakeMuteAPI 只禁言成功部分成员
+type fakeMuteAPI struct {
+	openapi.OpenAPI
+	succeeded []string
+	mute      *dto.UpdateGuildMute
+}
+
+func (api *fakeMuteAPI) MultiMemberMute(ctx context.Context, guildID string, mute *dto.UpdateGuildMute) (*dto.UpdateGuildMuteResponse, error) {
+	api.mute = mute
+	return &dto.UpdateGuildMuteResponse{UserIDs: api.succeeded}, nil
+}
+
+func TestHandleGuildMemberMuteBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		want     []*GuildMemberMuteResult
+	}{
+		{
+			name:     "partial success",
+			body:     `{"user_ids":["1","2","3"],"duration":60000}`,
+			wantCode: 0,
+			want: []*GuildMemberMuteResult{
+				{UserId: "1", Success: true},
+				{UserId: "2", Success: false},
+				{UserId: "3", Success: true},
+			},
+		},
+		{
+			name:     "no users",
+			body:     `{"user_ids":[],"duration":60000}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeMuteAPI{succeeded: []string{"1", "3"}}
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/guild.member.mute.batch", strings.NewReader(tt.body))
+			message := NewActionMessage("guild.member.mute.batch", nil, "qqguild", c)
+
+			response, apiErr := HandleGuildMemberMuteBatch(api, api, message)
+			if tt.wantCode != 0 {
+				if apiErr == nil || apiErr.Code() != tt.wantCode {
+					t.Fatalf("HandleGuildMemberMuteBatch() error = %v, want status %d", apiErr, tt.wantCode)
+				}
+				return
+			}
+			if apiErr != nil {
+				t.Fatalf("HandleGuildMemberMuteBatch() error = %v", apiErr)
+			}
+			if got := response.(ResponseGuildMemberMuteBatch).Data; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("results = %+v, want %+v", got, tt.want)
+			}
+			if api.mute.MuteSeconds != "60" {
+				t.Errorf("mute_seconds = %q, want %q", api.mute.MuteSeconds, "60")
+			}
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
@@ -32,6 +31,11 @@ func HandleGuildRoleCreate(api, apiv2 openapi.OpenAPI, message *ActionMessage) (
 	}
 
 	if message.Platform == "qqguild" {
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
 		var response ResponseGuildRoleCreate
 
 		dtoRole, err := convertGuildRoleToDtoRole(request.Role)
@@ -39,7 +43,7 @@ func HandleGuildRoleCreate(api, apiv2 openapi.OpenAPI, message *ActionMessage) (
 			return gin.H{}, &InternalServerError{err}
 		}
 
-		dtoUpdateResult, err := apiv2.PostRole(context.TODO(), request.GuildId, dtoRole)
+		dtoUpdateResult, err := apiv2.PostRole(message.Context(), request.GuildId, dtoRole)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
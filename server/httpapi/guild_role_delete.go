@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
@@ -28,7 +27,12 @@ func HandleGuildRoleDelete(api, apiv2 openapi.OpenAPI, message *ActionMessage) (
 	}
 
 	if message.Platform == "qqguild" {
-		err = apiv2.DeleteRole(context.TODO(), request.GuildId, dto.RoleID(request.RoleId))
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
+		err = apiv2.DeleteRole(message.Context(), request.GuildId, dto.RoleID(request.RoleId))
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
@@ -32,9 +31,14 @@ func HandleGuildRoleList(api, apiv2 openapi.OpenAPI, message *ActionMessage) (an
 	}
 
 	if message.Platform == "qqguild" {
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
 		var response ResponseGuildRoleList
 
-		dtoGuildRoles, err := apiv2.Roles(context.TODO(), request.GuildId)
+		dtoGuildRoles, err := apiv2.Roles(message.Context(), request.GuildId)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
@@ -31,12 +30,17 @@ func HandleGuildRoleUpdate(api, apiv2 openapi.OpenAPI, message *ActionMessage) (
 	}
 
 	if message.Platform == "qqguild" {
+		// 检查机器人是否拥有对应的 API 权限
+		if apiErr := checkAPIPermission(message.Context(), apiv2, message.API, request.GuildId); apiErr != nil {
+			return gin.H{}, apiErr
+		}
+
 		dtoRole, err := convertGuildRoleToDtoRole(request.Role)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
 
-		_, err = apiv2.PatchRole(context.TODO(), request.GuildId, dto.RoleID(request.RoleId), dtoRole)
+		_, err = apiv2.PatchRole(message.Context(), request.GuildId, dto.RoleID(request.RoleId), dtoRole)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
@@ -2,6 +2,7 @@ package httpapi
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/WindowsSov8forUs/glyccat/config"
 	"github.com/WindowsSov8forUs/glyccat/fileserver"
+	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/processor"
 	"github.com/WindowsSov8forUs/glyccat/version"
 	"github.com/gin-gonic/gin"
@@ -24,10 +26,22 @@ type webHookServerManager interface {
 	DeleteWebHook(url string) error
 }
 
+// configReloader 配置重新加载器
+type configReloader interface {
+	ReloadConfig() (*config.ReloadResult, error)
+}
+
+// serverManager Satori 服务端管理器
+type serverManager interface {
+	webHookServerManager
+	configReloader
+}
+
 // Server HTTP 服务端
 type Server struct {
 	httpServer     *http.Server
 	webHookManager webHookServerManager
+	configReloader configReloader
 }
 
 var instance *Server
@@ -40,17 +54,22 @@ func (server *Server) Shutdown(ctx context.Context) error {
 	return server.httpServer.Shutdown(ctx)
 }
 
+func (server *Server) Close() error {
+	return server.httpServer.Close()
+}
+
 func (server *Server) Addr() string {
 	return server.httpServer.Addr
 }
 
-func NewHttpServer(addr string, handler http.Handler, webHookManager webHookServerManager) *Server {
+func NewHttpServer(addr string, handler http.Handler, manager serverManager) *Server {
 	instance = &Server{
 		httpServer: &http.Server{
 			Addr:    addr,
 			Handler: handler,
 		},
-		webHookManager: webHookManager,
+		webHookManager: manager,
+		configReloader: manager,
 	}
 	return instance
 }
@@ -80,7 +99,7 @@ type UnauthorizedError struct {
 }
 
 func (e *UnauthorizedError) Error() string {
-	return fmt.Sprintf("unauthorized with wrong token: %s", e.invalidToken)
+	return "unauthorized with wrong token"
 }
 
 func (e *UnauthorizedError) Code() int {
@@ -104,10 +123,13 @@ func (e *ForbiddenError) Code() int {
 type NotFoundError struct {
 	api      string
 	platform string
+	err      error // 请求的资源不存在时的错误，为空时表示接口不存在
 }
 
 func (e *NotFoundError) Error() string {
-	if e.platform == "" {
+	if e.err != nil {
+		return e.err.Error()
+	} else if e.platform == "" {
 		return fmt.Sprintf(`api "%s" not found`, e.api)
 	} else {
 		return fmt.Sprintf(`api "%s" is not supported on %s`, e.api, e.platform)
@@ -131,6 +153,33 @@ func (e *MethodNotAllowedError) Code() int {
 	return http.StatusMethodNotAllowed
 }
 
+// PayloadTooLargeError 请求内容过大
+type PayloadTooLargeError struct {
+	err error
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *PayloadTooLargeError) Code() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+// TooManyRequestsError 超出 QQ 开放平台 OpenAPI 的频率限制
+type TooManyRequestsError struct {
+	err        error
+	retryAfter time.Duration // 建议的重试等待时间，为 0 时不设置 Retry-After 响应头
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *TooManyRequestsError) Code() int {
+	return http.StatusTooManyRequests
+}
+
 // InternalServerError 服务器内部错误
 type InternalServerError struct {
 	err error
@@ -164,6 +213,13 @@ func (message *ActionMessage) Data() []byte {
 	return bodyBytes
 }
 
+// Context 获取携带请求 ID 的上下文，应传递给 OpenAPI 调用
+//
+// 客户端断开连接时不会取消，避免消息发送到一半被中断
+func (message *ActionMessage) Context() context.Context {
+	return context.WithoutCancel(message.Ctx.Request.Context())
+}
+
 // MetaActionMessage Satori 应用发送的元信息接口调用信息
 type MetaActionMessage struct {
 	API string       // 接口
@@ -211,7 +267,7 @@ var metaHandlers = make(map[string]MetaHandlerFunc)
 
 // defaultResource 资源默认处理函数
 func defaultResource(action *ActionMessage) (any, APIError) {
-	return gin.H{}, &NotFoundError{action.API, action.Platform}
+	return gin.H{}, &NotFoundError{api: action.API, platform: action.Platform}
 }
 
 // RegisterHandler 注册特定资源与方法的处理函数
@@ -297,11 +353,11 @@ func AuthenticateMiddleware(realm string) gin.HandlerFunc {
 					`Bearer realm="%s", error="%s", error_description="%s", error_url="%s"`,
 					realm,
 					err.Error(),
-					"authorize failed with token: "+authorization,
+					"authorize failed",
 					`https://satori.js.org/zh-CN/protocol/api.html#%E9%89%B4%E6%9D%83`,
 				),
 			)
-			c.String(http.StatusUnauthorized, "authorize failed with token: "+authorization)
+			c.String(http.StatusUnauthorized, "authorize failed")
 			c.Abort()
 			return
 		}
@@ -355,12 +411,28 @@ func ProxyValidateMiddleware() gin.HandlerFunc {
 					c.Abort()
 					return
 				}
+
+				// 携带有效令牌的 Satori 应用无需签名，其余请求需要校验链接签名
+				if !authorizedWithToken(c.GetHeader("Authorization")) {
+					if err := fileserver.VerifyInternalURL(urlParam, c.Request.URL.Query()); err != nil {
+						log.Debugf("文件链接校验失败: %s", err)
+						if errors.Is(err, fileserver.ErrURLExpired) || errors.Is(err, fileserver.ErrURLUsed) {
+							c.String(http.StatusGone, "url is no longer available")
+						} else {
+							c.String(http.StatusForbidden, "invalid url signature")
+						}
+						c.Abort()
+						return
+					}
+				}
 			} else {
 				c.String(http.StatusBadRequest, "invalid internal url")
 				c.Abort()
 				return
 			}
 		} else {
+			urlParam = normalizeProxyURL(urlParam, c.Request.URL.RawQuery)
+
 			// 验证是否为合法的 URL
 			if _, err := url.ParseRequestURI(urlParam); err != nil {
 				c.String(http.StatusBadRequest, "invalid url")
@@ -403,7 +475,8 @@ func resourceAPIHandler(c *gin.Context, api, apiV2 openapi.OpenAPI) {
 	// 调用 API
 	response, err := CallAPI(api, apiV2, actionMessage)
 	if err != nil {
-		switch err.(type) {
+		err = asTooManyRequests(err)
+		switch err := err.(type) {
 		case *BadRequestError:
 			c.String(http.StatusBadRequest, err.Error())
 		case *UnauthorizedError:
@@ -414,6 +487,13 @@ func resourceAPIHandler(c *gin.Context, api, apiV2 openapi.OpenAPI) {
 			c.String(http.StatusNotFound, err.Error())
 		case *MethodNotAllowedError:
 			c.String(http.StatusMethodNotAllowed, err.Error())
+		case *PayloadTooLargeError:
+			c.String(http.StatusRequestEntityTooLarge, err.Error())
+		case *TooManyRequestsError:
+			if err.retryAfter > 0 {
+				c.Header("Retry-After", retryAfterSeconds(err.retryAfter))
+			}
+			c.String(http.StatusTooManyRequests, err.Error())
 		case *InternalServerError:
 			c.String(http.StatusInternalServerError, err.Error())
 		default:
@@ -455,6 +535,8 @@ func metaAPIHandler(c *gin.Context) {
 			c.String(http.StatusNotFound, err.Error())
 		case *MethodNotAllowedError:
 			c.String(http.StatusMethodNotAllowed, err.Error())
+		case *PayloadTooLargeError:
+			c.String(http.StatusRequestEntityTooLarge, err.Error())
 		case *InternalServerError:
 			c.String(http.StatusInternalServerError, err.Error())
 		default:
@@ -481,21 +563,35 @@ func proxyHandler(c *gin.Context, satoriVersion string) {
 	// 去除开头斜线
 	urlParam = strings.TrimPrefix(urlParam, "/")
 
+	// 设置响应头
+	c.Header("Date", time.Now().Format(time.RFC1123))
+	c.Header("Server", fmt.Sprintf("GlycCat/%s", version.Version))
+	c.Header("X-Satori-Protocol", satoriVersion)
+
 	// 解析内部链接
 	if _, _, path, ok := fileserver.ParseInternalURL(urlParam); ok {
-		if filePath, err := fileserver.GetPath(path); err == nil {
+		filePath, err := fileserver.GetPath(path)
+		if err != nil {
+			c.String(http.StatusNotFound, "file not found")
+			return
+		}
 
-			// 设置响应头
-			c.Header("Date", time.Now().Format(time.RFC1123))
-			c.Header("Server", fmt.Sprintf("GlycCat/%s", version.Version))
-			c.Header("X-Satori-Protocol", satoriVersion)
+		// 设置文件路径
+		c.File(filePath)
+		return
+	}
 
-			// 设置文件路径
-			c.File(filePath)
+	// 代理外部链接
+	proxyExternal(c, normalizeProxyURL(urlParam, c.Request.URL.RawQuery))
+}
 
-			return
-		}
+// authorizedWithToken 是否携带了已配置的有效令牌
+func authorizedWithToken(authorization string) bool {
+	if config.GetSatoriToken() == "" {
+		return false
 	}
+	ok, _ := authorize(authorization)
+	return ok
 }
 
 // authorize 鉴权
@@ -0,0 +1,57 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func TestAPIHandlerStatusCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		err  APIError
+		want int
+	}{
+		{"bad request", &BadRequestError{errors.New("bad")}, http.StatusBadRequest},
+		{"not found", &NotFoundError{api: "test"}, http.StatusNotFound},
+		{"payload too large", &PayloadTooLargeError{errors.New("too large")}, http.StatusRequestEntityTooLarge},
+		{"internal", &InternalServerError{errors.New("internal")}, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			RegisterHandler("test.status", func(api, apiV2 openapi.OpenAPI, action *ActionMessage) (any, APIError) {
+				return nil, tt.err
+			})
+			RegisterMetaHandler("test.status", func(action *MetaActionMessage) (any, APIError) {
+				return nil, tt.err
+			})
+			defer delete(handlers, "test.status")
+			defer delete(metaHandlers, "meta/test.status")
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/test.status", nil)
+			c.Params = gin.Params{{Key: "method", Value: "test.status"}}
+			resourceAPIHandler(c, nil, nil)
+			if w.Code != tt.want {
+				t.Errorf("resourceAPIHandler() status = %d, want %d", w.Code, tt.want)
+			}
+
+			w = httptest.NewRecorder()
+			c, _ = gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/meta/test.status", nil)
+			c.Params = gin.Params{{Key: "method", Value: "/test.status"}}
+			metaAPIHandler(c)
+			if w.Code != tt.want {
+				t.Errorf("metaAPIHandler() status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
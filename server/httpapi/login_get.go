@@ -1,8 +1,6 @@
 package httpapi
 
 import (
-	"context"
-
 	"github.com/WindowsSov8forUs/glyccat/processor"
 	"github.com/gin-gonic/gin"
 
@@ -24,7 +22,7 @@ func HandleLoginGet(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, AP
 	var response ResponseLoginGet
 
 	var me *dto.User
-	me, err := api.Me(context.TODO())
+	me, err := api.Me(message.Context())
 	if err != nil {
 		return gin.H{}, &InternalServerError{err}
 	}
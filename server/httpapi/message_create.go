@@ -53,12 +53,17 @@ func HandleMessageCreate(api, apiv2 openapi.OpenAPI, message *ActionMessage) (an
 			log.Infof("发送消息到频道 %s : %s", request.ChannelId, logContent(request.Content))
 
 			var dtoMessageToCreate = &dto.MessageToCreate{}
-			dtoMessageToCreate, err = convertToMessageToCreate(request.Content, message.Bot.Id, true)
+			var fileImage []byte
+			dtoMessageToCreate, fileImage, err = convertToMessageToCreate(request.Content, message.Bot.Id, true)
 			if err != nil {
 				return gin.H{}, &InternalServerError{err}
 			}
 			var dtoMessage *dto.Message
-			dtoMessage, err = api.PostMessage(context.TODO(), request.ChannelId, dtoMessageToCreate)
+			if len(fileImage) > 0 {
+				dtoMessage, err = api.PostMessageMultipart(message.Context(), request.ChannelId, dtoMessageToCreate, fileImage)
+			} else {
+				dtoMessage, err = api.PostMessage(message.Context(), request.ChannelId, dtoMessageToCreate)
+			}
 			if err != nil {
 				return gin.H{}, &InternalServerError{err}
 			}
@@ -73,14 +78,19 @@ func HandleMessageCreate(api, apiv2 openapi.OpenAPI, message *ActionMessage) (an
 
 			var dtoMessageToCreate = &dto.MessageToCreate{}
 			var dtoDirectMessage = &dto.DirectMessage{}
-			dtoMessageToCreate, err = convertToMessageToCreate(request.Content, message.Bot.Id, false)
+			var fileImage []byte
+			dtoMessageToCreate, fileImage, err = convertToMessageToCreate(request.Content, message.Bot.Id, false)
 			if err != nil {
 				return gin.H{}, &InternalServerError{err}
 			}
 			dtoDirectMessage.ChannelID = request.ChannelId
 			dtoDirectMessage.GuildID = guildId
 			var dtoMessage *dto.Message
-			dtoMessage, err = api.PostDirectMessage(context.TODO(), dtoDirectMessage, dtoMessageToCreate)
+			if len(fileImage) > 0 {
+				dtoMessage, err = api.PostDirectMessageMultipart(message.Context(), dtoDirectMessage, dtoMessageToCreate, fileImage)
+			} else {
+				dtoMessage, err = api.PostDirectMessage(message.Context(), dtoDirectMessage, dtoMessageToCreate)
+			}
 			if err != nil {
 				return gin.H{}, &InternalServerError{err}
 			}
@@ -103,12 +113,31 @@ func HandleMessageCreate(api, apiv2 openapi.OpenAPI, message *ActionMessage) (an
 
 			// 是私聊频道
 			var dtoMessageToCreate = &dto.MessageToCreate{}
-			dtoMessageToCreate, err = convertToMessageToCreateV2(request.Content, request.ChannelId, openIdType, apiv2)
+			dtoMessageToCreate, err = convertToMessageToCreateV2(message.Context(), request.Content, request.ChannelId, openIdType, apiv2)
 			if err != nil {
 				return gin.H{}, &InternalServerError{err}
 			}
+
+			// 追加的流式消息分片沿用流式消息的被动回复，不再占用新的回复次数
+			streamElement := findStreamElement(request.Content)
+			if streamElement == nil || !isStreamAppend(streamElement) {
+				if apiErr := attachPassiveReply(request.ChannelId, dtoMessageToCreate); apiErr != nil {
+					return gin.H{}, apiErr
+				}
+			}
+
+			// 含有流式消息元素时作为流式消息分片发送
+			if streamElement != nil {
+				messageResponse, apiErr := sendStreamElement(message.Context(), api, streamOwner(message), request.ChannelId, streamElement, dtoMessageToCreate)
+				if apiErr != nil {
+					return gin.H{}, apiErr
+				}
+				response = append(response, *messageResponse)
+				return response, nil
+			}
+
 			var dtoC2CMessageResponse *dto.C2CMessageResponse
-			dtoC2CMessageResponse, err = api.PostC2CMessage(context.TODO(), request.ChannelId, dtoMessageToCreate)
+			dtoC2CMessageResponse, err = api.PostC2CMessage(message.Context(), request.ChannelId, dtoMessageToCreate)
 			if err != nil {
 				return gin.H{}, &InternalServerError{err}
 			}
@@ -125,12 +154,15 @@ func HandleMessageCreate(api, apiv2 openapi.OpenAPI, message *ActionMessage) (an
 			log.Infof("发送消息到群 %s : %s", request.ChannelId, logContent(request.Content))
 
 			var dtoMessageToCreate = &dto.MessageToCreate{}
-			dtoMessageToCreate, err = convertToMessageToCreateV2(request.Content, request.ChannelId, openIdType, apiv2)
+			dtoMessageToCreate, err = convertToMessageToCreateV2(message.Context(), request.Content, request.ChannelId, openIdType, apiv2)
 			if err != nil {
 				return gin.H{}, &InternalServerError{err}
 			}
+			if apiErr := attachPassiveReply(request.ChannelId, dtoMessageToCreate); apiErr != nil {
+				return gin.H{}, apiErr
+			}
 			var dtoGroupMessageResponse *dto.GroupMessageResponse
-			dtoGroupMessageResponse, err = api.PostGroupMessage(context.TODO(), request.ChannelId, dtoMessageToCreate)
+			dtoGroupMessageResponse, err = api.PostGroupMessage(message.Context(), request.ChannelId, dtoMessageToCreate)
 			if err != nil {
 				return gin.H{}, &InternalServerError{err}
 			}
@@ -157,24 +189,27 @@ func logContent(content string) string {
 }
 
 // convertToMessageToCreate 转换为消息体结构
-func convertToMessageToCreate(content, userId string, isGuild bool) (*dto.MessageToCreate, error) {
+//
+// 图片无法通过本地文件服务器提供链接时，返回需要以 multipart 形式上传的图片数据
+func convertToMessageToCreate(content, userId string, isGuild bool) (*dto.MessageToCreate, []byte, error) {
 	// 将文本消息内容转换为 satoriMessage.MessageElement
 	elements, err := satoriMessage.Parse(content)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// 处理 satoriMessage.MessageElement
 	var dtoMessageToCreate = &dto.MessageToCreate{}
-	err = parseElementsInMessageToCreate(elements, dtoMessageToCreate, isGuild, userId)
+	var fileImage []byte
+	err = parseElementsInMessageToCreate(elements, dtoMessageToCreate, &fileImage, isGuild, userId)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return dtoMessageToCreate, nil
+	return dtoMessageToCreate, fileImage, nil
 }
 
 // parseElementsInMessageToCreate 将 Satori 消息元素转换为消息体结构
-func parseElementsInMessageToCreate(elements []satoriMessage.MessageElement, dtoMessageToCreate *dto.MessageToCreate, isGuild bool, userId string) error {
+func parseElementsInMessageToCreate(elements []satoriMessage.MessageElement, dtoMessageToCreate *dto.MessageToCreate, fileImage *[]byte, isGuild bool, userId string) error {
 	// 处理 satoriMessage.MessageElement
 	for _, element := range elements {
 		// 根据元素类型进行处理
@@ -198,7 +233,7 @@ func parseElementsInMessageToCreate(elements []satoriMessage.MessageElement, dto
 		case *satoriMessage.MessageElementA:
 			dtoMessageToCreate.Content += e.Href
 		case *satoriMessage.MessageElementImg:
-			if dtoMessageToCreate.Image != "" {
+			if dtoMessageToCreate.Image != "" || len(*fileImage) > 0 {
 				// 只支持发一张图片
 				// TODO: 多图片时分割发送
 				continue
@@ -214,6 +249,12 @@ func parseElementsInMessageToCreate(elements []satoriMessage.MessageElement, dto
 			if url != "" {
 				dtoMessageToCreate.Image = url
 			} else if file != nil {
+				// 本地文件服务器无法对外提供文件时以 multipart 形式上传图片
+				if !fileserver.Public() {
+					*fileImage = file.Data
+					continue
+				}
+
 				// 保存至文件服务器并放入资源链接
 				fileReader, err := file.GetReader()
 				if err != nil {
@@ -232,7 +273,7 @@ func parseElementsInMessageToCreate(elements []satoriMessage.MessageElement, dto
 				if e.Cache {
 					meta, err := fileserver.GetFile(ident)
 					if err == nil {
-						dtoMessageToCreate.Image = fileserver.InternalURL(meta)
+						dtoMessageToCreate.Image = fileserver.OpenPlatformURL(meta)
 						continue
 					}
 				}
@@ -245,10 +286,11 @@ func parseElementsInMessageToCreate(elements []satoriMessage.MessageElement, dto
 				}
 				meta, err := fileserver.SaveFile(fileReader, "qqguild", userId, e.Title, file.MimeType)
 				if err != nil {
-					log.Warnf("保存图片文件失败: %s", err)
+					log.Warnf("保存图片文件失败，将直接上传图片: %s", err)
+					*fileImage = file.Data
 					continue
 				}
-				dtoMessageToCreate.Image = fileserver.InternalURL(meta)
+				dtoMessageToCreate.Image = fileserver.OpenPlatformURL(meta)
 			} else {
 				log.Warnf("图片元素没有有效的 src 或文件")
 			}
@@ -264,38 +306,38 @@ func parseElementsInMessageToCreate(elements []satoriMessage.MessageElement, dto
 		// TODO: 修饰元素全部视为子元素集合，或许可以变成 dto.markdown ？
 		case *satoriMessage.MessageElementStrong:
 			// 递归调用
-			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, isGuild, userId)
+			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, fileImage, isGuild, userId)
 		case *satoriMessage.MessageElementEm:
 			// 递归调用
-			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, isGuild, userId)
+			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, fileImage, isGuild, userId)
 		case *satoriMessage.MessageElementIns:
 			// 递归调用
-			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, isGuild, userId)
+			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, fileImage, isGuild, userId)
 		case *satoriMessage.MessageElementDel:
 			// 递归调用
-			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, isGuild, userId)
+			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, fileImage, isGuild, userId)
 		case *satoriMessage.MessageElementSpl:
 			// 递归调用
-			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, isGuild, userId)
+			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, fileImage, isGuild, userId)
 		case *satoriMessage.MessageElementCode:
 			// 递归调用
-			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, isGuild, userId)
+			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, fileImage, isGuild, userId)
 		case *satoriMessage.MessageElementSup:
 			// 递归调用
-			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, isGuild, userId)
+			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, fileImage, isGuild, userId)
 		case *satoriMessage.MessageElementSub:
 			// 递归调用
-			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, isGuild, userId)
+			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, fileImage, isGuild, userId)
 		case *satoriMessage.MessageElmentBr:
 			dtoMessageToCreate.Content += "\n"
 		case *satoriMessage.MessageElmentP:
 			dtoMessageToCreate.Content += "\n"
 			// 视为子元素集合
-			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, isGuild, userId)
+			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, fileImage, isGuild, userId)
 			dtoMessageToCreate.Content += "\n"
 		case *satoriMessage.MessageElementMessage:
 			// 视为子元素集合，目前不支持视为转发消息
-			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, isGuild, userId)
+			parseElementsInMessageToCreate(e.GetChildren(), dtoMessageToCreate, fileImage, isGuild, userId)
 		case *satoriMessage.MessageElementQuote:
 			// 遍历子元素，只会处理第一个 satoriMessage.MessageElementMessage 元素
 			for _, child := range e.GetChildren() {
@@ -334,7 +376,7 @@ func parseElementsInMessageToCreate(elements []satoriMessage.MessageElement, dto
 }
 
 // convertToMessageToCreateV2 转换为 V2 消息体结构
-func convertToMessageToCreateV2(content string, openId string, messageType string, apiv2 openapi.OpenAPI) (*dto.MessageToCreate, error) {
+func convertToMessageToCreateV2(ctx context.Context, content string, openId string, messageType string, apiv2 openapi.OpenAPI) (*dto.MessageToCreate, error) {
 	// 将文本消息内容转换为 satoriMessage.MessageElement
 	elements, err := satoriMessage.Parse(content)
 	if err != nil {
@@ -343,7 +385,7 @@ func convertToMessageToCreateV2(content string, openId string, messageType strin
 
 	// 处理 satoriMessage.MessageElement
 	var dtoMessageToCreate = &dto.MessageToCreate{}
-	err = parseElementsInMessageToCreateV2(elements, dtoMessageToCreate, openId, messageType, apiv2)
+	err = parseElementsInMessageToCreateV2(ctx, elements, dtoMessageToCreate, openId, messageType, apiv2)
 	if err != nil {
 		return nil, err
 	}
@@ -352,7 +394,7 @@ func convertToMessageToCreateV2(content string, openId string, messageType strin
 }
 
 // parseElementsInMessageToCreateV2 将 Satori 消息元素转换为 V2 消息体结构
-func parseElementsInMessageToCreateV2(elements []satoriMessage.MessageElement, dtoMessageToCreate *dto.MessageToCreate, openId, messageType string, apiv2 openapi.OpenAPI) error {
+func parseElementsInMessageToCreateV2(ctx context.Context, elements []satoriMessage.MessageElement, dtoMessageToCreate *dto.MessageToCreate, openId, messageType string, apiv2 openapi.OpenAPI) error {
 	// 处理 satoriMessage.MessageElement
 	for _, element := range elements {
 		// 根据元素类型进行处理
@@ -384,7 +426,7 @@ func parseElementsInMessageToCreateV2(elements []satoriMessage.MessageElement, d
 				continue
 			}
 
-			if err := parseResourceElementInMTCV2(e, dtoMessageToCreate, openId, messageType, apiv2); err != nil {
+			if err := parseResourceElementInMTCV2(ctx, e, dtoMessageToCreate, openId, messageType, apiv2); err != nil {
 				return err
 			}
 		case *satoriMessage.MessageElementAudio:
@@ -393,7 +435,7 @@ func parseElementsInMessageToCreateV2(elements []satoriMessage.MessageElement, d
 				continue
 			}
 
-			if err := parseResourceElementInMTCV2(e, dtoMessageToCreate, openId, messageType, apiv2); err != nil {
+			if err := parseResourceElementInMTCV2(ctx, e, dtoMessageToCreate, openId, messageType, apiv2); err != nil {
 				return err
 			}
 		case *satoriMessage.MessageElementVideo:
@@ -402,7 +444,7 @@ func parseElementsInMessageToCreateV2(elements []satoriMessage.MessageElement, d
 				continue
 			}
 
-			if err := parseResourceElementInMTCV2(e, dtoMessageToCreate, openId, messageType, apiv2); err != nil {
+			if err := parseResourceElementInMTCV2(ctx, e, dtoMessageToCreate, openId, messageType, apiv2); err != nil {
 				return err
 			}
 		case *satoriMessage.MessageElementFile:
@@ -412,44 +454,44 @@ func parseElementsInMessageToCreateV2(elements []satoriMessage.MessageElement, d
 				continue
 			}
 
-			if err := parseResourceElementInMTCV2(e, dtoMessageToCreate, openId, messageType, apiv2); err != nil {
+			if err := parseResourceElementInMTCV2(ctx, e, dtoMessageToCreate, openId, messageType, apiv2); err != nil {
 				return err
 			}
 		// 修饰元素全部视为子元素集合，Markdown 是别想了
 		case *satoriMessage.MessageElementStrong:
 			// 递归调用
-			parseElementsInMessageToCreateV2(e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
+			parseElementsInMessageToCreateV2(ctx, e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
 		case *satoriMessage.MessageElementEm:
 			// 递归调用
-			parseElementsInMessageToCreateV2(e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
+			parseElementsInMessageToCreateV2(ctx, e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
 		case *satoriMessage.MessageElementIns:
 			// 递归调用
-			parseElementsInMessageToCreateV2(e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
+			parseElementsInMessageToCreateV2(ctx, e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
 		case *satoriMessage.MessageElementDel:
 			// 递归调用
-			parseElementsInMessageToCreateV2(e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
+			parseElementsInMessageToCreateV2(ctx, e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
 		case *satoriMessage.MessageElementSpl:
 			// 递归调用
-			parseElementsInMessageToCreateV2(e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
+			parseElementsInMessageToCreateV2(ctx, e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
 		case *satoriMessage.MessageElementCode:
 			// 递归调用
-			parseElementsInMessageToCreateV2(e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
+			parseElementsInMessageToCreateV2(ctx, e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
 		case *satoriMessage.MessageElementSup:
 			// 递归调用
-			parseElementsInMessageToCreateV2(e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
+			parseElementsInMessageToCreateV2(ctx, e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
 		case *satoriMessage.MessageElementSub:
 			// 递归调用
-			parseElementsInMessageToCreateV2(e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
+			parseElementsInMessageToCreateV2(ctx, e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
 		case *satoriMessage.MessageElmentBr:
 			dtoMessageToCreate.Content += "\n"
 		case *satoriMessage.MessageElmentP:
 			dtoMessageToCreate.Content += "\n"
 			// 视为子元素集合
-			parseElementsInMessageToCreateV2(e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
+			parseElementsInMessageToCreateV2(ctx, e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
 			dtoMessageToCreate.Content += "\n"
 		case *satoriMessage.MessageElementMessage:
 			// 视为子元素集合，目前不支持视为转发消息
-			parseElementsInMessageToCreateV2(e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
+			parseElementsInMessageToCreateV2(ctx, e.GetChildren(), dtoMessageToCreate, openId, messageType, apiv2)
 		case *satoriMessage.MessageElementQuote:
 			// 遍历子元素，只会处理第一个 satoriMessage.MessageElementMessage 元素
 			for _, child := range e.GetChildren() {
@@ -488,7 +530,7 @@ func parseElementsInMessageToCreateV2(elements []satoriMessage.MessageElement, d
 }
 
 // parseResourceElementInMTCV2 将 Satori 资源消息元素解析到 V2 消息体结构中
-func parseResourceElementInMTCV2(element satoriMessage.MessageElement, dtoMessageToCreate *dto.MessageToCreate, openId, messageType string, apiv2 openapi.OpenAPI) error {
+func parseResourceElementInMTCV2(ctx context.Context, element satoriMessage.MessageElement, dtoMessageToCreate *dto.MessageToCreate, openId, messageType string, apiv2 openapi.OpenAPI) error {
 	// TODO: 这里似乎应该将所有资源元素统一到一个子类型中，然后再细分
 	// TODO: 再说吧，需要改 satori-model-go 了
 
@@ -537,7 +579,7 @@ func parseResourceElementInMTCV2(element satoriMessage.MessageElement, dtoMessag
 	}
 
 	// 生成上传用富媒体结构
-	dtoRichMediaMessage, err := generateDtoRichMediaMessage(dtoMessageToCreate.MsgID, element)
+	dtoRichMediaMessage, err := generateDtoRichMediaMessage(ctx, dtoMessageToCreate.MsgID, element)
 	if err != nil {
 		log.Warnf("生成富媒体消息失败: %s", err)
 		return nil
@@ -546,12 +588,12 @@ func parseResourceElementInMTCV2(element satoriMessage.MessageElement, dtoMessag
 	// 上传富媒体
 	var mediaResponse *dto.MediaResponse
 	if messageType == "private" {
-		mediaResponse, err = uploadMediaPrivate(context.TODO(), openId, dtoRichMediaMessage, apiv2)
+		mediaResponse, err = uploadMediaPrivate(ctx, openId, dtoRichMediaMessage, apiv2)
 		if err != nil {
 			return err
 		}
 	} else {
-		mediaResponse, err = uploadMedia(context.TODO(), openId, dtoRichMediaMessage, apiv2)
+		mediaResponse, err = uploadMedia(ctx, openId, dtoRichMediaMessage, apiv2)
 		if err != nil {
 			return err
 		}
@@ -750,13 +792,13 @@ func uploadMediaPrivate(ctx context.Context, userID string, richMediaMessage *dt
 }
 
 // generateDtoRichMediaMessage 创建 dto.RichMediaMessage
-func generateDtoRichMediaMessage(id string, element satoriMessage.MessageElement) (*dto.RichMediaMessage, error) {
+func generateDtoRichMediaMessage(ctx context.Context, id string, element satoriMessage.MessageElement) (*dto.RichMediaMessage, error) {
 	var dtoRichMediaMessage *dto.RichMediaMessage
 
 	// 根据 element 的类型来创建 dto.RichMediaMessage
 	switch e := element.(type) {
 	case *satoriMessage.MessageElementImg:
-		url, fileData, err := processor.ParseSrcToAvailavle(e.Src)
+		url, fileData, err := processor.ParseSrcToAvailavle(ctx, e.Src)
 		if err != nil {
 			return nil, err
 		}
@@ -768,7 +810,7 @@ func generateDtoRichMediaMessage(id string, element satoriMessage.MessageElement
 			SrvSendMsg: false,
 		}
 	case *satoriMessage.MessageElementVideo:
-		url, fileData, err := processor.ParseSrcToAvailavle(e.Src)
+		url, fileData, err := processor.ParseSrcToAvailavle(ctx, e.Src)
 		if err != nil {
 			return nil, err
 		}
@@ -780,7 +822,7 @@ func generateDtoRichMediaMessage(id string, element satoriMessage.MessageElement
 			SrvSendMsg: false,
 		}
 	case *satoriMessage.MessageElementAudio:
-		url, fileData, err := processor.ParseSrcToAvailavle(e.Src)
+		url, fileData, err := processor.ParseSrcToAvailavle(ctx, e.Src)
 		if err != nil {
 			return nil, err
 		}
@@ -792,7 +834,7 @@ func generateDtoRichMediaMessage(id string, element satoriMessage.MessageElement
 			SrvSendMsg: false,
 		}
 	case *satoriMessage.MessageElementFile:
-		url, fileData, err := processor.ParseSrcToAvailavle(e.Src)
+		url, fileData, err := processor.ParseSrcToAvailavle(ctx, e.Src)
 		if err != nil {
 			return nil, err
 		}
@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/WindowsSov8forUs/glyccat/processor"
@@ -32,14 +31,14 @@ func HandleMessageDelete(api, apiv2 openapi.OpenAPI, message *ActionMessage) (an
 		guildId := processor.GetDirectChannelGuild(request.ChannelId)
 		if guildId == "" {
 			// 群组频道
-			err = apiv2.RetractMessage(context.TODO(), request.ChannelId, request.MessageId)
+			err = apiv2.RetractMessage(message.Context(), request.ChannelId, request.MessageId)
 			if err != nil {
 				return gin.H{}, &InternalServerError{err}
 			}
 			return gin.H{}, nil
 		} else {
 			// 私聊频道
-			err = apiv2.RetractDMMessage(context.TODO(), guildId, request.MessageId)
+			err = apiv2.RetractDMMessage(message.Context(), guildId, request.MessageId)
 			if err != nil {
 				return gin.H{}, &InternalServerError{err}
 			}
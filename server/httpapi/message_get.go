@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/WindowsSov8forUs/glyccat/database"
@@ -41,7 +40,7 @@ func HandleMessageGet(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any,
 	if message.Platform == "qqguild" {
 		var response ResponseMessageGet
 		var dtoMessage *dto.Message
-		dtoMessage, err = apiv2.Message(context.TODO(), request.ChannelId, request.MessageId)
+		dtoMessage, err = apiv2.Message(message.Context(), request.ChannelId, request.MessageId)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
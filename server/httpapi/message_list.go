@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"sort"
@@ -84,7 +83,7 @@ func HandleMessageList(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any,
 		var response ResponseMessageList
 		var dtoMessages []*dto.Message
 
-		dtoMessages, err = apiv2.Messages(context.TODO(), request.ChannelId, createMessagesPager(&request))
+		dtoMessages, err = apiv2.Messages(message.Context(), request.ChannelId, createMessagesPager(&request))
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
@@ -168,6 +167,10 @@ func HandleMessageList(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any,
 		case DirectionAround:
 			queryDirection = database.QueryDirectionAround
 		}
+		// 单次获取的数量不超过消息数据库的限制
+		if limit := database.MessageLimit(); limit > 0 && request.Limit > limit {
+			request.Limit = limit
+		}
 		prevs, nexts, err := database.GetMessageList(request.ChannelId, channelType, request.Next, queryDirection, request.Limit)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
@@ -1,9 +1,9 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
+	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/processor"
 	"github.com/gin-gonic/gin"
 	"github.com/tencent-connect/botgo/dto"
@@ -32,15 +32,20 @@ func HandleMessageUpdate(api, apiv2 openapi.OpenAPI, message *ActionMessage) (an
 	if message.Platform == "qqguild" {
 		var dtoMessageToCreate = &dto.MessageToCreate{}
 		guildId := processor.GetDirectChannelGuild(request.ChannelId)
+		var fileImage []byte
 		if guildId == "" {
-			dtoMessageToCreate, err = convertToMessageToCreate(request.Content, message.Bot.Id, true)
+			dtoMessageToCreate, fileImage, err = convertToMessageToCreate(request.Content, message.Bot.Id, true)
 		} else {
-			dtoMessageToCreate, err = convertToMessageToCreate(request.Content, message.Bot.Id, false)
+			dtoMessageToCreate, fileImage, err = convertToMessageToCreate(request.Content, message.Bot.Id, false)
 		}
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
-		_, err := apiv2.PatchMessage(context.TODO(), request.ChannelId, request.MessageId, dtoMessageToCreate)
+		if len(fileImage) > 0 {
+			// 编辑消息不支持以 multipart 形式上传图片
+			log.Warn("编辑消息时无法上传本地图片，已忽略图片。")
+		}
+		_, err := apiv2.PatchMessage(message.Context(), request.ChannelId, request.MessageId, dtoMessageToCreate)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
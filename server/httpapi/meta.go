@@ -3,6 +3,8 @@ package httpapi
 import (
 	"encoding/json"
 
+	"github.com/WindowsSov8forUs/glyccat/config"
+	"github.com/WindowsSov8forUs/glyccat/fileserver"
 	"github.com/WindowsSov8forUs/glyccat/processor"
 	"github.com/gin-gonic/gin"
 
@@ -14,6 +16,8 @@ func init() {
 	RegisterMetaHandler("", HandlerMeta)
 	RegisterMetaHandler("webhook.create", HandlerWebHookCreate)
 	RegisterMetaHandler("webhook.delete", HandlerWebHookDelete)
+	RegisterMetaHandler("file.gc", HandlerFileGC)
+	RegisterMetaHandler("config.reload", HandlerConfigReload)
 }
 
 // MetaResponse 获取元信息响应
@@ -30,6 +34,17 @@ type WebHookDeleteRequest struct {
 	URL string `json:"url"` // WebHook 地址
 }
 
+// FileGCResponse 清理过期文件响应
+type FileGCResponse struct {
+	Collected *fileserver.GCStats `json:"collected"`  // 本次清理的统计
+	Total     *fileserver.GCStats `json:"total"`      // 累计清理的统计
+	UsedBytes int64               `json:"used_bytes"` // 已使用的存储空间
+	MaxBytes  int64               `json:"max_bytes"`  // 存储空间上限，为 0 时无上限
+}
+
+// ConfigReloadResponse 重新加载配置响应
+type ConfigReloadResponse config.ReloadResult
+
 // HandlerMeta 处理获取元信息请求
 func HandlerMeta(message *MetaActionMessage) (any, APIError) {
 	var response MetaResponse
@@ -82,3 +97,28 @@ func HandlerWebHookDelete(message *MetaActionMessage) (any, APIError) {
 
 	return gin.H{}, nil
 }
+
+// HandlerFileGC 处理清理过期文件请求
+func HandlerFileGC(message *MetaActionMessage) (any, APIError) {
+	var response FileGCResponse
+
+	collected, err := fileserver.RunGC()
+	if err != nil {
+		return gin.H{}, &InternalServerError{err}
+	}
+	response.Collected = collected
+	response.Total = fileserver.Stats()
+	response.UsedBytes, response.MaxBytes = fileserver.StorageUsage()
+
+	return response, nil
+}
+
+// HandlerConfigReload 处理重新加载配置请求
+func HandlerConfigReload(message *MetaActionMessage) (any, APIError) {
+	result, err := instance.configReloader.ReloadConfig()
+	if err != nil {
+		return gin.H{}, &InternalServerError{err}
+	}
+
+	return ConfigReloadResponse(*result), nil
+}
@@ -0,0 +1,43 @@
+package httpapi
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware 指标记录中间件
+//
+// kind 为 http_api 、meta 或 proxy ，未注册的接口统一记录为 unknown ，避免标签值过多
+func MetricsMiddleware(kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		method := metricsMethodLabel(kind, c.Param("method"))
+		metrics.HTTPRequests.WithLabelValues(method, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.ObserveSince(metrics.HTTPRequestDuration, start, method)
+	}
+}
+
+// metricsMethodLabel 获取接口在指标中的名称
+func metricsMethodLabel(kind, method string) string {
+	switch kind {
+	case "http_api":
+		if _, ok := handlers[method]; ok {
+			return method
+		}
+	case "meta":
+		if method == "/" {
+			method = ""
+		}
+		if _, ok := metaHandlers["meta"+method]; ok {
+			return "meta" + method
+		}
+	case "proxy":
+		return "proxy"
+	}
+	return "unknown"
+}
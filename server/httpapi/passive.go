@@ -0,0 +1,43 @@
+package httpapi
+
+import (
+	"fmt"
+
+	"github.com/WindowsSov8forUs/glyccat/config"
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/WindowsSov8forUs/glyccat/processor"
+	"github.com/tencent-connect/botgo/dto"
+)
+
+// attachPassiveReply 为群聊与单聊消息附加被动回复的消息或事件 ID 与消息序号
+//
+// 消息中有 qq:passive 元素时只为其分配消息序号，否则使用频道中最近收到的消息或事件。
+// 没有可以被动回复的消息或事件时，按照配置发送主动消息或返回错误
+func attachPassiveReply(channelId string, dtoMessageToCreate *dto.MessageToCreate) APIError {
+	passive := config.GetPassiveConfig()
+	if !passive.Auto {
+		return nil
+	}
+
+	if dtoMessageToCreate.MsgID != "" {
+		dtoMessageToCreate.MsgSeq = processor.ReservePassiveSeq(dtoMessageToCreate.MsgID, dtoMessageToCreate.MsgSeq)
+		return nil
+	}
+	if dtoMessageToCreate.EventID != "" {
+		dtoMessageToCreate.MsgSeq = processor.ReservePassiveSeq(dtoMessageToCreate.EventID, dtoMessageToCreate.MsgSeq)
+		return nil
+	}
+
+	if reply, ok := processor.NextPassiveReply(channelId); ok {
+		dtoMessageToCreate.MsgID = reply.MsgID
+		dtoMessageToCreate.EventID = reply.EventID
+		dtoMessageToCreate.MsgSeq = reply.MsgSeq
+		return nil
+	}
+
+	if passive.Fallback == config.PassiveFallbackError {
+		return &ForbiddenError{fmt.Sprintf("no message or event in channel %s can be passively replied to, the reply window has closed", channelId)}
+	}
+	log.Debugf("频道 %s 中没有可以被动回复的消息或事件，将发送主动消息", channelId)
+	return nil
+}
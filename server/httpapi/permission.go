@@ -0,0 +1,137 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/tencent-connect/botgo/dto"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+const (
+	apiPermissionTTL       = 5 * time.Minute // 权限列表缓存有效期
+	apiPermissionFailedTTL = 1 * time.Minute // 获取权限列表失败时的缓存有效期
+)
+
+// apiPermissionIdentifies Satori API 与其调用的 QQ 频道 API 的对应关系
+//
+// 只记录以群组为单位进行权限校验的 API
+var apiPermissionIdentifies = map[string]*dto.APIPermissionDemandIdentify{
+	"guild.get":               {Method: http.MethodGet, Path: "/guilds/{guild_id}"},
+	"guild.member.get":        {Method: http.MethodGet, Path: "/guilds/{guild_id}/members/{user_id}"},
+	"guild.member.list":       {Method: http.MethodGet, Path: "/guilds/{guild_id}/members"},
+	"guild.member.kick":       {Method: http.MethodDelete, Path: "/guilds/{guild_id}/members/{user_id}"},
+	"guild.member.mute":       {Method: http.MethodPatch, Path: "/guilds/{guild_id}/members/{user_id}/mute"},
+	"guild.member.mute.batch": {Method: http.MethodPatch, Path: "/guilds/{guild_id}/mute"},
+	"guild.mute":              {Method: http.MethodPatch, Path: "/guilds/{guild_id}/mute"},
+	"guild.member.role.set":   {Method: http.MethodPut, Path: "/guilds/{guild_id}/members/{user_id}/roles/{role_id}"},
+	"guild.member.role.unset": {Method: http.MethodDelete, Path: "/guilds/{guild_id}/members/{user_id}/roles/{role_id}"},
+	"guild.role.list":         {Method: http.MethodGet, Path: "/guilds/{guild_id}/roles"},
+	"guild.role.create":       {Method: http.MethodPost, Path: "/guilds/{guild_id}/roles"},
+	"guild.role.update":       {Method: http.MethodPatch, Path: "/guilds/{guild_id}/roles/{role_id}"},
+	"guild.role.delete":       {Method: http.MethodDelete, Path: "/guilds/{guild_id}/roles/{role_id}"},
+	"channel.list":            {Method: http.MethodGet, Path: "/guilds/{guild_id}/channels"},
+	"channel.create":          {Method: http.MethodPost, Path: "/guilds/{guild_id}/channels"},
+}
+
+// guildAPIPermissions 单个群组的 API 权限
+type guildAPIPermissions struct {
+	permissions map[string]*dto.APIPermission // 以 "METHOD path" 为键的权限列表
+	expireAt    time.Time                     // 过期时间
+}
+
+// APIPermissionCache 群组 API 权限缓存
+type APIPermissionCache struct {
+	mapping map[string]*guildAPIPermissions
+	mu      sync.Mutex
+}
+
+var globalAPIPermissionCache = &APIPermissionCache{
+	mapping: make(map[string]*guildAPIPermissions),
+}
+
+// apiPermissionKey 生成权限键
+func apiPermissionKey(method, path string) string {
+	return method + " " + path
+}
+
+// SetAPIPermissions 设置群组的 API 权限列表
+func SetAPIPermissions(guildId string, permissions *dto.APIPermissions) {
+	entry := &guildAPIPermissions{
+		permissions: make(map[string]*dto.APIPermission),
+		expireAt:    time.Now().Add(apiPermissionTTL),
+	}
+	if permissions != nil {
+		for _, permission := range permissions.APIList {
+			entry.permissions[apiPermissionKey(permission.Method, permission.Path)] = permission
+		}
+	}
+
+	globalAPIPermissionCache.mu.Lock()
+	defer globalAPIPermissionCache.mu.Unlock()
+	globalAPIPermissionCache.mapping[guildId] = entry
+}
+
+// InvalidateAPIPermissions 使群组的 API 权限缓存失效
+func InvalidateAPIPermissions(guildId string) {
+	globalAPIPermissionCache.mu.Lock()
+	defer globalAPIPermissionCache.mu.Unlock()
+	delete(globalAPIPermissionCache.mapping, guildId)
+}
+
+// getAPIPermissions 获取未过期的群组 API 权限列表
+func getAPIPermissions(guildId string) (*guildAPIPermissions, bool) {
+	globalAPIPermissionCache.mu.Lock()
+	defer globalAPIPermissionCache.mu.Unlock()
+
+	entry, ok := globalAPIPermissionCache.mapping[guildId]
+	if !ok || time.Now().After(entry.expireAt) {
+		return nil, false
+	}
+	return entry, true
+}
+
+// checkAPIPermission 在调用 QQ 开放平台前检查机器人是否拥有对应的 API 权限
+//
+// 权限列表获取失败时不进行拦截，交由开放平台返回实际结果
+func checkAPIPermission(ctx context.Context, apiv2 openapi.OpenAPI, api, guildId string) APIError {
+	identify, ok := apiPermissionIdentifies[api]
+	if !ok || guildId == "" {
+		return nil
+	}
+
+	entry, ok := getAPIPermissions(guildId)
+	if !ok {
+		permissions, err := apiv2.GetAPIPermissions(ctx, guildId)
+		if err != nil {
+			log.Debugf("获取群组 %s 的 API 权限列表失败: %v", guildId, err)
+
+			// 短时间内不再重复获取
+			globalAPIPermissionCache.mu.Lock()
+			globalAPIPermissionCache.mapping[guildId] = &guildAPIPermissions{
+				permissions: make(map[string]*dto.APIPermission),
+				expireAt:    time.Now().Add(apiPermissionFailedTTL),
+			}
+			globalAPIPermissionCache.mu.Unlock()
+			return nil
+		}
+		SetAPIPermissions(guildId, permissions)
+		entry, _ = getAPIPermissions(guildId)
+	}
+	if entry == nil {
+		return nil
+	}
+
+	permission, ok := entry.permissions[apiPermissionKey(identify.Method, identify.Path)]
+	if !ok || permission.AuthStatus == 1 {
+		return nil
+	}
+	return &ForbiddenError{fmt.Sprintf(
+		`missing api permission "%s %s" (%s) in guild %s, use "qqguild.permission.request" to request authorization`,
+		permission.Method, permission.Path, permission.Desc, guildId,
+	)}
+}
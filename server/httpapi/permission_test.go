@@ -0,0 +1,68 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/tencent-connect/botgo/dto"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+// fakePermissionAPI 返回固定的 API 权限列表
+type fakePermissionAPI struct {
+	openapi.OpenAPI
+	permissions *dto.APIPermissions
+	err         error
+	calls       int
+}
+
+func (api *fakePermissionAPI) GetAPIPermissions(ctx context.Context, guildID string) (*dto.APIPermissions, error) {
+	api.calls++
+	return api.permissions, api.err
+}
+
+func TestCheckAPIPermission(t *testing.T) {
+	permissions := &dto.APIPermissions{APIList: []*dto.APIPermission{
+		{Method: http.MethodGet, Path: "/guilds/{guild_id}/members", AuthStatus: 1},
+		{Method: http.MethodDelete, Path: "/guilds/{guild_id}/members/{user_id}", AuthStatus: 0},
+	}}
+
+	tests := []struct {
+		name      string
+		api       string
+		guildId   string
+		fetchErr  error
+		wantCode  int
+		wantCalls int
+	}{
+		{"authorized", "guild.member.list", "permission-guild-1", nil, 0, 1},
+		{"unauthorized", "guild.member.kick", "permission-guild-2", nil, http.StatusForbidden, 1},
+		{"not listed", "guild.role.list", "permission-guild-3", nil, 0, 1},
+		{"not guild api", "message.create", "permission-guild-4", nil, 0, 0},
+		{"no guild", "guild.member.kick", "", nil, 0, 0},
+		{"fetch failed", "guild.member.kick", "permission-guild-5", errors.New("fetch failed"), 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer InvalidateAPIPermissions(tt.guildId)
+			api := &fakePermissionAPI{permissions: permissions, err: tt.fetchErr}
+
+			// 第二次检查使用缓存
+			for i := 0; i < 2; i++ {
+				err := checkAPIPermission(context.Background(), api, tt.api, tt.guildId)
+				switch {
+				case tt.wantCode == 0 && err != nil:
+					t.Errorf("checkAPIPermission() error = %v, want nil", err)
+				case tt.wantCode != 0 && (err == nil || err.Code() != tt.wantCode):
+					t.Errorf("checkAPIPermission() error = %v, want status %d", err, tt.wantCode)
+				}
+			}
+			if api.calls != tt.wantCalls {
+				t.Errorf("GetAPIPermissions() called %d times, want %d", api.calls, tt.wantCalls)
+			}
+		})
+	}
+}
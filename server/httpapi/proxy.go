@@ -1,17 +1,383 @@
 package httpapi
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/WindowsSov8forUs/glyccat/config"
+	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/processor"
+	"github.com/WindowsSov8forUs/glyccat/version"
+	"github.com/gin-gonic/gin"
 )
 
+const (
+	proxyCachePath       = "data/proxy"          // 代理资源缓存目录
+	proxyCacheSweepEvery = 10 * time.Minute      // 代理资源缓存清理间隔
+	proxyMaxRedirects    = 5                     // 代理请求最大重定向次数
+	proxyUserAgentFormat = "GlycCat/%s (+proxy)" // 代理请求的 User-Agent
+)
+
+// proxyRequestHeaders 转发至上游的请求头
+var proxyRequestHeaders = []string{
+	"Accept",
+	"Accept-Language",
+	"Range",
+	"If-Range",
+	"If-None-Match",
+	"If-Modified-Since",
+}
+
+// proxyResponseHeaders 转发至客户端的响应头
+var proxyResponseHeaders = []string{
+	"Content-Type",
+	"Content-Length",
+	"Content-Range",
+	"Content-Disposition",
+	"Accept-Ranges",
+	"Cache-Control",
+	"ETag",
+	"Last-Modified",
+	"Expires",
+}
+
+// proxyTransport 代理请求使用的连接池，不自动解压以保证 Range 与 Content-Length 正确
+var proxyTransport = &http.Transport{
+	Proxy:                 http.ProxyFromEnvironment,
+	DisableCompression:    true,
+	MaxIdleConnsPerHost:   8,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ResponseHeaderTimeout: 30 * time.Second,
+}
+
+// schemeSlashPattern 匹配被合并为单斜线的协议头
+var schemeSlashPattern = regexp.MustCompile(`^(https?):/+`)
+
+// proxyCacheSweep 代理资源缓存的上次清理时间
+var proxyCacheSweep = struct {
+	last time.Time
+	mu   sync.Mutex
+}{}
+
+// proxyCacheMeta 代理资源缓存元数据
+type proxyCacheMeta struct {
+	URL                string `json:"url"`                           // 资源链接
+	ContentType        string `json:"content_type,omitempty"`        // 资源类型
+	ContentDisposition string `json:"content_disposition,omitempty"` // 资源描述
+	ETag               string `json:"etag,omitempty"`                // 资源标签
+	LastModified       string `json:"last_modified,omitempty"`       // 资源修改时间
+	StoredAt           int64  `json:"stored_at"`                     // 缓存时间戳
+}
+
 // couldBeProxied 是否可代理
-func couldBeProxied(url string) bool {
+func couldBeProxied(rawURL string) bool {
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Host == "" {
+		return false
+	}
 	for _, proxyUrl := range processor.ProxyUrls() {
-		if strings.HasPrefix(url, proxyUrl) {
+		if matchProxyPrefix(target, proxyUrl) {
 			return true
 		}
 	}
 	return false
 }
+
+// matchProxyPrefix 判断链接是否符合代理前缀
+//
+// 协议与主机需要完全一致，路径需要在分段边界上匹配前缀，避免 example.com.attacker.net 之类的链接通过校验
+func matchProxyPrefix(target *url.URL, prefix string) bool {
+	allowed, err := url.Parse(prefix)
+	if err != nil || allowed.Host == "" {
+		return false
+	}
+	if !strings.EqualFold(target.Scheme, allowed.Scheme) || !strings.EqualFold(target.Host, allowed.Host) {
+		return false
+	}
+
+	// 清理路径中的 . 与 .. ，避免越过前缀路径
+	targetPath := path.Clean("/" + target.Path)
+	allowedPath := strings.TrimSuffix(allowed.Path, "/")
+	return allowedPath == "" || targetPath == allowedPath || strings.HasPrefix(targetPath, allowedPath+"/")
+}
+
+// normalizeProxyURL 还原代理路由中的外部链接
+//
+// 路由参数中的 :// 可能被合并为 :/ ，查询参数则位于代理路由本身的查询字符串中
+func normalizeProxyURL(urlParam, rawQuery string) string {
+	urlParam = schemeSlashPattern.ReplaceAllString(urlParam, "$1://")
+	if rawQuery != "" {
+		urlParam += "?" + rawQuery
+	}
+	return urlParam
+}
+
+// proxyExternal 代理外部链接
+func proxyExternal(c *gin.Context, target string) {
+	conf := config.GetProxyConfig()
+	cacheable := conf.Cache && c.GetHeader("Range") == ""
+
+	// 优先使用缓存
+	if conf.Cache && serveProxyCache(c, target, conf) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if conf.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(conf.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		c.String(http.StatusBadRequest, "invalid url")
+		return
+	}
+	for _, key := range proxyRequestHeaders {
+		if value := c.GetHeader(key); value != "" {
+			request.Header.Set(key, value)
+		}
+	}
+	request.Header.Set("User-Agent", fmt.Sprintf(proxyUserAgentFormat, version.Version))
+
+	client := &http.Client{
+		Transport: proxyTransport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= proxyMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", proxyMaxRedirects)
+			}
+			// 重定向目标同样需要符合代理前缀
+			if !couldBeProxied(req.URL.String()) {
+				return fmt.Errorf("redirect to %s is not allowed", req.URL.Redacted())
+			}
+			return nil
+		},
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		log.Warnf("代理请求 %s 失败: %v", target, err)
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			c.String(http.StatusGatewayTimeout, "upstream timeout")
+		} else {
+			c.String(http.StatusBadGateway, "upstream unavailable")
+		}
+		return
+	}
+	defer response.Body.Close()
+
+	if conf.MaxSize > 0 && response.ContentLength > int64(conf.MaxSize) {
+		log.Warnf("代理资源 %s 大小 %d 超过上限 %d", target, response.ContentLength, conf.MaxSize)
+		c.String(http.StatusBadGateway, "upstream resource too large")
+		return
+	}
+
+	var body io.Reader = response.Body
+	var bufferedLength int64 = -1
+	if conf.MaxSize > 0 && response.ContentLength < 0 {
+		// 长度未知时先读取至上限，超出上限时可以在写入响应头之前返回错误
+		buffered, err := io.ReadAll(io.LimitReader(response.Body, int64(conf.MaxSize)+1))
+		if err != nil {
+			log.Warnf("代理请求 %s 失败: %v", target, err)
+			c.String(http.StatusBadGateway, "upstream unavailable")
+			return
+		}
+		if int64(len(buffered)) > int64(conf.MaxSize) {
+			log.Warnf("代理资源 %s 超过大小上限 %d", target, conf.MaxSize)
+			c.String(http.StatusBadGateway, "upstream resource too large")
+			return
+		}
+		body = bytes.NewReader(buffered)
+		bufferedLength = int64(len(buffered))
+	} else if conf.MaxSize > 0 {
+		body = io.LimitReader(response.Body, int64(conf.MaxSize)+1)
+	}
+
+	for _, key := range proxyResponseHeaders {
+		if value := response.Header.Get(key); value != "" {
+			c.Header(key, value)
+		}
+	}
+	if bufferedLength >= 0 {
+		c.Header("Content-Length", strconv.FormatInt(bufferedLength, 10))
+	}
+	c.Status(response.StatusCode)
+
+	// 完整的响应同时写入缓存
+	var cacheFile *os.File
+	if cacheable && response.StatusCode == http.StatusOK {
+		cacheFile, err = createProxyCacheTemp()
+		if err != nil {
+			log.Warnf("创建代理资源缓存失败: %v", err)
+		} else {
+			body = io.TeeReader(body, cacheFile)
+		}
+	}
+
+	written, err := io.Copy(c.Writer, body)
+	truncated := conf.MaxSize > 0 && written > int64(conf.MaxSize)
+	if err != nil || truncated {
+		if truncated {
+			log.Warnf("代理资源 %s 超过大小上限 %d ，已截断", target, conf.MaxSize)
+		} else {
+			log.Debugf("代理资源 %s 传输中断: %v", target, err)
+		}
+		if cacheFile != nil {
+			cacheFile.Close()
+			os.Remove(cacheFile.Name())
+		}
+		return
+	}
+
+	if cacheFile != nil {
+		if err := storeProxyCache(cacheFile, target, response.Header); err != nil {
+			log.Warnf("保存代理资源缓存失败: %v", err)
+		}
+	}
+}
+
+// proxyCacheKey 代理资源缓存键
+func proxyCacheKey(target string) string {
+	hash := sha256.Sum256([]byte(target))
+	return hex.EncodeToString(hash[:])
+}
+
+// serveProxyCache 使用缓存响应代理请求，缓存不存在或已过期时返回 false
+func serveProxyCache(c *gin.Context, target string, conf config.Proxy) bool {
+	key := proxyCacheKey(target)
+	dataPath := filepath.Join(proxyCachePath, key)
+	metaPath := dataPath + ".json"
+
+	metaData, err := os.ReadFile(metaPath)
+	if err != nil {
+		return false
+	}
+	var meta proxyCacheMeta
+	if err := json.Unmarshal(metaData, &meta); err != nil || meta.URL != target {
+		return false
+	}
+	storedAt := time.Unix(meta.StoredAt, 0)
+	if conf.CacheTTL > 0 && time.Since(storedAt) > time.Duration(conf.CacheTTL)*time.Second {
+		os.Remove(dataPath)
+		os.Remove(metaPath)
+		return false
+	}
+
+	file, err := os.Open(dataPath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	if meta.ContentType != "" {
+		c.Header("Content-Type", meta.ContentType)
+	}
+	if meta.ContentDisposition != "" {
+		c.Header("Content-Disposition", meta.ContentDisposition)
+	}
+	if meta.ETag != "" {
+		c.Header("ETag", meta.ETag)
+	}
+	modTime := storedAt
+	if lastModified, err := http.ParseTime(meta.LastModified); err == nil {
+		modTime = lastModified
+	}
+
+	// 由 http.ServeContent 处理 Range 与条件请求
+	http.ServeContent(c.Writer, c.Request, "", modTime, file)
+	return true
+}
+
+// createProxyCacheTemp 创建代理资源缓存临时文件
+func createProxyCacheTemp() (*os.File, error) {
+	if err := os.MkdirAll(proxyCachePath, 0755); err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(proxyCachePath, "tmp-*")
+}
+
+// storeProxyCache 将临时文件保存为代理资源缓存
+func storeProxyCache(file *os.File, target string, header http.Header) error {
+	tempPath := file.Name()
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	key := proxyCacheKey(target)
+	dataPath := filepath.Join(proxyCachePath, key)
+	if err := os.Rename(tempPath, dataPath); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	meta := proxyCacheMeta{
+		URL:                target,
+		ContentType:        header.Get("Content-Type"),
+		ContentDisposition: header.Get("Content-Disposition"),
+		ETag:               header.Get("ETag"),
+		LastModified:       header.Get("Last-Modified"),
+		StoredAt:           time.Now().Unix(),
+	}
+	metaData, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dataPath+".json", metaData, 0644); err != nil {
+		return err
+	}
+
+	sweepProxyCache()
+	return nil
+}
+
+// sweepProxyCache 定期清理过期的代理资源缓存
+func sweepProxyCache() {
+	proxyCacheSweep.mu.Lock()
+	if time.Since(proxyCacheSweep.last) < proxyCacheSweepEvery {
+		proxyCacheSweep.mu.Unlock()
+		return
+	}
+	proxyCacheSweep.last = time.Now()
+	proxyCacheSweep.mu.Unlock()
+
+	ttl := time.Duration(config.GetProxyConfig().CacheTTL) * time.Second
+	if ttl == 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(proxyCachePath)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		// 临时文件与过期缓存均以修改时间判断
+		if time.Since(info.ModTime()) > ttl {
+			os.Remove(filepath.Join(proxyCachePath, entry.Name()))
+		}
+	}
+}
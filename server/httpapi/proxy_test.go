@@ -0,0 +1,38 @@
+package httpapi
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestMatchProxyPrefix(t *testing.T) {
+	tests := []struct {
+		target string
+		prefix string
+		want   bool
+	}{
+		{"https://example.com/a.png", "https://example.com", true},
+		{"https://example.com/a.png", "https://example.com/", true},
+		{"https://example.com.attacker.net/a.png", "https://example.com", false},
+		{"https://example.com@attacker.net/a.png", "https://example.com", false},
+		{"http://example.com/a.png", "https://example.com", false},
+		{"https://EXAMPLE.com/a.png", "https://example.com", true},
+		{"https://example.com:8443/a.png", "https://example.com", false},
+		{"https://example.com/files/a.png", "https://example.com/files/", true},
+		{"https://example.com/files/a.png", "https://example.com/files", true},
+		{"https://example.com/files", "https://example.com/files", true},
+		{"https://example.com/files-private/a.png", "https://example.com/files", false},
+		{"https://example.com/files/../secret", "https://example.com/files/", false},
+		{"https://example.com/files/%2e%2e/secret", "https://example.com/files/", false},
+	}
+
+	for _, tt := range tests {
+		target, err := url.Parse(tt.target)
+		if err != nil {
+			t.Fatalf("url.Parse(%q) error = %v", tt.target, err)
+		}
+		if got := matchProxyPrefix(target, tt.prefix); got != tt.want {
+			t.Errorf("matchProxyPrefix(%q, %q) = %v, want %v", tt.target, tt.prefix, got, tt.want)
+		}
+	}
+}
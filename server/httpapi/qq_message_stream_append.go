@@ -0,0 +1,52 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/gin-gonic/gin"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func init() {
+	RegisterHandler("qq.message.stream.append", HandleQQMessageStreamAppend)
+}
+
+// RequestQQMessageStreamAppend 追加流式消息分片请求
+type RequestQQMessageStreamAppend struct {
+	StreamId string `json:"stream_id"`       // 流 ID
+	Content  string `json:"content"`         // 分片内容
+	Index    *int   `json:"index,omitempty"` // 期望的分片序号，用于校验分片顺序
+}
+
+// HandleQQMessageStreamAppend 处理追加流式消息分片请求
+func HandleQQMessageStreamAppend(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
+	var request RequestQQMessageStreamAppend
+	err := json.Unmarshal(message.Data(), &request)
+	if err != nil {
+		return gin.H{}, &BadRequestError{err}
+	}
+
+	if message.Platform == "qq" {
+		// 输出日志
+		log.Debugf("追加流式消息分片 %s : %s", request.StreamId, logContent(request.Content))
+
+		chunk := &MessageStreamChunk{
+			Content: request.Content,
+			Index:   request.Index,
+		}
+		return sendMessageStreamChunk(message.Context(), api, streamOwner(message), request.StreamId, chunk)
+	}
+
+	return defaultResource(message)
+}
+
+// sendMessageStreamChunk 向已有的流式消息发送分片
+func sendMessageStreamChunk(ctx context.Context, api openapi.OpenAPI, owner, streamId string, chunk *MessageStreamChunk) (any, APIError) {
+	stream, dtoMessage, err := appendMessageStream(ctx, api, owner, streamId, chunk)
+	if err != nil {
+		return gin.H{}, convertStreamError(err)
+	}
+	return convertToResponseQQMessageStream(stream, dtoMessage)
+}
@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/WindowsSov8forUs/glyccat/processor"
+	"github.com/gin-gonic/gin"
+	satoriMessage "github.com/satori-protocol-go/satori-model-go/pkg/message"
+	"github.com/tencent-connect/botgo/dto"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func init() {
+	RegisterHandler("qq.message.stream.create", HandleQQMessageStreamCreate)
+}
+
+// RequestQQMessageStreamCreate 创建流式消息请求
+type RequestQQMessageStreamCreate struct {
+	ChannelId string   `json:"channel_id"`        // 私聊频道 ID
+	Content   string   `json:"content"`           // 首个分片内容
+	MsgId     string   `json:"msg_id,omitempty"`  // 被动回复的消息 ID
+	MsgSeq    int      `json:"msg_seq,omitempty"` // 首个分片的消息序号
+	Finish    bool     `json:"finish,omitempty"`  // 是否同时结束流式消息
+	Prompts   []string `json:"prompts,omitempty"` // 结束时附带的引导按钮
+}
+
+// ResponseQQMessageStream 流式消息响应
+type ResponseQQMessageStream struct {
+	StreamId string                 `json:"stream_id"` // 流 ID
+	Index    int                    `json:"index"`     // 本次发送的分片序号
+	Finished bool                   `json:"finished"`  // 流式消息是否已结束
+	Message  *satoriMessage.Message `json:"message"`   // 本次发送的消息
+}
+
+// HandleQQMessageStreamCreate 处理创建流式消息请求
+func HandleQQMessageStreamCreate(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
+	var request RequestQQMessageStreamCreate
+	err := json.Unmarshal(message.Data(), &request)
+	if err != nil {
+		return gin.H{}, &BadRequestError{err}
+	}
+
+	if message.Platform == "qq" {
+		if processor.GetOpenIdType(request.ChannelId) != "private" {
+			return gin.H{}, &BadRequestError{fmt.Errorf("stream message is only available in private channel")}
+		}
+
+		// 输出日志
+		log.Infof("发送流式消息到用户 %s : %s", request.ChannelId, logContent(request.Content))
+
+		chunk := &MessageStreamChunk{
+			Content: request.Content,
+			Finish:  request.Finish,
+			Prompts: request.Prompts,
+		}
+		stream, dtoMessage, err := openMessageStream(message.Context(), api, streamOwner(message), request.ChannelId, request.MsgId, request.MsgSeq, chunk)
+		if err != nil {
+			return gin.H{}, convertStreamError(err)
+		}
+		return convertToResponseQQMessageStream(stream, dtoMessage)
+	}
+
+	return defaultResource(message)
+}
+
+// convertToResponseQQMessageStream 转换为流式消息响应
+func convertToResponseQQMessageStream(stream *messageStream, dtoMessage *dto.Message) (*ResponseQQMessageStream, APIError) {
+	var response ResponseQQMessageStream
+
+	messageResponse, err := convertDtoMessageV2ToMessage(dtoMessage)
+	if err != nil {
+		return nil, &InternalServerError{err}
+	}
+	response.StreamId, response.Index, response.Finished = stream.state()
+	response.Message = messageResponse
+
+	return &response, nil
+}
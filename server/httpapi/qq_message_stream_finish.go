@@ -0,0 +1,45 @@
+package httpapi
+
+import (
+	"encoding/json"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/gin-gonic/gin"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func init() {
+	RegisterHandler("qq.message.stream.finish", HandleQQMessageStreamFinish)
+}
+
+// RequestQQMessageStreamFinish 结束流式消息请求
+type RequestQQMessageStreamFinish struct {
+	StreamId string   `json:"stream_id"`         // 流 ID
+	Content  string   `json:"content,omitempty"` // 最后一个分片的内容
+	Index    *int     `json:"index,omitempty"`   // 期望的分片序号，用于校验分片顺序
+	Prompts  []string `json:"prompts,omitempty"` // 引导按钮
+}
+
+// HandleQQMessageStreamFinish 处理结束流式消息请求
+func HandleQQMessageStreamFinish(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
+	var request RequestQQMessageStreamFinish
+	err := json.Unmarshal(message.Data(), &request)
+	if err != nil {
+		return gin.H{}, &BadRequestError{err}
+	}
+
+	if message.Platform == "qq" {
+		// 输出日志
+		log.Infof("结束流式消息 %s : %s", request.StreamId, logContent(request.Content))
+
+		chunk := &MessageStreamChunk{
+			Content: request.Content,
+			Index:   request.Index,
+			Finish:  true,
+			Prompts: request.Prompts,
+		}
+		return sendMessageStreamChunk(message.Context(), api, streamOwner(message), request.StreamId, chunk)
+	}
+
+	return defaultResource(message)
+}
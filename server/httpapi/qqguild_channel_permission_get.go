@@ -0,0 +1,148 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func init() {
+	RegisterHandler("qqguild.channel.permission.get", HandleQQGuildChannelPermissionGet)
+}
+
+// 子频道权限位
+const (
+	channelPermissionView   uint64 = 1 << iota // 可查看子频道
+	channelPermissionManage                    // 可管理子频道
+	channelPermissionSpeak                     // 可发言子频道
+	channelPermissionLive                      // 可直播子频道
+)
+
+// channelPermissionNames 子频道权限位与可读名称的对应关系
+var channelPermissionNames = []struct {
+	Name string
+	Bit  uint64
+}{
+	{"view", channelPermissionView},
+	{"manage", channelPermissionManage},
+	{"speak", channelPermissionSpeak},
+	{"live", channelPermissionLive},
+}
+
+// RequestQQGuildChannelPermissionGet 获取子频道权限请求
+type RequestQQGuildChannelPermissionGet struct {
+	ChannelId string `json:"channel_id"`        // 子频道 ID
+	UserId    string `json:"user_id,omitempty"` // 用户 ID ，与 role_id 二选一
+	RoleId    string `json:"role_id,omitempty"` // 身份组 ID ，与 user_id 二选一
+}
+
+// ResponseQQGuildChannelPermission 子频道权限响应
+type ResponseQQGuildChannelPermission struct {
+	ChannelId   string   `json:"channel_id"`        // 子频道 ID
+	UserId      string   `json:"user_id,omitempty"` // 用户 ID
+	RoleId      string   `json:"role_id,omitempty"` // 身份组 ID
+	Permissions string   `json:"permissions"`       // 权限位掩码
+	Flags       []string `json:"flags"`             // 可读的权限列表
+}
+
+// HandleQQGuildChannelPermissionGet 处理获取子频道权限请求
+func HandleQQGuildChannelPermissionGet(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
+	var request RequestQQGuildChannelPermissionGet
+	err := json.Unmarshal(message.Data(), &request)
+	if err != nil {
+		return gin.H{}, &BadRequestError{err}
+	}
+
+	if message.Platform == "qqguild" {
+		if err := validatePermissionTarget(request.UserId, request.RoleId); err != nil {
+			return gin.H{}, &BadRequestError{err}
+		}
+
+		var response ResponseQQGuildChannelPermission
+		var permissions string
+
+		if request.UserId != "" {
+			dtoPermissions, err := apiv2.ChannelPermissions(message.Context(), request.ChannelId, request.UserId)
+			if err != nil {
+				return gin.H{}, &InternalServerError{err}
+			}
+			response.UserId = dtoPermissions.UserID
+			permissions = dtoPermissions.Permissions
+		} else {
+			dtoPermissions, err := apiv2.ChannelRolesPermissions(message.Context(), request.ChannelId, request.RoleId)
+			if err != nil {
+				return gin.H{}, &InternalServerError{err}
+			}
+			response.RoleId = dtoPermissions.RoleID
+			permissions = dtoPermissions.Permissions
+		}
+
+		flags, err := parseChannelPermissions(permissions)
+		if err != nil {
+			return gin.H{}, &InternalServerError{err}
+		}
+		response.ChannelId = request.ChannelId
+		response.Permissions = permissions
+		response.Flags = flags
+
+		return response, nil
+	}
+
+	return defaultResource(message)
+}
+
+// validatePermissionTarget 校验权限操作对象，用户与身份组必须且只能指定一个
+func validatePermissionTarget(userId, roleId string) error {
+	if userId == "" && roleId == "" {
+		return fmt.Errorf("either user_id or role_id is required")
+	}
+	if userId != "" && roleId != "" {
+		return fmt.Errorf("user_id and role_id are mutually exclusive")
+	}
+	return nil
+}
+
+// parseChannelPermissions 将权限位掩码转换为可读的权限列表
+func parseChannelPermissions(permissions string) ([]string, error) {
+	flags := []string{}
+	if permissions == "" {
+		return flags, nil
+	}
+
+	mask, err := strconv.ParseUint(permissions, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid permissions %q: %w", permissions, err)
+	}
+	for _, item := range channelPermissionNames {
+		if mask&item.Bit != 0 {
+			flags = append(flags, item.Name)
+		}
+	}
+	return flags, nil
+}
+
+// formatChannelPermissions 将可读的权限列表转换为权限位掩码
+func formatChannelPermissions(flags []string) (string, error) {
+	if len(flags) == 0 {
+		return "", nil
+	}
+
+	var mask uint64
+	for _, flag := range flags {
+		found := false
+		for _, item := range channelPermissionNames {
+			if item.Name == flag {
+				mask |= item.Bit
+				found = true
+				break
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("unknown channel permission %q", flag)
+		}
+	}
+	return strconv.FormatUint(mask, 10), nil
+}
@@ -0,0 +1,75 @@
+package httpapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChannelPermissions(t *testing.T) {
+	tests := []struct {
+		permissions string
+		want        []string
+		wantErr     bool
+	}{
+		{"", []string{}, false},
+		{"0", []string{}, false},
+		{"1", []string{"view"}, false},
+		{"6", []string{"manage", "speak"}, false},
+		{"15", []string{"view", "manage", "speak", "live"}, false},
+		{"all", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseChannelPermissions(tt.permissions)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseChannelPermissions(%q) error = %v, wantErr %v", tt.permissions, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseChannelPermissions(%q) = %v, want %v", tt.permissions, got, tt.want)
+		}
+	}
+}
+
+func TestFormatChannelPermissions(t *testing.T) {
+	tests := []struct {
+		flags   []string
+		want    string
+		wantErr bool
+	}{
+		{nil, "", false},
+		{[]string{"view"}, "1", false},
+		{[]string{"speak", "manage", "speak"}, "6", false},
+		{[]string{"view", "manage", "speak", "live"}, "15", false},
+		{[]string{"admin"}, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := formatChannelPermissions(tt.flags)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("formatChannelPermissions(%v) error = %v, wantErr %v", tt.flags, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("formatChannelPermissions(%v) = %q, want %q", tt.flags, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePermissionTarget(t *testing.T) {
+	tests := []struct {
+		userId, roleId string
+		wantErr        bool
+	}{
+		{"user", "", false},
+		{"", "role", false},
+		{"", "", true},
+		{"user", "role", true},
+	}
+
+	for _, tt := range tests {
+		if err := validatePermissionTarget(tt.userId, tt.roleId); (err != nil) != tt.wantErr {
+			t.Errorf("validatePermissionTarget(%q, %q) error = %v, wantErr %v", tt.userId, tt.roleId, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tencent-connect/botgo/dto"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func init() {
+	RegisterHandler("qqguild.channel.permission.update", HandleQQGuildChannelPermissionUpdate)
+}
+
+// RequestQQGuildChannelPermissionUpdate 修改子频道权限请求
+type RequestQQGuildChannelPermissionUpdate struct {
+	ChannelId string   `json:"channel_id"`        // 子频道 ID
+	UserId    string   `json:"user_id,omitempty"` // 用户 ID ，与 role_id 二选一
+	RoleId    string   `json:"role_id,omitempty"` // 身份组 ID ，与 user_id 二选一
+	Add       []string `json:"add,omitempty"`     // 需要添加的权限
+	Remove    []string `json:"remove,omitempty"`  // 需要移除的权限
+}
+
+// HandleQQGuildChannelPermissionUpdate 处理修改子频道权限请求
+func HandleQQGuildChannelPermissionUpdate(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
+	var request RequestQQGuildChannelPermissionUpdate
+	err := json.Unmarshal(message.Data(), &request)
+	if err != nil {
+		return gin.H{}, &BadRequestError{err}
+	}
+
+	if message.Platform == "qqguild" {
+		if err := validatePermissionTarget(request.UserId, request.RoleId); err != nil {
+			return gin.H{}, &BadRequestError{err}
+		}
+
+		dtoUpdate, err := createUpdateChannelPermissions(&request)
+		if err != nil {
+			return gin.H{}, &BadRequestError{err}
+		}
+
+		if request.UserId != "" {
+			err = apiv2.PutChannelPermissions(message.Context(), request.ChannelId, request.UserId, dtoUpdate)
+		} else {
+			err = apiv2.PutChannelRolesPermissions(message.Context(), request.ChannelId, request.RoleId, dtoUpdate)
+		}
+		if err != nil {
+			return gin.H{}, &InternalServerError{err}
+		}
+
+		return gin.H{}, nil
+	}
+
+	return defaultResource(message)
+}
+
+// createUpdateChannelPermissions 创建 dto.UpdateChannelPermissions
+func createUpdateChannelPermissions(request *RequestQQGuildChannelPermissionUpdate) (*dto.UpdateChannelPermissions, error) {
+	add, err := formatChannelPermissions(request.Add)
+	if err != nil {
+		return nil, err
+	}
+	remove, err := formatChannelPermissions(request.Remove)
+	if err != nil {
+		return nil, err
+	}
+	if add == "" && remove == "" {
+		return nil, fmt.Errorf("either add or remove is required")
+	}
+	return &dto.UpdateChannelPermissions{
+		Add:    add,
+		Remove: remove,
+	}, nil
+}
@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func init() {
+	RegisterHandler("qqguild.permission.list", HandleQQGuildPermissionList)
+}
+
+// RequestQQGuildPermissionList 获取群组 API 权限列表请求
+type RequestQQGuildPermissionList struct {
+	GuildId string `json:"guild_id"` // 群组 ID
+}
+
+// QQGuildPermission 群组 API 权限
+type QQGuildPermission struct {
+	Method     string   `json:"method"`         // 请求方法
+	Path       string   `json:"path"`           // 接口路径
+	Desc       string   `json:"desc"`           // 接口描述
+	Authorized bool     `json:"authorized"`     // 是否已授权
+	APIs       []string `json:"apis,omitempty"` // 对应的 Satori API
+}
+
+// ResponseQQGuildPermissionList 获取群组 API 权限列表响应
+type ResponseQQGuildPermissionList struct {
+	Data []*QQGuildPermission `json:"data"` // 权限列表
+}
+
+// HandleQQGuildPermissionList 处理获取群组 API 权限列表请求
+func HandleQQGuildPermissionList(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
+	var request RequestQQGuildPermissionList
+	err := json.Unmarshal(message.Data(), &request)
+	if err != nil {
+		return gin.H{}, &BadRequestError{err}
+	}
+
+	if message.Platform == "qqguild" {
+		var response ResponseQQGuildPermissionList
+
+		dtoPermissions, err := apiv2.GetAPIPermissions(message.Context(), request.GuildId)
+		if err != nil {
+			return gin.H{}, &InternalServerError{err}
+		}
+
+		// 同时刷新权限缓存
+		SetAPIPermissions(request.GuildId, dtoPermissions)
+
+		response.Data = make([]*QQGuildPermission, 0, len(dtoPermissions.APIList))
+		for _, item := range dtoPermissions.APIList {
+			response.Data = append(response.Data, &QQGuildPermission{
+				Method:     item.Method,
+				Path:       item.Path,
+				Desc:       item.Desc,
+				Authorized: item.AuthStatus == 1,
+				APIs:       findSatoriAPIsByPermission(item.Method, item.Path),
+			})
+		}
+
+		return response, nil
+	}
+
+	return defaultResource(message)
+}
+
+// findSatoriAPIsByPermission 查找 QQ 频道 API 对应的 Satori API
+func findSatoriAPIsByPermission(method, path string) []string {
+	var apis []string
+	for api, identify := range apiPermissionIdentifies {
+		if identify.Method == method && identify.Path == path {
+			apis = append(apis, api)
+		}
+	}
+	sort.Strings(apis)
+	return apis
+}
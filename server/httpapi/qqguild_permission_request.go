@@ -0,0 +1,100 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tencent-connect/botgo/dto"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func init() {
+	RegisterHandler("qqguild.permission.request", HandleQQGuildPermissionRequest)
+}
+
+// RequestQQGuildPermissionRequest 发送 API 权限授权请求
+type RequestQQGuildPermissionRequest struct {
+	GuildId   string `json:"guild_id"`         // 群组 ID
+	ChannelId string `json:"channel_id"`       // 发送授权链接的子频道 ID
+	API       string `json:"api,omitempty"`    // 需要授权的 Satori API ，与 method 和 path 二选一
+	Method    string `json:"method,omitempty"` // 需要授权的接口请求方法
+	Path      string `json:"path,omitempty"`   // 需要授权的接口路径
+	Desc      string `json:"desc"`             // 授权链接中机器人可使用功能的描述
+}
+
+// ResponseQQGuildPermissionRequest 发送 API 权限授权响应
+type ResponseQQGuildPermissionRequest struct {
+	GuildId   string `json:"guild_id"`   // 群组 ID
+	ChannelId string `json:"channel_id"` // 子频道 ID
+	Method    string `json:"method"`     // 接口请求方法
+	Path      string `json:"path"`       // 接口路径
+	Title     string `json:"title"`      // 授权链接中的接口权限描述
+	Desc      string `json:"desc"`       // 授权链接中机器人可使用功能的描述
+}
+
+// HandleQQGuildPermissionRequest 处理发送 API 权限授权请求
+func HandleQQGuildPermissionRequest(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
+	var request RequestQQGuildPermissionRequest
+	err := json.Unmarshal(message.Data(), &request)
+	if err != nil {
+		return gin.H{}, &BadRequestError{err}
+	}
+
+	if message.Platform == "qqguild" {
+		var response ResponseQQGuildPermissionRequest
+
+		identify, err := createAPIPermissionDemandIdentify(&request)
+		if err != nil {
+			return gin.H{}, &BadRequestError{err}
+		}
+
+		dtoDemand, err := apiv2.RequireAPIPermissions(message.Context(), request.GuildId, &dto.APIPermissionDemandToCreate{
+			ChannelID:   request.ChannelId,
+			APIIdentify: identify,
+			Desc:        request.Desc,
+		})
+		if err != nil {
+			return gin.H{}, &InternalServerError{err}
+		}
+
+		// 授权状态即将发生变化，使缓存失效
+		InvalidateAPIPermissions(request.GuildId)
+
+		response.GuildId = dtoDemand.GuildID
+		response.ChannelId = dtoDemand.ChannelID
+		if dtoDemand.APIIdentify != nil {
+			response.Method = dtoDemand.APIIdentify.Method
+			response.Path = dtoDemand.APIIdentify.Path
+		}
+		response.Title = dtoDemand.Title
+		response.Desc = dtoDemand.Desc
+
+		return response, nil
+	}
+
+	return defaultResource(message)
+}
+
+// createAPIPermissionDemandIdentify 根据请求构建权限需求标识
+func createAPIPermissionDemandIdentify(request *RequestQQGuildPermissionRequest) (*dto.APIPermissionDemandIdentify, error) {
+	if request.API != "" {
+		identify, ok := apiPermissionIdentifies[request.API]
+		if !ok {
+			return nil, fmt.Errorf("api %q does not require guild api permission", request.API)
+		}
+		return &dto.APIPermissionDemandIdentify{
+			Method: identify.Method,
+			Path:   identify.Path,
+		}, nil
+	}
+
+	if request.Method == "" || request.Path == "" {
+		return nil, fmt.Errorf("either api or method and path are required")
+	}
+	return &dto.APIPermissionDemandIdentify{
+		Method: strings.ToUpper(request.Method),
+		Path:   request.Path,
+	}, nil
+}
@@ -0,0 +1,41 @@
+package httpapi
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/ratelimit"
+	"github.com/tencent-connect/botgo/errs"
+)
+
+// asTooManyRequests 将超出频率限制导致的内部错误转换为 TooManyRequestsError
+//
+// 包括 GlycCat 的限流器拒绝的请求与 QQ 开放平台返回 429 的请求
+func asTooManyRequests(err APIError) APIError {
+	internal, ok := err.(*InternalServerError)
+	if !ok || internal.err == nil {
+		return err
+	}
+
+	var limited *ratelimit.Error
+	if errors.As(internal.err, &limited) {
+		return &TooManyRequestsError{err: limited, retryAfter: limited.RetryAfter}
+	}
+	var qqErr *errs.Err
+	if errors.As(internal.err, &qqErr) && qqErr.Code() == http.StatusTooManyRequests {
+		return &TooManyRequestsError{err: internal.err}
+	}
+	return err
+}
+
+// retryAfterSeconds 将等待时间转换为 Retry-After 响应头的秒数，向上取整且至少为 1
+func retryAfterSeconds(retryAfter time.Duration) string {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.FormatInt(seconds, 10)
+}
@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
@@ -33,7 +32,7 @@ func HandleReactionCreate(api, apiv2 openapi.OpenAPI, message *ActionMessage) (a
 			ID:   request.Emoji,
 			Type: 1, // 统一为 QQ 系统表情
 		}
-		err = apiv2.CreateMessageReaction(context.TODO(), request.ChannelId, request.MessageId, dtoEmoji)
+		err = apiv2.CreateMessageReaction(message.Context(), request.ChannelId, request.MessageId, dtoEmoji)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 
@@ -40,7 +39,7 @@ func HandleReactionDelete(api, apiv2 openapi.OpenAPI, message *ActionMessage) (a
 			ID:   request.Emoji,
 			Type: 1, // 统一为 QQ 系统表情
 		}
-		err = apiv2.DeleteOwnMessageReaction(context.TODO(), request.ChannelId, request.MessageId, dtoEmoji)
+		err = apiv2.DeleteOwnMessageReaction(message.Context(), request.ChannelId, request.MessageId, dtoEmoji)
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/gin-gonic/gin"
@@ -40,7 +39,7 @@ func HandleReactionList(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any
 			ID:   request.Emoji,
 			Type: 1, // 统一为 QQ 系统表情
 		}
-		dtoMessageReactionUsers, err = apiv2.GetMessageReactionUsers(context.TODO(), request.ChannelId, request.MessageId, dtoEmoji, createMessageReactionPager(request.Next))
+		dtoMessageReactionUsers, err = apiv2.GetMessageReactionUsers(message.Context(), request.ChannelId, request.MessageId, dtoEmoji, createMessageReactionPager(request.Next))
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		}
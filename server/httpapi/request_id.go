@@ -0,0 +1,145 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/gin-gonic/gin"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+// RequestIDHeader 携带请求 ID 的请求头与响应头
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 请求 ID 的最大长度，超出或含有不可见字符时重新生成
+const maxRequestIDLength = 128
+
+// requestIDKey 上下文中请求 ID 的键
+type requestIDKey struct{}
+
+// requestTraceKey 上下文中 trace ID 记录的键
+type requestTraceKey struct{}
+
+// requestTrace 一次 Satori 请求中 QQ 开放平台返回的 trace ID
+type requestTrace struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+// add 记录 trace ID
+func (t *requestTrace) add(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ids = append(t.ids, id)
+}
+
+// list 获取已记录的 trace ID
+func (t *requestTrace) list() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.ids...)
+}
+
+// RequestIDFromContext 获取上下文中的请求 ID
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// RequestIDMiddleware 请求 ID 中间件
+//
+// 沿用请求头中的 X-Request-ID ，没有时生成新的 ID ，写入响应头与请求的上下文，
+// 请求结束后输出带有请求 ID 与 QQ 开放平台 trace ID 的日志。
+// kind 为 http_api 、meta 或 proxy
+func RequestIDMiddleware(kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		// 在处理之前设置，错误响应同样会携带
+		c.Header(RequestIDHeader, requestID)
+
+		trace := &requestTrace{}
+		ctx := context.WithValue(c.Request.Context(), requestIDKey{}, requestID)
+		ctx = context.WithValue(ctx, requestTraceKey{}, trace)
+		c.Request = c.Request.WithContext(ctx)
+
+		start := time.Now()
+		c.Next()
+
+		fields := log.Fields{
+			log.FieldRequestID: requestID,
+			log.FieldAPI:       metricsMethodLabel(kind, c.Param("method")),
+		}
+		if traceIDs := trace.list(); len(traceIDs) > 0 {
+			fields[log.FieldQQTraceID] = strings.Join(traceIDs, ",")
+		}
+		entry := log.WithFields(fields)
+		status := c.Writer.Status()
+		if status >= http.StatusBadRequest {
+			entry.Warnf("请求 %s %s 失败，状态码 %d ，耗时 %v", c.Request.Method, c.Request.URL.Path, status, time.Since(start))
+		} else {
+			entry.Debugf("请求 %s %s 完成，状态码 %d ，耗时 %v", c.Request.Method, c.Request.URL.Path, status, time.Since(start))
+		}
+	}
+}
+
+// isValidRequestID 判断请求头中的请求 ID 是否可以沿用
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成新的请求 ID
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
+
+const traceFilterName = "glyccat_request_id"
+
+var registerTraceOnce sync.Once
+
+// RegisterTraceFilters 注册 OpenAPI 响应过滤器，将 QQ 开放平台返回的 trace ID 关联到请求 ID ，重复调用无效
+func RegisterTraceFilters() {
+	registerTraceOnce.Do(func() {
+		openapi.RegisterRespFilter(traceFilterName, recordTraceID)
+	})
+}
+
+// recordTraceID 记录 OpenAPI 响应中的 trace ID
+func recordTraceID(req *http.Request, resp *http.Response) error {
+	if req == nil || resp == nil {
+		return nil
+	}
+	requestID := RequestIDFromContext(req.Context())
+	if requestID == "" {
+		return nil
+	}
+	traceID := resp.Header.Get(openapi.TraceIDKey)
+	if trace, ok := req.Context().Value(requestTraceKey{}).(*requestTrace); ok && traceID != "" {
+		trace.add(traceID)
+	}
+	log.WithFields(log.Fields{
+		log.FieldRequestID: requestID,
+		log.FieldQQTraceID: traceID,
+	}).Debugf("OpenAPI 请求 %s %s 返回状态码 %d", req.Method, req.URL.Path, resp.StatusCode)
+	return nil
+}
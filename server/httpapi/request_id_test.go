@@ -0,0 +1,93 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"keep client id", "client-request-1", true},
+		{"generate when missing", "", false},
+		{"regenerate with spaces", "bad id", false},
+		{"regenerate when too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctxID string
+			var actionCtx context.Context
+			engine := gin.New()
+			engine.POST("/v1/:method", RequestIDMiddleware("http_api"), func(c *gin.Context) {
+				ctxID = RequestIDFromContext(c.Request.Context())
+				actionCtx = NewActionMessage(c.Param("method"), nil, "qq", c).Context()
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/test.request_id", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			got := w.Header().Get(RequestIDHeader)
+			if tt.keep && got != tt.header {
+				t.Errorf("response request id = %q, want %q", got, tt.header)
+			}
+			if !tt.keep && (got == "" || got == tt.header) {
+				t.Errorf("response request id = %q, want a generated id", got)
+			}
+			if ctxID != got {
+				t.Errorf("context request id = %q, want %q", ctxID, got)
+			}
+			if id := RequestIDFromContext(actionCtx); id != got {
+				t.Errorf("ActionMessage.Context() request id = %q, want %q", id, got)
+			}
+			if actionCtx.Done() != nil {
+				t.Error("ActionMessage.Context() can be cancelled, want it detached from the request")
+			}
+		})
+	}
+}
+
+func TestRecordTraceID(t *testing.T) {
+	trace := &requestTrace{}
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "request-1")
+	ctx = context.WithValue(ctx, requestTraceKey{}, trace)
+
+	for _, traceID := range []string{"trace-1", "", "trace-2"} {
+		req := httptest.NewRequest(http.MethodGet, "/v2/users/@me", nil).WithContext(ctx)
+		resp := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header)}
+		if traceID != "" {
+			resp.Header.Set(openapi.TraceIDKey, traceID)
+		}
+		if err := recordTraceID(req, resp); err != nil {
+			t.Fatalf("recordTraceID() error = %v", err)
+		}
+	}
+
+	// 没有请求 ID 的 OpenAPI 调用不记录
+	req := httptest.NewRequest(http.MethodGet, "/v2/users/@me", nil)
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{openapi.TraceIDKey: {"trace-3"}}}
+	if err := recordTraceID(req, resp); err != nil {
+		t.Fatalf("recordTraceID() error = %v", err)
+	}
+
+	if got, want := trace.list(), []string{"trace-1", "trace-2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("trace ids = %v, want %v", got, want)
+	}
+}
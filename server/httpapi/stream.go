@@ -0,0 +1,321 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/processor"
+	satoriMessage "github.com/satori-protocol-go/satori-model-go/pkg/message"
+	"github.com/tencent-connect/botgo/dto"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+// 流式消息状态
+const (
+	streamStateGenerating = 1  // 正文生成中
+	streamStateFinished   = 10 // 正文生成结束
+)
+
+const (
+	streamIdleTimeout  = 5 * time.Minute // 流式消息无新分片时的保留时间
+	streamPromptStyle  = 1               // 引导按钮样式
+	streamPromptAction = 2               // 引导按钮行为，点击后发送按钮文本
+)
+
+// errInvalidStreamChunk 分片与流式消息当前状态不符
+var errInvalidStreamChunk = errors.New("invalid stream chunk")
+
+// errStreamNotOwned 流式消息不属于发起请求的机器人
+var errStreamNotOwned = errors.New("stream is not owned by this bot")
+
+// messageStream 单条流式消息的状态
+type messageStream struct {
+	id         string       // 流 ID ，即首个分片返回的消息 ID
+	owner      string       // 创建流式消息的机器人
+	userId     string       // 用户 openid
+	msgId      string       // 被动回复的消息 ID
+	msgSeq     int          // 下一个分片使用的消息序号
+	index      int          // 最后一个已发送分片的序号
+	finished   bool         // 是否已结束
+	lastActive atomic.Int64 // 最后一次发送分片的时间，单位纳秒，清理时不持有 mu 读取
+	mu         sync.Mutex
+}
+
+// MessageStreamMapping 流式消息映射
+type MessageStreamMapping struct {
+	mapping map[string]*messageStream
+	mu      sync.Mutex
+}
+
+var globalMessageStreamMapping = &MessageStreamMapping{
+	mapping: make(map[string]*messageStream),
+}
+
+// MessageStreamChunk 流式消息分片
+type MessageStreamChunk struct {
+	Content string   // 分片内容
+	Index   *int     // 期望的分片序号，为空时自动递增
+	Finish  bool     // 是否为最后一个分片
+	Prompts []string // 结束时附带的引导按钮
+}
+
+// openMessageStream 发送首个分片并创建流式消息
+func openMessageStream(ctx context.Context, api openapi.OpenAPI, owner, userId, msgId string, msgSeq int, chunk *MessageStreamChunk) (*messageStream, *dto.Message, error) {
+	if chunk.Index != nil && *chunk.Index != 0 {
+		return nil, nil, fmt.Errorf("%w: the first chunk of a stream must have index 0, got %d", errInvalidStreamChunk, *chunk.Index)
+	}
+	if msgSeq <= 0 {
+		msgSeq = 1
+		if msgId != "" {
+			msgSeq = processor.ReservePassiveSeq(msgId, 0)
+		}
+	}
+
+	stream := &messageStream{
+		owner:  owner,
+		userId: userId,
+		msgId:  msgId,
+		msgSeq: msgSeq,
+		index:  -1,
+	}
+	dtoMessage, err := stream.send(ctx, api, chunk)
+	if err != nil {
+		return nil, nil, err
+	}
+	if chunk.Finish {
+		return stream, dtoMessage, nil
+	}
+
+	globalMessageStreamMapping.mu.Lock()
+	defer globalMessageStreamMapping.mu.Unlock()
+
+	// 顺便清理已过期的流式消息
+	for id, s := range globalMessageStreamMapping.mapping {
+		if s.idle() > streamIdleTimeout {
+			delete(globalMessageStreamMapping.mapping, id)
+		}
+	}
+	globalMessageStreamMapping.mapping[stream.id] = stream
+	return stream, dtoMessage, nil
+}
+
+// getMessageStream 获取属于 owner 的流式消息
+func getMessageStream(owner, id string) (*messageStream, error) {
+	globalMessageStreamMapping.mu.Lock()
+	defer globalMessageStreamMapping.mu.Unlock()
+
+	stream, ok := globalMessageStreamMapping.mapping[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: stream %s not found or already finished", errInvalidStreamChunk, id)
+	}
+	if stream.idle() > streamIdleTimeout {
+		delete(globalMessageStreamMapping.mapping, id)
+		return nil, fmt.Errorf("%w: stream %s has expired", errInvalidStreamChunk, id)
+	}
+	if stream.owner != owner {
+		return nil, fmt.Errorf("%w: %s", errStreamNotOwned, id)
+	}
+	return stream, nil
+}
+
+// removeMessageStream 移除流式消息
+func removeMessageStream(id string) {
+	globalMessageStreamMapping.mu.Lock()
+	defer globalMessageStreamMapping.mu.Unlock()
+	delete(globalMessageStreamMapping.mapping, id)
+}
+
+// appendMessageStream 向已有的流式消息追加分片
+func appendMessageStream(ctx context.Context, api openapi.OpenAPI, owner, id string, chunk *MessageStreamChunk) (*messageStream, *dto.Message, error) {
+	stream, err := getMessageStream(owner, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	dtoMessage, err := stream.send(ctx, api, chunk)
+	if err != nil {
+		return nil, nil, err
+	}
+	if chunk.Finish {
+		removeMessageStream(id)
+	}
+	return stream, dtoMessage, nil
+}
+
+// send 按顺序发送分片，同一流式消息的分片不会并发发送
+func (s *messageStream) send(ctx context.Context, api openapi.OpenAPI, chunk *MessageStreamChunk) (*dto.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.finished {
+		return nil, fmt.Errorf("%w: stream %s is already finished", errInvalidStreamChunk, s.id)
+	}
+	index := s.index + 1
+	if chunk.Index != nil && *chunk.Index != index {
+		return nil, fmt.Errorf("%w: out of order chunk for stream %s, expected index %d, got %d", errInvalidStreamChunk, s.id, index, *chunk.Index)
+	}
+
+	dtoMessageSSE := &dto.MessageSSE{
+		MsgType: 2,
+		Markdown: &dto.MarkdownSSE{
+			Content: chunk.Content,
+		},
+		MsgID:  s.msgId,
+		MsgSeq: s.msgSeq,
+		Stream: &dto.StreamSSE{
+			State: streamStateGenerating,
+			Index: index,
+			ID:    s.id,
+		},
+	}
+	if chunk.Finish {
+		dtoMessageSSE.Stream.State = streamStateFinished
+		dtoMessageSSE.PromptKeyboard = createPromptKeyboard(chunk.Prompts)
+	}
+
+	dtoResponse, err := api.PostC2CMessageSSE(ctx, s.userId, dtoMessageSSE)
+	if err != nil {
+		return nil, err
+	}
+	if dtoResponse.Message == nil {
+		return nil, fmt.Errorf("empty response for stream chunk %d", index)
+	}
+
+	// 发送成功后再推进状态，失败时可以使用相同的序号重试
+	if s.msgId != "" {
+		processor.ReservePassiveSeq(s.msgId, s.msgSeq)
+	}
+	if s.id == "" {
+		s.id = dtoResponse.Message.ID
+	}
+	s.index = index
+	s.msgSeq++
+	s.finished = chunk.Finish
+	s.lastActive.Store(time.Now().UnixNano())
+	return dtoResponse.Message, nil
+}
+
+// state 获取流 ID 、最后一个已发送分片的序号与是否已结束
+func (s *messageStream) state() (string, int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.id, s.index, s.finished
+}
+
+// idle 距离最后一次发送分片的时间
+func (s *messageStream) idle() time.Duration {
+	return time.Since(time.Unix(0, s.lastActive.Load()))
+}
+
+// convertStreamError 将流式消息错误转换为 APIError
+func convertStreamError(err error) APIError {
+	if errors.Is(err, errInvalidStreamChunk) {
+		return &BadRequestError{err}
+	}
+	if errors.Is(err, errStreamNotOwned) {
+		return &ForbiddenError{err.Error()}
+	}
+	return &InternalServerError{err}
+}
+
+// streamOwner 获取发起请求的机器人标识，用于区分不同机器人的流式消息
+func streamOwner(message *ActionMessage) string {
+	if message.Bot == nil {
+		return message.Platform
+	}
+	return message.Platform + ":" + message.Bot.Id
+}
+
+// createPromptKeyboard 创建流式消息结束时的引导按钮
+func createPromptKeyboard(prompts []string) *dto.KeyboardSSE {
+	var rows []dto.RowSSE
+	for _, prompt := range prompts {
+		prompt = strings.TrimSpace(prompt)
+		if prompt == "" {
+			continue
+		}
+		rows = append(rows, dto.RowSSE{
+			Buttons: []dto.ButtonSSE{{
+				RenderData: dto.RenderDataSSE{
+					Label: prompt,
+					Style: streamPromptStyle,
+				},
+				Action: dto.ActionSSE{
+					Type: streamPromptAction,
+				},
+			}},
+		})
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var promptKeyboard dto.KeyboardSSE
+	promptKeyboard.Content.Rows = rows
+	return &promptKeyboard
+}
+
+// findStreamElement 查找消息内容中的流式消息元素
+func findStreamElement(content string) *satoriMessage.MessageElementExtend {
+	elements, err := satoriMessage.Parse(content)
+	if err != nil {
+		return nil
+	}
+	for _, element := range elements {
+		if e, ok := element.(*satoriMessage.MessageElementExtend); ok && e.Tag() == "qq:stream" {
+			return e
+		}
+	}
+	return nil
+}
+
+// isStreamAppend 流式消息元素是否向已有的流式消息追加分片
+func isStreamAppend(element *satoriMessage.MessageElementExtend) bool {
+	id, ok := element.Get("id")
+	return ok && id != ""
+}
+
+// sendStreamElement 根据流式消息元素发送分片
+//
+// 不含 id 属性时创建新的流式消息，含有 finish 属性时结束流式消息
+func sendStreamElement(ctx context.Context, api openapi.OpenAPI, owner, userId string, element *satoriMessage.MessageElementExtend, dtoMessageToCreate *dto.MessageToCreate) (*satoriMessage.Message, APIError) {
+	chunk := &MessageStreamChunk{
+		Content: dtoMessageToCreate.Content,
+	}
+	if index, ok := element.Get("index"); ok {
+		intIndex, err := strconv.Atoi(index)
+		if err != nil {
+			return nil, &BadRequestError{fmt.Errorf("invalid stream index %q: %w", index, err)}
+		}
+		chunk.Index = &intIndex
+	}
+	if finish, ok := element.Get("finish"); ok && finish != "false" {
+		chunk.Finish = true
+	}
+	if prompts, ok := element.Get("prompts"); ok {
+		chunk.Prompts = strings.Split(prompts, "|")
+	}
+
+	var dtoMessage *dto.Message
+	var err error
+	if isStreamAppend(element) {
+		id, _ := element.Get("id")
+		_, dtoMessage, err = appendMessageStream(ctx, api, owner, id, chunk)
+	} else {
+		_, dtoMessage, err = openMessageStream(ctx, api, owner, userId, dtoMessageToCreate.MsgID, dtoMessageToCreate.MsgSeq, chunk)
+	}
+	if err != nil {
+		return nil, convertStreamError(err)
+	}
+
+	messageResponse, err := convertDtoMessageV2ToMessage(dtoMessage)
+	if err != nil {
+		return nil, &InternalServerError{err}
+	}
+	return messageResponse, nil
+}
@@ -0,0 +1,205 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/processor"
+	"github.com/tencent-connect/botgo/dto"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+// fakeSSEAPI 记录发送的流式消息分片
+type fakeSSEAPI struct {
+	openapi.OpenAPI
+	mu     sync.Mutex
+	chunks []*dto.MessageSSE
+	fail   bool
+}
+
+func (api *fakeSSEAPI) PostC2CMessageSSE(ctx context.Context, userID string, msg dto.APIMessage) (*dto.C2CMessageResponse, error) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if api.fail {
+		return nil, errors.New("send failed")
+	}
+	chunk := msg.(*dto.MessageSSE)
+	api.chunks = append(api.chunks, chunk)
+	return &dto.C2CMessageResponse{
+		Message: &dto.Message{
+			ID:        fmt.Sprintf("msg-%d", len(api.chunks)),
+			Timestamp: "2024-01-01T00:00:00+08:00",
+		},
+	}, nil
+}
+
+func TestMessageStreamLifecycle(t *testing.T) {
+	api := &fakeSSEAPI{}
+	ctx := context.Background()
+
+	stream, _, err := openMessageStream(ctx, api, "qq:1", "user", "", 0, &MessageStreamChunk{Content: "a"})
+	if err != nil {
+		t.Fatalf("openMessageStream() error = %v", err)
+	}
+	if stream.id != "msg-1" {
+		t.Fatalf("stream id = %q, want %q", stream.id, "msg-1")
+	}
+
+	index := 1
+	tests := []struct {
+		name    string
+		owner   string
+		id      string
+		chunk   *MessageStreamChunk
+		wantErr APIError
+	}{
+		{"other owner", "qq:2", stream.id, &MessageStreamChunk{Content: "x"}, &ForbiddenError{}},
+		{"unknown stream", "qq:1", "missing", &MessageStreamChunk{Content: "x"}, &BadRequestError{}},
+		{"out of order", "qq:1", stream.id, &MessageStreamChunk{Content: "x", Index: new(int)}, &BadRequestError{}},
+		{"append", "qq:1", stream.id, &MessageStreamChunk{Content: "b", Index: &index}, nil},
+		{"finish", "qq:1", stream.id, &MessageStreamChunk{Content: "c", Finish: true, Prompts: []string{"again"}}, nil},
+		{"after finish", "qq:1", stream.id, &MessageStreamChunk{Content: "d"}, &BadRequestError{}},
+	}
+
+	for _, tt := range tests {
+		_, _, err := appendMessageStream(ctx, api, tt.owner, tt.id, tt.chunk)
+		if tt.wantErr == nil {
+			if err != nil {
+				t.Errorf("%s: appendMessageStream() error = %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: appendMessageStream() error = nil, want %T", tt.name, tt.wantErr)
+			continue
+		}
+		if got := convertStreamError(err); got.Code() != tt.wantErr.Code() {
+			t.Errorf("%s: status = %d, want %d", tt.name, got.Code(), tt.wantErr.Code())
+		}
+	}
+
+	if len(api.chunks) != 3 {
+		t.Fatalf("sent %d chunks, want 3", len(api.chunks))
+	}
+	for i, chunk := range api.chunks {
+		if chunk.Stream.Index != i {
+			t.Errorf("chunk %d index = %d, want %d", i, chunk.Stream.Index, i)
+		}
+		if i > 0 && chunk.Stream.ID != stream.id {
+			t.Errorf("chunk %d stream id = %q, want %q", i, chunk.Stream.ID, stream.id)
+		}
+	}
+	last := api.chunks[2]
+	if last.Stream.State != streamStateFinished || last.PromptKeyboard == nil {
+		t.Errorf("last chunk = %+v, want finished with prompt keyboard", last.Stream)
+	}
+}
+
+func TestMessageStreamRetryKeepsSeq(t *testing.T) {
+	api := &fakeSSEAPI{}
+	ctx := context.Background()
+
+	stream, _, err := openMessageStream(ctx, api, "qq:1", "user", "", 0, &MessageStreamChunk{Content: "a"})
+	if err != nil {
+		t.Fatalf("openMessageStream() error = %v", err)
+	}
+
+	api.fail = true
+	if _, _, err := appendMessageStream(ctx, api, "qq:1", stream.id, &MessageStreamChunk{Content: "b"}); err == nil {
+		t.Fatal("appendMessageStream() error = nil, want send error")
+	} else if got := convertStreamError(err); got.Code() != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", got.Code(), http.StatusInternalServerError)
+	}
+
+	api.fail = false
+	if _, _, err := appendMessageStream(ctx, api, "qq:1", stream.id, &MessageStreamChunk{Content: "b"}); err != nil {
+		t.Fatalf("appendMessageStream() error = %v", err)
+	}
+	if got := api.chunks[1]; got.Stream.Index != 1 || got.MsgSeq != 2 {
+		t.Errorf("retried chunk index = %d, msg_seq = %d, want 1, 2", got.Stream.Index, got.MsgSeq)
+	}
+	removeMessageStream(stream.id)
+}
+
+func TestMessageStreamReservesPassiveSeq(t *testing.T) {
+	api := &fakeSSEAPI{}
+	ctx := context.Background()
+	msgId := fmt.Sprintf("stream-passive-msg-%d", time.Now().UnixNano())
+
+	// 流式消息之前已经被动回复过一次
+	if seq := processor.ReservePassiveSeq(msgId, 0); seq != 1 {
+		t.Fatalf("ReservePassiveSeq() = %d, want 1", seq)
+	}
+
+	stream, _, err := openMessageStream(ctx, api, "qq:1", "user", msgId, 0, &MessageStreamChunk{Content: "a"})
+	if err != nil {
+		t.Fatalf("openMessageStream() error = %v", err)
+	}
+	if _, _, err := appendMessageStream(ctx, api, "qq:1", stream.id, &MessageStreamChunk{Content: "b", Finish: true}); err != nil {
+		t.Fatalf("appendMessageStream() error = %v", err)
+	}
+
+	for i, want := range []int{2, 3} {
+		if got := api.chunks[i]; got.MsgID != msgId || got.MsgSeq != want {
+			t.Errorf("chunk %d msg_id = %q, msg_seq = %d, want %q, %d", i, got.MsgID, got.MsgSeq, msgId, want)
+		}
+	}
+
+	// 流式消息之后的被动回复不会与已发送的分片重复
+	if seq := processor.ReservePassiveSeq(msgId, 0); seq != 4 {
+		t.Errorf("ReservePassiveSeq() after stream = %d, want 4", seq)
+	}
+}
+
+func TestMessageStreamConcurrentAppend(t *testing.T) {
+	api := &fakeSSEAPI{}
+	ctx := context.Background()
+
+	var streams []*messageStream
+	for i := 0; i < 4; i++ {
+		stream, _, err := openMessageStream(ctx, api, "qq:1", "user", "", 0, &MessageStreamChunk{Content: "a"})
+		if err != nil {
+			t.Fatalf("openMessageStream() error = %v", err)
+		}
+		streams = append(streams, stream)
+	}
+
+	// 同时向不同与相同的流式消息追加分片，并创建新的流式消息触发清理
+	var wg sync.WaitGroup
+	for _, stream := range streams {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				stream, dtoMessage, err := appendMessageStream(ctx, api, "qq:1", id, &MessageStreamChunk{Content: "b"})
+				if err != nil {
+					t.Errorf("appendMessageStream() error = %v", err)
+					return
+				}
+				if _, apiErr := convertToResponseQQMessageStream(stream, dtoMessage); apiErr != nil {
+					t.Errorf("convertToResponseQQMessageStream() error = %v", apiErr)
+				}
+			}(stream.id)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := openMessageStream(ctx, api, "qq:1", "user", "", 0, &MessageStreamChunk{Content: "a"}); err != nil {
+				t.Errorf("openMessageStream() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, stream := range streams {
+		if _, index, _ := stream.state(); index != 4 {
+			t.Errorf("stream %s index = %d, want 4", stream.id, index)
+		}
+		removeMessageStream(stream.id)
+	}
+}
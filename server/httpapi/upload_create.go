@@ -1,6 +1,11 @@
 package httpapi
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/WindowsSov8forUs/glyccat/fileserver"
 	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/gin-gonic/gin"
@@ -11,6 +16,9 @@ func init() {
 	RegisterHandler("upload.create", HandleUploadCreate)
 }
 
+// uploadDeduplicatedHeader 复用了已存在文件的表单项响应头
+const uploadDeduplicatedHeader = "X-GlycCat-Deduplicated"
+
 // HandleUploadCreate 处理文件上传请求
 func HandleUploadCreate(api openapi.OpenAPI, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
 	response := gin.H{}
@@ -21,6 +29,9 @@ func HandleUploadCreate(api openapi.OpenAPI, apiv2 openapi.OpenAPI, message *Act
 		return gin.H{}, &BadRequestError{err}
 	}
 
+	// 记录复用了已存在文件的表单项
+	var deduplicated []string
+
 	// 遍历处理文件
 	for name, files := range form.File {
 		// 每个 name 只会对应一个文件
@@ -33,13 +44,26 @@ func HandleUploadCreate(api openapi.OpenAPI, apiv2 openapi.OpenAPI, message *Act
 		contentType := file.Header.Get("Content-Type")
 
 		meta, err := fileserver.SaveFile(reader, message.Platform, message.Bot.Id, file.Filename, contentType)
+		reader.Close()
 		if err != nil {
 			log.Errorf("保存文件 %s 时发生错误: %v", name, err)
+			if errors.Is(err, fileserver.ErrFileTooLarge) || errors.Is(err, fileserver.ErrQuotaExceeded) {
+				return gin.H{}, &PayloadTooLargeError{fmt.Errorf("failed to save file %s: %w", name, err)}
+			}
 			continue
 		}
+		if meta.Deduplicated() {
+			deduplicated = append(deduplicated, name)
+		}
 
 		response[name] = fileserver.InternalURL(meta)
 	}
 
+	// 通过响应头告知哪些文件复用了已存在的文件
+	if len(deduplicated) > 0 {
+		sort.Strings(deduplicated)
+		message.Ctx.Header(uploadDeduplicatedHeader, strings.Join(deduplicated, ","))
+	}
+
 	return response, nil
 }
@@ -1,7 +1,6 @@
 package httpapi
 
 import (
-	"context"
 	"encoding/json"
 
 	"github.com/WindowsSov8forUs/glyccat/processor"
@@ -53,7 +52,7 @@ func HandleUserChannelCreate(api, apiv2 openapi.OpenAPI, message *ActionMessage)
 		// QQ 频道需要调用 API
 
 		var dtoDirectMessage *dto.DirectMessage
-		dtoDirectMessage, err = apiv2.CreateDirectMessage(context.TODO(), createDirectMessageToCreate(request))
+		dtoDirectMessage, err = apiv2.CreateDirectMessage(message.Context(), createDirectMessageToCreate(request))
 		if err != nil {
 			return gin.H{}, &InternalServerError{err}
 		} else {
@@ -0,0 +1,66 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/WindowsSov8forUs/glyccat/database"
+	"github.com/gin-gonic/gin"
+	"github.com/satori-protocol-go/satori-model-go/pkg/user"
+	"github.com/tencent-connect/botgo/openapi"
+)
+
+func init() {
+	RegisterHandler("user.get", HandleUserGet)
+}
+
+// RequestUserGet 获取用户信息请求
+type RequestUserGet struct {
+	UserId string `json:"user_id"` // 用户 ID
+}
+
+// ResponseObservedUser 由观察记录得到的用户信息响应
+type ResponseObservedUser struct {
+	user.User
+	Partial     bool               `json:"partial"`     // 数据是否不完整，观察记录总是不完整的
+	Observation *MemberObservation `json:"observation"` // 观察记录
+}
+
+// HandleUserGet 处理获取用户信息请求
+func HandleUserGet(api, apiv2 openapi.OpenAPI, message *ActionMessage) (any, APIError) {
+	var request RequestUserGet
+	err := json.Unmarshal(message.Data(), &request)
+	if err != nil {
+		return gin.H{}, &BadRequestError{err}
+	}
+
+	if message.Platform == "qq" {
+		// 没有用户查询接口，使用观察记录
+		if !database.MemberDBEnabled() {
+			return gin.H{}, &InternalServerError{fmt.Errorf("member database is not enabled")}
+		}
+
+		var response ResponseObservedUser
+
+		observed, err := database.GetObservedUser(request.UserId)
+		if err != nil {
+			return gin.H{}, &InternalServerError{err}
+		}
+		if observed == nil {
+			return gin.H{}, &NotFoundError{err: fmt.Errorf("user %s has not been observed", request.UserId)}
+		}
+
+		response.Id = observed.UserId
+		response.Avatar = observed.Avatar
+		response.Partial = true
+		response.Observation = &MemberObservation{
+			FirstSeen:    observed.FirstSeen,
+			LastSeen:     observed.LastSeen,
+			MessageCount: observed.MessageCount,
+		}
+
+		return response, nil
+	}
+
+	return defaultResource(message)
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/satori-protocol-go/satori-model-go/pkg/login"
+
+	"github.com/WindowsSov8forUs/glyccat/fileserver"
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/WindowsSov8forUs/glyccat/metrics"
+	"github.com/WindowsSov8forUs/glyccat/processor"
+)
+
+// loginStatusNames 登录状态在指标中的名称，下标为登录状态
+var loginStatusNames = []string{
+	login.StatusOffline:    "offline",
+	login.StatusOnline:     "online",
+	login.StatusConnect:    "connect",
+	login.StatusDisconnect: "disconnect",
+	login.StatusReconnect:  "reconnect",
+}
+
+// sessionStatusDesc QQ 开放平台会话状态指标
+var sessionStatusDesc = prometheus.NewDesc(
+	"glyccat_qq_session_status",
+	"QQ open platform session status, 1 for the current status of each platform.",
+	[]string{"platform", "status"},
+	nil,
+)
+
+// sessionStatusCollector 在采集时读取各平台的会话状态
+type sessionStatusCollector struct{}
+
+// Describe 实现 prometheus.Collector
+func (sessionStatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sessionStatusDesc
+}
+
+// Collect 实现 prometheus.Collector
+func (sessionStatusCollector) Collect(ch chan<- prometheus.Metric) {
+	for platform := range processor.GetBots() {
+		current := processor.GetStatus(platform)
+		for status, name := range loginStatusNames {
+			value := 0.0
+			if login.LoginStatus(status) == current {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(sessionStatusDesc, prometheus.GaugeValue, value, platform, name)
+		}
+	}
+}
+
+// registerMetrics 注册在采集时计算的指标
+func (server *Server) registerMetrics() {
+	subscribers := func(transport string, count func() int) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "glyccat_satori_subscribers",
+			Help:        "Connected Satori subscribers.",
+			ConstLabels: prometheus.Labels{"transport": transport},
+		}, func() float64 {
+			server.rwMutex.RLock()
+			defer server.rwMutex.RUnlock()
+			return float64(count())
+		})
+	}
+
+	collectors := []prometheus.Collector{
+		sessionStatusCollector{},
+		subscribers("websocket", func() int { return len(server.websockets) }),
+		subscribers("webhook", func() int { return len(server.webhooks) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "glyccat_file_server_stored_bytes",
+			Help: "Bytes stored by the local file server.",
+		}, func() float64 {
+			used, _ := fileserver.StorageUsage()
+			return float64(used)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "glyccat_file_server_max_bytes",
+			Help: "Storage limit of the local file server, 0 for unlimited.",
+		}, func() float64 {
+			_, limit := fileserver.StorageUsage()
+			return float64(limit)
+		}),
+	}
+	for _, collector := range collectors {
+		if err := metrics.Default.Register(collector); err != nil {
+			log.Warnf("注册指标失败: %v", err)
+		}
+	}
+}
+
+// MetricsHandler 指标处理函数
+func (server *Server) MetricsHandler() gin.HandlerFunc {
+	return gin.WrapH(metrics.Handler())
+}
+
+// recordDelivery 记录事件推送结果
+func recordDelivery(transport, subscriber string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	metrics.SatoriEventsDelivered.WithLabelValues(transport, subscriber, result).Inc()
+}
+
+// webHookSubscriber 获取 WebHook 在指标中的名称，去除可能含有凭据的部分
+func webHookSubscriber(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "invalid"
+	}
+	return u.Scheme + "://" + u.Host + u.Path
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"strings"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/config"
+	"github.com/WindowsSov8forUs/glyccat/database"
+	"github.com/WindowsSov8forUs/glyccat/fileserver"
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/WindowsSov8forUs/glyccat/ratelimit"
+)
+
+// ReloadConfig 重新加载配置，并将无需重启的变更应用到运行中的组件
+//
+// 已建立的 Satori WebSocket 连接与 QQ 开放平台会话不会断开
+func (server *Server) ReloadConfig() (*config.ReloadResult, error) {
+	log.Info("正在重新加载配置...")
+	result, err := config.Reload()
+	if err != nil {
+		log.Errorf("重新加载配置时出错: %v", err)
+		return nil, err
+	}
+
+	conf := result.Config
+	logReconfigured, rateLimitReconfigured := false, false
+	for _, key := range result.Applied {
+		switch {
+		case key == "log_level":
+			log.SetLogLevel(conf.LogLevel)
+		case strings.HasPrefix(key, "log.") && !logReconfigured:
+			logReconfigured = true
+			if err := log.Configure(conf.Log.Options()); err != nil {
+				log.Errorf("重新配置日志输出时出错: %v", err)
+			}
+		case key == "database.message_database.limit":
+			database.SetMessageLimit(conf.Database.MessageDatabase.Limit)
+		case key == "file_server.ttl":
+			fileserver.SetTTL(time.Duration(conf.FileServer.TTL) * time.Second)
+		case strings.HasPrefix(key, "rate_limit.") && !rateLimitReconfigured:
+			rateLimitReconfigured = true
+			ratelimit.Configure(conf.RateLimit.Options())
+		case key == "satori.webhook.timeout":
+			server.setWebHookTimeout(time.Duration(conf.Satori.WebHook.Timeout) * time.Second)
+		}
+	}
+
+	if !result.Changed() {
+		log.Info("配置未发生变更")
+	}
+	return result, nil
+}
+
+// setWebHookTimeout 更新所有 WebHook 客户端的超时时间
+func (server *Server) setWebHookTimeout(timeout time.Duration) {
+	server.rwMutex.RLock()
+	defer server.rwMutex.RUnlock()
+
+	for _, webhook := range server.webhooks {
+		if webhook != nil {
+			webhook.SetTimeout(timeout)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/tencent-connect/botgo/openapi"
+	"github.com/tencent-connect/botgo/token"
 
 	"github.com/WindowsSov8forUs/glyccat/config"
 	"github.com/WindowsSov8forUs/glyccat/log"
@@ -87,8 +89,14 @@ type Server struct {
 	websockets []*WebSocket
 	webhooks   []*WebHook
 	httpServer *httpapi.Server
+	fileServer *http.Server // 独立的文件服务器，未启用时为 nil
 	conf       *config.Config
+	token      *token.Token // QQ 开放平台鉴权令牌，用于健康检查
 	events     *EventQueue
+
+	sendMutex sync.Mutex     // 保护 closing 与 inflight 的计数
+	closing   bool           // 是否正在关闭，关闭后不再推送事件
+	inflight  sync.WaitGroup // 正在进行的事件推送
 }
 
 func (server *Server) setupV1Engine(api, apiV2 openapi.OpenAPI) *gin.Engine {
@@ -99,11 +107,20 @@ func (server *Server) setupV1Engine(api, apiV2 openapi.OpenAPI) *gin.Engine {
 
 	webSocketGroup := engine.Group(fmt.Sprintf("%s/v1/events", server.conf.Satori.Path))
 	// WebSocket 处理函数
-	webSocketGroup.GET("", server.WebSocketHandler(server.conf.Satori.Token))
+	webSocketGroup.GET("", server.WebSocketHandler())
+
+	// 健康检查处理函数
+	engine.GET(fmt.Sprintf("%s/healthz", server.conf.Satori.Path), server.HealthzHandler())
+	engine.GET(fmt.Sprintf("%s/readyz", server.conf.Satori.Path), server.ReadyzHandler())
+
+	// 指标处理函数
+	engine.GET(fmt.Sprintf("%s/metrics", server.conf.Satori.Path), httpapi.AuthenticateMiddleware("metrics"), server.MetricsHandler())
 
 	resourceGroup := engine.Group(fmt.Sprintf("%s/v1/", server.conf.Satori.Path))
 	// 资源接口处理函数
 	resourceGroup.Use(
+		httpapi.MetricsMiddleware("http_api"),
+		httpapi.RequestIDMiddleware("http_api"),
 		httpapi.HeadersValidateMiddleware(),
 		httpapi.AuthenticateMiddleware("http_api"),
 		httpapi.BotValidateMiddleware(),
@@ -112,7 +129,7 @@ func (server *Server) setupV1Engine(api, apiV2 openapi.OpenAPI) *gin.Engine {
 	resourceGroup.POST(":method", func(c *gin.Context) {
 		method := c.Param("method")
 		// 将请求输出
-		log.Tracef(
+		log.WithField(log.FieldRequestID, c.Writer.Header().Get(httpapi.RequestIDHeader)).Tracef(
 			"收到请求: %s /%s ，请求头：%v ，请求体：%v",
 			c.Request.Method,
 			method,
@@ -125,6 +142,8 @@ func (server *Server) setupV1Engine(api, apiV2 openapi.OpenAPI) *gin.Engine {
 	metaGroup := engine.Group(fmt.Sprintf("%s/v1/meta", server.conf.Satori.Path))
 	// 元信息接口处理函数
 	metaGroup.Use(
+		httpapi.MetricsMiddleware("meta"),
+		httpapi.RequestIDMiddleware("meta"),
 		httpapi.HeadersValidateMiddleware(),
 		httpapi.AuthenticateMiddleware("meta"),
 		httpapi.HeadersSetMiddleware(satoriVersion),
@@ -135,7 +154,7 @@ func (server *Server) setupV1Engine(api, apiV2 openapi.OpenAPI) *gin.Engine {
 			method = ""
 		}
 		// 将请求输出
-		log.Tracef(
+		log.WithField(log.FieldRequestID, c.Writer.Header().Get(httpapi.RequestIDHeader)).Tracef(
 			"收到请求: %s /meta%s ，请求头：%v ，请求体：%v",
 			c.Request.Method,
 			method,
@@ -148,6 +167,8 @@ func (server *Server) setupV1Engine(api, apiV2 openapi.OpenAPI) *gin.Engine {
 
 	proxyGroup := engine.Group(fmt.Sprintf("%s/v1/proxy", server.conf.Satori.Path))
 	proxyGroup.Use(
+		httpapi.MetricsMiddleware("proxy"),
+		httpapi.RequestIDMiddleware("proxy"),
 		httpapi.ProxyValidateMiddleware(),
 	)
 	proxyGroup.GET("/*url", func(c *gin.Context) {
@@ -156,7 +177,7 @@ func (server *Server) setupV1Engine(api, apiV2 openapi.OpenAPI) *gin.Engine {
 		url = strings.TrimPrefix(url, "/")
 
 		// 将请求输出
-		log.Tracef(
+		log.WithField(log.FieldRequestID, c.Writer.Header().Get(httpapi.RequestIDHeader)).Tracef(
 			"收到请求: %s /proxy/%s ，请求头：%v ，请求体：%v",
 			c.Request.Method,
 			url,
@@ -169,13 +190,14 @@ func (server *Server) setupV1Engine(api, apiV2 openapi.OpenAPI) *gin.Engine {
 	return engine
 }
 
-func NewServer(api, apiV2 openapi.OpenAPI, conf *config.Config) (*Server, error) {
+func NewServer(api, apiV2 openapi.OpenAPI, token *token.Token, conf *config.Config) (*Server, error) {
 	server := &Server{
 		rwMutex:    sync.RWMutex{},
 		websockets: make([]*WebSocket, 0),
 		webhooks:   make([]*WebHook, 0),
 		httpServer: nil,
 		conf:       conf,
+		token:      token,
 		events:     NewEventQueue(),
 	}
 
@@ -194,10 +216,23 @@ func NewServer(api, apiV2 openapi.OpenAPI, conf *config.Config) (*Server, error)
 		return nil, fmt.Errorf("unknown Satori protocol version: v%d", conf.Satori.Version)
 	}
 
+	server.registerMetrics()
+
+	if conf.FileServer.Enable && conf.FileServer.Listener.Enable {
+		fileServer, err := server.newFileHTTPServer(conf.FileServer.Listener)
+		if err != nil {
+			return nil, err
+		}
+		server.fileServer = fileServer
+	}
+
 	return server, nil
 }
 
 func (server *Server) Run() error {
+	if server.fileServer != nil {
+		go server.runFileServer()
+	}
 	log.Infof("Satori 服务器已启动，监听地址: %s", server.httpServer.Addr())
 	err := server.httpServer.Run()
 	if err != nil && err != http.ErrServerClosed {
@@ -207,6 +242,12 @@ func (server *Server) Run() error {
 }
 
 func (server *Server) Send(event *operation.Event) {
+	if !server.beginSend() {
+		log.Debugf("Satori 服务端正在关闭，已丢弃事件 %d", event.Sn)
+		return
+	}
+	defer server.inflight.Done()
+
 	server.rwMutex.RLock()
 
 	server.events.PushEvent(event)
@@ -219,6 +260,7 @@ func (server *Server) Send(event *operation.Event) {
 		go func(ws *WebSocket) {
 			defer waitGroup.Done()
 			err := ws.PostEvent(event)
+			recordDelivery("websocket", ws.IP, err)
 			if err != nil {
 				log.Errorf("WebSocket 推送事件时出错: %v", err)
 				ws.Close()
@@ -239,6 +281,7 @@ func (server *Server) Send(event *operation.Event) {
 				return
 			}
 			err := wh.PostEvent(event)
+			recordDelivery("webhook", webHookSubscriber(wh.GetURL()), err)
 			if err != nil {
 				url := wh.GetURL()
 				switch err {
@@ -286,31 +329,80 @@ func (server *Server) Send(event *operation.Event) {
 	server.webhooks = webhooks
 }
 
+// beginSend 开始一次事件推送，正在关闭时返回 false
+func (server *Server) beginSend() bool {
+	server.sendMutex.Lock()
+	defer server.sendMutex.Unlock()
+	if server.closing {
+		return false
+	}
+	server.inflight.Add(1)
+	return true
+}
+
+// Close 关闭 Satori 服务端，最多等待 5 秒
 func (server *Server) Close() {
 	log.Info("正在关闭 Satori 服务端...")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Errorf("关闭 Satori 服务端时出错: %v", err)
+	}
+}
 
-	totalWebSocket := len(server.websockets)
-	for index, ws := range server.websockets {
-		if ws != nil {
-			ws.Close()
-			log.Tracef("WebSocket 连接 (%v/%v) 已关闭：%s", index+1, totalWebSocket, ws.IP)
+// Shutdown 关闭 Satori 服务端
+//
+// 不再接受新的事件，等待正在进行的 WebSocket 与 WebHook 推送完成后关闭 HTTP 服务器、
+// 文件服务器与所有 WebSocket 连接。ctx 到期时不再等待推送完成，强制关闭所有连接并返回错误
+func (server *Server) Shutdown(ctx context.Context) error {
+	server.sendMutex.Lock()
+	server.closing = true
+	server.sendMutex.Unlock()
+
+	var errs []error
+
+	// 等待正在进行的事件推送
+	drained := make(chan struct{})
+	go func() {
+		server.inflight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		log.Trace("事件推送已全部完成")
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("等待事件推送完成时超时: %w", ctx.Err()))
+	}
+
+	log.Trace("正在关闭 HTTP 服务器...")
+	if err := server.httpServer.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("关闭 HTTP 服务器时出错: %w", err))
+		// 超时后强制关闭仍未结束的连接
+		server.httpServer.Close()
+	}
+	if server.fileServer != nil {
+		log.Trace("正在关闭文件服务器...")
+		if err := server.fileServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("关闭文件服务器时出错: %w", err))
+			server.fileServer.Close()
 		}
 	}
 
+	// 关闭连接时会从列表中移除自身，需要在锁外关闭
 	server.rwMutex.Lock()
-	defer func() {
-		server.rwMutex.Unlock()
-	}()
-
+	websockets := server.websockets
 	server.websockets = make([]*WebSocket, 0)
 	server.webhooks = make([]*WebHook, 0)
+	server.rwMutex.Unlock()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	log.Trace("正在关闭 HTTP 服务器...")
-	if err := server.httpServer.Shutdown(ctx); err != nil {
-		log.Errorf("关闭 HTTP 服务器时出错: %v", err)
+	totalWebSocket := len(websockets)
+	for index, ws := range websockets {
+		if ws != nil {
+			ws.Close()
+			log.Tracef("WebSocket 连接 (%v/%v) 已关闭：%s", index+1, totalWebSocket, ws.IP)
+		}
 	}
 
 	log.Info("Satori 服务端已关闭")
+	return errors.Join(errs...)
 }
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/config"
+	"github.com/WindowsSov8forUs/glyccat/server/httpapi"
+)
+
+// newShutdownTestServer 创建未启动的 Satori 服务端
+func newShutdownTestServer() *Server {
+	server := &Server{
+		conf:       config.DefaultConfig(),
+		websockets: make([]*WebSocket, 0),
+		webhooks:   make([]*WebHook, 0),
+	}
+	server.httpServer = httpapi.NewHttpServer("127.0.0.1:0", nil, nil)
+	return server
+}
+
+func TestShutdownDrainsInflightEvents(t *testing.T) {
+	server := newShutdownTestServer()
+	if !server.beginSend() {
+		t.Fatal("beginSend() = false before shutdown")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Shutdown(context.Background())
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown() returned %v before the inflight event finished", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if server.beginSend() {
+		t.Error("beginSend() = true while shutting down")
+	}
+
+	server.inflight.Done()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Shutdown() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown() did not return after the inflight event finished")
+	}
+}
+
+func TestShutdownTimesOutWaitingForEvents(t *testing.T) {
+	server := newShutdownTestServer()
+	if !server.beginSend() {
+		t.Fatal("beginSend() = false before shutdown")
+	}
+	defer server.inflight.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := server.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestShutdownForceClosesHangingConnections(t *testing.T) {
+	server := newShutdownTestServer()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	server.fileServer = &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}),
+	}
+	go server.fileServer.Serve(listener)
+
+	requestDone := make(chan error, 1)
+	go func() {
+		response, err := http.Get("http://" + listener.Addr().String())
+		if err == nil {
+			response.Body.Close()
+		}
+		requestDone <- err
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := server.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// 连接被强制关闭后请求应立即失败
+	select {
+	case err := <-requestDone:
+		if err == nil {
+			t.Error("request succeeded, want connection closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("hanging connection was not closed after Shutdown() returned")
+	}
+}
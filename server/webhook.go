@@ -8,6 +8,7 @@ import (
 
 	"github.com/go-resty/resty/v2"
 
+	"github.com/WindowsSov8forUs/glyccat/config"
 	"github.com/WindowsSov8forUs/glyccat/operation"
 )
 
@@ -41,8 +42,8 @@ func StartWebHook(url string, token string, server *Server) *WebHook {
 	}
 
 	// 设置超时时间
-	if server.conf.Satori.WebHook.Timeout > 0 {
-		webhook.client.SetTimeout(time.Duration(server.conf.Satori.WebHook.Timeout) * time.Second)
+	if timeout := config.GetWebHookConfig().Timeout; timeout > 0 {
+		webhook.client.SetTimeout(time.Duration(timeout) * time.Second)
 	}
 
 	// 返回 WebHook 客户端
@@ -117,3 +118,11 @@ func (w *WebHook) PostEvent(event *operation.Event) error {
 func (w *WebHook) GetURL() string {
 	return w.url
 }
+
+// SetTimeout 设置请求超时时间，为 0 时不限制
+func (w *WebHook) SetTimeout(timeout time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.client.SetTimeout(timeout)
+}
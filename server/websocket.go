@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/WindowsSov8forUs/glyccat/config"
 	"github.com/WindowsSov8forUs/glyccat/log"
 	"github.com/WindowsSov8forUs/glyccat/operation"
 	"github.com/WindowsSov8forUs/glyccat/processor"
@@ -32,9 +33,11 @@ var upgrader = websocket.Upgrader{
 }
 
 // WebSocketHandler 对外暴露的 WebSocket 处理函数
-func (server *Server) WebSocketHandler(token string) gin.HandlerFunc {
+//
+// 鉴权令牌在建立连接时读取，重新加载配置后新的连接使用新的令牌
+func (server *Server) WebSocketHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		webSocketHandler(token, server, c)
+		webSocketHandler(config.GetSatoriToken(), server, c)
 	}
 }
 
@@ -83,7 +86,7 @@ func webSocketHandler(token string, server *Server, c *gin.Context) {
 				if err := json.Unmarshal(body, &identify); err != nil {
 					continue
 				}
-				log.Infof("收到鉴权信令，鉴权令牌: %s", identify.Token)
+				log.Info("收到鉴权信令。")
 				if !ws.authorize(identify.Token) {
 					// 鉴权失败
 					log.Warn("鉴权失败，请重新进行鉴权")
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/WindowsSov8forUs/glyccat/database"
+	"github.com/WindowsSov8forUs/glyccat/fileserver"
+	"github.com/WindowsSov8forUs/glyccat/lifecycle"
+	"github.com/WindowsSov8forUs/glyccat/log"
+	"github.com/WindowsSov8forUs/glyccat/processor"
+	"github.com/WindowsSov8forUs/glyccat/server"
+)
+
+// shutdownTimeout 等待事件推送完成与各子系统关闭的总时间
+const shutdownTimeout = 15 * time.Second
+
+// shutdown 依次停止接收 QQ 开放平台的事件、等待事件推送完成、关闭 Satori 服务端与所有数据库，返回退出码
+//
+// 关闭期间再次收到退出信号时立即退出
+func shutdown(p *processor.Processor, satoriServer *server.Server, sigCh <-chan os.Signal) int {
+	manager := lifecycle.New()
+	manager.Register("QQ 开放平台连接", func(context.Context) error {
+		return p.Stop()
+	})
+	manager.Register("Satori 服务端", satoriServer.Shutdown)
+	// 数据库可能仍在被 Satori 服务端的请求使用，需要等待服务端关闭后再关闭
+	manager.RegisterStrict("消息与成员数据库", func(context.Context) error {
+		return database.Close()
+	})
+	manager.RegisterStrict("文件服务器数据库", func(context.Context) error {
+		return fileserver.Close()
+	})
+
+	go func() {
+		for sig := range sigCh {
+			if isReloadSignal(sig) {
+				continue
+			}
+			log.Warn("再次收到退出信号，立即退出")
+			os.Exit(1)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := manager.Shutdown(ctx); err != nil {
+		log.Errorf("未能正常关闭: %v", err)
+		return 1
+	}
+	log.Info("已正常关闭")
+	return 0
+}
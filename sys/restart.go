@@ -49,6 +49,12 @@ func isRunningInTerminal() bool {
 	return os.Stdout.Fd() != 0 && isatty.IsTerminal(os.Stdout.Fd())
 }
 
+// IsInteractive 检查标准输入是否连接到终端，Docker 与 systemd 等环境下无法进行交互
+func IsInteractive() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
 // InitBase 解析参数并检测
 func InitBase() {
 	switch runtime.GOOS {